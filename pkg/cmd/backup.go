@@ -0,0 +1,379 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	backupBucketKind = "BackupBucket"
+	backupEntryKind  = "BackupEntry"
+)
+
+// printBackupBuckets lists all backup buckets of the targeted garden
+func printBackupBuckets(target TargetInterface, writer io.Writer, outFormat string) error {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+	bucketList, err := gardenClientset.CoreV1beta1().BackupBuckets().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var buckets BackupBuckets
+	for _, bucket := range bucketList.Items {
+		buckets.BackupBuckets = append(buckets.BackupBuckets, toBackupBucketMeta(bucket))
+	}
+
+	return PrintoutObject(buckets, writer, outFormat)
+}
+
+// printBackupEntries lists the backup entries of the targeted project (or of all projects), optionally only the one of the given shoot
+func printBackupEntries(target TargetInterface, shootName string, writer io.Writer, outFormat string) error {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+
+	namespace := metav1.NamespaceAll
+	if len(target.Stack()) > 1 && target.Stack()[1].Kind == TargetKindProject {
+		project, err := gardenClientset.CoreV1beta1().Projects().Get(target.Stack()[1].Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if project.Spec.Namespace == nil {
+			// the namespace of a new project is not created yet, so it cannot contain backup entries
+			return PrintoutObject(BackupEntries{}, writer, outFormat)
+		}
+		namespace = *project.Spec.Namespace
+	}
+
+	entryList, err := gardenClientset.CoreV1beta1().BackupEntries(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var entryNames map[string]bool
+	if shootName != "" {
+		shootList, err := gardenClientset.CoreV1beta1().Shoots(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		entryNames = make(map[string]bool)
+		for _, shoot := range shootList.Items {
+			if shoot.Name == shootName {
+				entryNames[backupEntryNameForShoot(&shoot)] = true
+			}
+		}
+		if len(entryNames) == 0 {
+			return fmt.Errorf("no shoot found with name %q", shootName)
+		}
+	}
+
+	projects, err := projectNamesByNamespace(gardenClientset)
+	if err != nil {
+		return err
+	}
+
+	var entries BackupEntries
+	for _, entry := range entryList.Items {
+		if entryNames != nil && !entryNames[entry.Name] {
+			continue
+		}
+		entries.BackupEntries = append(entries.BackupEntries, toBackupEntryMeta(entry, projects[entry.Namespace]))
+	}
+
+	return PrintoutObject(entries, writer, outFormat)
+}
+
+// printShootBackupEntry prints the given backup entry or the one of the targeted shoot together with its bucket and warns about failing reconciliations
+func printShootBackupEntry(target TargetInterface, name string, ioStreams IOStreams, outFormat string) error {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+	r := &resourceResolver{target: target, gardenClientset: gardenClientset}
+
+	entry, err := r.getBackupEntry(name)
+	if err != nil {
+		return err
+	}
+	shootName := backupEntryShootName(entry)
+	if name == "" {
+		shootName = r.shoot.Name
+	}
+	bucket, err := gardenClientset.CoreV1beta1().BackupBuckets().Get(entry.Spec.BucketName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	projects, err := projectNamesByNamespace(gardenClientset)
+	if err != nil {
+		return err
+	}
+	shootBackup := ShootBackup{
+		Shoot:  shootName,
+		Entry:  toBackupEntryMeta(*entry, projects[entry.Namespace]),
+		Bucket: toBackupBucketMeta(*bucket),
+	}
+	if issue, ok := backupEntryIssue(*entry); ok {
+		shootBackup.Issues = append(shootBackup.Issues, issue)
+	}
+	if issue, ok := backupBucketIssue(*bucket); ok {
+		shootBackup.Issues = append(shootBackup.Issues, issue)
+	}
+
+	if err := PrintoutObject(shootBackup, ioStreams.Out, outFormat); err != nil {
+		return err
+	}
+	for _, issue := range shootBackup.Issues {
+		if issue.Severity == SeverityInfo {
+			continue
+		}
+		warning := fmt.Sprintf("\n%s %s is failing (%s): %s\n", issue.Kind, issue.Name, issue.Severity, issue.Status.LastOperation.Description)
+		if isTerminal(ioStreams.ErrOut) {
+			warning = fmt.Sprintf(warningColor, warning)
+		}
+		fmt.Fprint(ioStreams.ErrOut, warning)
+	}
+
+	return nil
+}
+
+// getBackupEntryForShoot returns the backup entry of a shoot
+func getBackupEntryForShoot(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot) (*gardencorev1beta1.BackupEntry, error) {
+	entry, err := gardenClientset.CoreV1beta1().BackupEntries(shoot.Namespace).Get(backupEntryNameForShoot(shoot), metav1.GetOptions{})
+	if err == nil {
+		return entry, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Fall back to the owner reference and the technical id, the shoot UID is not part of the name for entries of older shoots
+	entryList, err := gardenClientset.CoreV1beta1().BackupEntries(shoot.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index, e := range entryList.Items {
+		for _, owner := range e.OwnerReferences {
+			if shoot.UID != "" && owner.UID == shoot.UID {
+				return &entryList.Items[index], nil
+			}
+		}
+	}
+	for index, e := range entryList.Items {
+		// the technical id of another shoot may start with the one of this shoot, e.g. shoot--prod--app2 and shoot--prod--app
+		if shoot.Status.TechnicalID != "" && (e.Name == shoot.Status.TechnicalID || strings.HasPrefix(e.Name, shoot.Status.TechnicalID+"--")) {
+			return &entryList.Items[index], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no backup entry found for shoot %q", shoot.Name)
+}
+
+// backupEntryShootName returns the name of the shoot owning the backup entry, if any
+func backupEntryShootName(entry *gardencorev1beta1.BackupEntry) string {
+	for _, owner := range entry.OwnerReferences {
+		if owner.Kind == "Shoot" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// backupEntryNameForShoot returns the name gardener uses for the backup entry of a shoot
+func backupEntryNameForShoot(shoot *gardencorev1beta1.Shoot) string {
+	return fmt.Sprintf("%s--%s", shoot.Status.TechnicalID, shoot.Status.UID)
+}
+
+// getBackupIssues returns the failing backup buckets and backup entries of the garden
+func getBackupIssues(gardenClientset gardencoreclientset.Interface) ([]BackupIssueMeta, error) {
+	var issues []BackupIssueMeta
+
+	bucketList, err := gardenClientset.CoreV1beta1().BackupBuckets().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range bucketList.Items {
+		if issue, ok := backupBucketIssue(bucket); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	entryList, err := gardenClientset.CoreV1beta1().BackupEntries(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entryList.Items {
+		if issue, ok := backupEntryIssue(entry); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// backupBucketIssue returns an issue if the last reconciliation of the bucket did not succeed
+func backupBucketIssue(bucket gardencorev1beta1.BackupBucket) (BackupIssueMeta, bool) {
+	severity, ok := backupSeverity(bucket.Status.LastOperation, bucket.Status.LastError)
+	if !ok {
+		return BackupIssueMeta{}, false
+	}
+
+	return BackupIssueMeta{
+		Kind:     backupBucketKind,
+		Name:     bucket.Name,
+		Seed:     stringValue(bucket.Spec.SeedName),
+		Severity: severity,
+		Status:   toStatusMeta(bucket.Status.LastOperation, bucket.Status.LastError),
+	}, true
+}
+
+// backupEntryIssue returns an issue if the last reconciliation of the entry did not succeed
+func backupEntryIssue(entry gardencorev1beta1.BackupEntry) (BackupIssueMeta, bool) {
+	severity, ok := backupSeverity(entry.Status.LastOperation, entry.Status.LastError)
+	if !ok {
+		return BackupIssueMeta{}, false
+	}
+
+	return BackupIssueMeta{
+		Kind:      backupEntryKind,
+		Name:      entry.Name,
+		Namespace: entry.Namespace,
+		Seed:      stringValue(entry.Spec.SeedName),
+		Severity:  severity,
+		Status:    toStatusMeta(entry.Status.LastOperation, entry.Status.LastError),
+	}, true
+}
+
+// backupSeverity returns the severity of a backup resource and whether it has an issue at all
+func backupSeverity(lastOperation *gardencorev1beta1.LastOperation, lastError *gardencorev1beta1.LastError) (Severity, bool) {
+	severity := lastOperationSeverity(lastOperation)
+	if lastError != nil && (severity == "" || severity == SeverityInfo) {
+		severity = SeverityWarning
+	}
+
+	return severity, severity != ""
+}
+
+// lastOperationSeverity maps the state of a last operation to a severity, an empty severity means the operation succeeded
+func lastOperationSeverity(lastOperation *gardencorev1beta1.LastOperation) Severity {
+	if lastOperation == nil {
+		return SeverityWarning
+	}
+
+	switch lastOperation.State {
+	case gardencorev1beta1.LastOperationStateSucceeded:
+		return ""
+	case gardencorev1beta1.LastOperationStateFailed:
+		return SeverityError
+	case gardencorev1beta1.LastOperationStateError, gardencorev1beta1.LastOperationStateAborted:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+func toBackupBucketMeta(bucket gardencorev1beta1.BackupBucket) BackupBucketMeta {
+	meta := BackupBucketMeta{
+		Name:          bucket.Name,
+		Seed:          stringValue(bucket.Spec.SeedName),
+		Provider:      bucket.Spec.Provider.Type,
+		Region:        bucket.Spec.Provider.Region,
+		LastOperation: toLastOperationMeta(bucket.Status.LastOperation),
+	}
+	if bucket.Status.LastError != nil {
+		meta.LastError = bucket.Status.LastError.Description
+	}
+
+	return meta
+}
+
+func toBackupEntryMeta(entry gardencorev1beta1.BackupEntry, projectName string) BackupEntryMeta {
+	meta := BackupEntryMeta{
+		Name:          entry.Name,
+		Project:       projectName,
+		Bucket:        entry.Spec.BucketName,
+		Seed:          stringValue(entry.Spec.SeedName),
+		LastOperation: toLastOperationMeta(entry.Status.LastOperation),
+	}
+	if entry.Status.LastError != nil {
+		meta.LastError = entry.Status.LastError.Description
+	}
+
+	return meta
+}
+
+func toStatusMeta(lastOperation *gardencorev1beta1.LastOperation, lastError *gardencorev1beta1.LastError) StatusMeta {
+	statusMeta := StatusMeta{
+		LastOperation: toLastOperationMeta(lastOperation),
+	}
+	if lastOperation == nil {
+		statusMeta.LastOperation.Description = "Not processed (!)"
+	}
+	if lastError != nil {
+		statusMeta.LastErrors = append(statusMeta.LastErrors, lastError.Description)
+	}
+
+	return statusMeta
+}
+
+func toLastOperationMeta(lastOperation *gardencorev1beta1.LastOperation) LastOperationMeta {
+	if lastOperation == nil {
+		return LastOperationMeta{}
+	}
+
+	return LastOperationMeta{
+		Description:    lastOperation.Description,
+		LastUpdateTime: lastOperation.LastUpdateTime.String(),
+		Progress:       int(lastOperation.Progress),
+		State:          string(lastOperation.State),
+		Type:           string(lastOperation.Type),
+	}
+}
+
+// projectNamesByNamespace maps project namespaces to project names
+func projectNamesByNamespace(gardenClientset gardencoreclientset.Interface) (map[string]string, error) {
+	projectList, err := gardenClientset.CoreV1beta1().Projects().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]string)
+	for _, project := range projectList.Items {
+		if project.Spec.Namespace != nil {
+			projects[*project.Spec.Namespace] = project.Name
+		}
+	}
+
+	return projects, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
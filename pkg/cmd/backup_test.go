@@ -0,0 +1,256 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Backup", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		stack        []cmd.TargetMeta
+		gardenClient *gardencorefake.Clientset
+	)
+
+	seedName := "test-seed"
+	projectNamespace := "garden-prod"
+	targetMeta := []cmd.TargetMeta{
+		{
+			Kind: cmd.TargetKindGarden,
+			Name: "test-garden",
+		},
+	}
+	shootTargetMeta := func(project, shoot string) []cmd.TargetMeta {
+		return append(targetMeta,
+			cmd.TargetMeta{Kind: cmd.TargetKindProject, Name: project},
+			cmd.TargetMeta{Kind: cmd.TargetKindShoot, Name: shoot},
+		)
+	}
+
+	clientSet := gardencorefake.NewSimpleClientset(
+		&gardencorev1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+		},
+		&gardencorev1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "fresh"},
+		},
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+			Status: gardencorev1beta1.ShootStatus{
+				TechnicalID: "shoot--prod--test-shoot",
+				UID:         "1234",
+			},
+		},
+		&gardencorev1beta1.BackupBucket{
+			ObjectMeta: metav1.ObjectMeta{Name: "bucket-ok"},
+			Spec: gardencorev1beta1.BackupBucketSpec{
+				Provider: gardencorev1beta1.BackupBucketProvider{Type: "aws", Region: "eu-west-1"},
+				SeedName: &seedName,
+			},
+			Status: gardencorev1beta1.BackupBucketStatus{
+				LastOperation: &gardencorev1beta1.LastOperation{
+					State: gardencorev1beta1.LastOperationStateSucceeded,
+					Type:  gardencorev1beta1.LastOperationTypeReconcile,
+				},
+			},
+		},
+		&gardencorev1beta1.BackupEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--prod--test-shoot--1234", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.BackupEntrySpec{BucketName: "bucket-ok", SeedName: &seedName},
+			Status: gardencorev1beta1.BackupEntryStatus{
+				LastOperation: &gardencorev1beta1.LastOperation{
+					Description: "access denied",
+					State:       gardencorev1beta1.LastOperationStateFailed,
+					Type:        gardencorev1beta1.LastOperationTypeReconcile,
+				},
+				LastError: &gardencorev1beta1.LastError{Description: "access denied"},
+			},
+		},
+		&gardencorev1beta1.BackupEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--prod--other--5678", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.BackupEntrySpec{BucketName: "bucket-ok", SeedName: &seedName},
+		},
+		// app and app2 are shoots whose technical ids share a prefix, app has no backup entry
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+			Status:     gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--app", UID: "1111"},
+		},
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "app2", Namespace: projectNamespace, UID: "2222"},
+			Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+			Status:     gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--app2", UID: "2222"},
+		},
+		&gardencorev1beta1.BackupEntry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "shoot--prod--app2--legacy",
+				Namespace:       projectNamespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Shoot", Name: "app2", UID: "2222"}},
+			},
+			Spec: gardencorev1beta1.BackupEntrySpec{BucketName: "bucket-ok", SeedName: &seedName},
+		},
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		stack = targetMeta
+		gardenClient = clientSet
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) { return gardenClient, nil }).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list backup buckets", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"backupbuckets"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("name: bucket-ok"))
+		Expect(out.String()).To(ContainSubstring("provider: aws"))
+		Expect(out.String()).To(ContainSubstring("region: eu-west-1"))
+		Expect(out.String()).To(ContainSubstring("seed: test-seed"))
+	})
+
+	It("should only list the backup entry of the given shoot", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"backupentries", "--shoot", "test-shoot"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("name: shoot--prod--test-shoot--1234"))
+		Expect(out.String()).To(ContainSubstring("project: prod"))
+		Expect(out.String()).To(ContainSubstring("lastError: access denied"))
+		Expect(out.String()).NotTo(ContainSubstring("shoot--prod--other--5678"))
+	})
+
+	It("should return error for unknown shoot", func() {
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"backupentries", "--shoot", "unknown"})
+		err := command.Execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("no shoot found with name \"unknown\""))
+	})
+
+	It("should list no backup entries of a project without namespace", func() {
+		stack = append(targetMeta, cmd.TargetMeta{Kind: cmd.TargetKindProject, Name: "fresh"})
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"backupentries"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).NotTo(ContainSubstring("name:"))
+	})
+
+	It("should include failing backup entries in the issues", func() {
+		gardenClient = gardencorefake.NewSimpleClientset(&gardencorev1beta1.BackupEntry{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--prod--test-shoot--1234", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.BackupEntrySpec{BucketName: "bucket-ok", SeedName: &seedName},
+			Status: gardencorev1beta1.BackupEntryStatus{
+				LastOperation: &gardencorev1beta1.LastOperation{
+					Description: "access denied",
+					State:       gardencorev1beta1.LastOperationStateFailed,
+					Type:        gardencorev1beta1.LastOperationTypeReconcile,
+				},
+			},
+		})
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"issues", "--include-backups"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("backupIssues:"))
+		Expect(out.String()).To(ContainSubstring("name: shoot--prod--test-shoot--1234"))
+		Expect(out.String()).To(ContainSubstring("access denied"))
+	})
+
+	It("should get the backup entry of the targeted shoot", func() {
+		stack = shootTargetMeta("prod", "test-shoot")
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, nil, nil, ioStreams)
+		command.SetArgs([]string{"backupentry"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("shoot: test-shoot"))
+		Expect(out.String()).To(ContainSubstring("name: shoot--prod--test-shoot--1234"))
+		Expect(out.String()).To(ContainSubstring("name: bucket-ok"))
+		Expect(errOut.String()).To(ContainSubstring("shoot--prod--test-shoot--1234 is failing"))
+		Expect(errOut.String()).NotTo(ContainSubstring("\x1b["))
+	})
+
+	It("should not get the backup entry of a shoot whose technical id starts with the one of the targeted shoot", func() {
+		stack = shootTargetMeta("prod", "app")
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, nil, nil, ioStreams)
+		command.SetArgs([]string{"backupentry"})
+		err := command.Execute()
+
+		Expect(err).To(MatchError("no backup entry found for shoot \"app\""))
+	})
+
+	It("should get the backup entry owned by the targeted shoot", func() {
+		stack = shootTargetMeta("prod", "app2")
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, nil, nil, ioStreams)
+		command.SetArgs([]string{"backupentry"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("name: shoot--prod--app2--legacy"))
+	})
+
+	It("should get a backup entry by name with the same structure as the one of the targeted shoot", func() {
+		stack = append(targetMeta, cmd.TargetMeta{Kind: cmd.TargetKindProject, Name: "prod"})
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, nil, nil, ioStreams)
+		command.SetArgs([]string{"backupentry", "shoot--prod--app2--legacy", "--expand"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("shoot: app2"))
+		Expect(out.String()).To(ContainSubstring("name: shoot--prod--app2--legacy"))
+		Expect(out.String()).To(ContainSubstring("project: prod"))
+		Expect(out.String()).To(ContainSubstring("name: bucket-ok"))
+	})
+})
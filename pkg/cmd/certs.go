@@ -0,0 +1,498 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// certificateHandshakeTimeout is the maximum time to wait for the TLS handshake with a kube-apiserver
+	certificateHandshakeTimeout = 10 * time.Second
+	// defaultCertificateWindow is the default time before their expiry certificates are flagged
+	defaultCertificateWindow = 30 * 24 * time.Hour
+)
+
+// states of checked certificates
+const (
+	certificateValid    = "valid"
+	certificateExpiring = "expiring"
+	certificateExpired  = "expired"
+)
+
+// namedCertificate is a certificate with a description of where it was found
+type namedCertificate struct {
+	name        string
+	certificate *x509.Certificate
+}
+
+// NewCertsCmd returns a new certs command.
+func NewCertsCmd(targetReader TargetReader, kubeconfigReader KubeconfigReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Inspect the certificates of shoots, e.g. \"gardenctl certs check --within 720h\"",
+	}
+	cmd.AddCommand(newCertsCheckCmd(targetReader, kubeconfigReader, ioStreams))
+
+	return cmd
+}
+
+func newCertsCheckCmd(targetReader TargetReader, kubeconfigReader KubeconfigReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		within      time.Duration
+		allShoots   bool
+		concurrency int
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the expiry of the certificates of the targeted shoot or all shoots, e.g. \"gardenctl certs check --all-shoots\"",
+		Long: fmt.Sprintf(`Check the expiry of the certificates of the targeted shoot. The following certificates are checked:
+  kubeconfig secret         the CA and client certificates of the <shoot>.kubeconfig secret in the project namespace
+  cached shoot kubeconfig   the CA and client certificates of the shoot kubeconfig cached by gardenctl target
+  cached seed kubeconfig    the CA and client certificates of the seed kubeconfig cached by gardenctl target
+  kube-apiserver            the serving certificate presented by the kube-apiserver in a TLS handshake
+  control plane secret      the certificates (*.crt) of the secrets in the control plane namespace on the seed
+
+With --all-shoots the shoots of the targeted project, or of the whole garden if no project is targeted, are checked
+concurrently. Only the kubeconfig secret and the kube-apiserver are checked for them.
+
+Exit codes:
+  0  no certificate expires within --within
+  %d  a certificate has expired or expires within --within`, exitCodeFindings),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: certs check [--within duration] [--all-shoots]")
+			}
+			if within < 0 {
+				return fmt.Errorf("invalid --within %s, must not be negative", within)
+			}
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+
+			var shoots []gardencorev1beta1.Shoot
+			if allShoots {
+				var err error
+				if shoots, err = listCertificateShoots(target); err != nil {
+					return err
+				}
+			} else {
+				if !CheckShootIsTargeted(target) {
+					return errors.New("no shoot targeted, target a shoot or use --all-shoots")
+				}
+				_, targeted, err := resolveShoots(target, "", "")
+				if err != nil {
+					return err
+				}
+				shoots = targeted
+			}
+
+			gardenClient, gardenErr := target.K8SClientToKind(TargetKindGarden)
+			now := time.Now()
+			var (
+				mutex    sync.Mutex
+				results  = map[string][]CertificateMeta{}
+				warnings = map[string][]string{}
+			)
+			err := forEachShoot(shoots, concurrency, ioStreams.ErrOut, func(shoot *gardencorev1beta1.Shoot) error {
+				checker := &certificateChecker{shoot: shoot, now: now, within: within}
+				checker.checkKubeconfigSecret(gardenClient, gardenErr)
+				if !allShoots {
+					checker.checkCachedKubeconfigs(target, kubeconfigReader)
+				}
+				checker.checkAPIServer()
+				if !allShoots {
+					checker.checkControlPlaneSecrets(target)
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				results[shootKey(shoot)] = checker.certificates
+				warnings[shootKey(shoot)] = checker.warnings
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			var certificates []CertificateMeta
+			for _, shoot := range shoots {
+				for _, warning := range warnings[shootKey(&shoot)] {
+					fmt.Fprintf(ioStreams.ErrOut, "Warning: %s: %s\n", shootKey(&shoot), warning)
+				}
+				certificates = append(certificates, results[shootKey(&shoot)]...)
+			}
+			if outFormat := outputFormatOrTable(cmd); outFormat != tableOutputFormat {
+				if err := PrintoutObject(certificates, ioStreams.Out, outFormat); err != nil {
+					return err
+				}
+			} else {
+				printCertificates(certificates, now, ioStreams.Out)
+			}
+
+			flagged := 0
+			for _, certificate := range certificates {
+				if certificate.Status != certificateValid {
+					flagged++
+				}
+			}
+			if flagged > 0 {
+				return &exitError{code: exitCodeFindings, err: fmt.Errorf("found %d certificates which expired or expire within %s", flagged, within)}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&within, "within", defaultCertificateWindow, "flag certificates which expire within this duration")
+	cmd.Flags().BoolVar(&allShoots, "all-shoots", false, "check all shoots of the targeted project or garden")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultShootConcurrency, "maximum number of shoots checked in parallel with --all-shoots")
+
+	return cmd
+}
+
+// listCertificateShoots returns the shoots of the targeted project, or of all projects if no project is targeted
+func listCertificateShoots(target TargetInterface) ([]gardencorev1beta1.Shoot, error) {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return nil, err
+	}
+	r := &resourceResolver{target: target, gardenClientset: gardenClientset}
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	list, err := gardenClientset.CoreV1beta1().Shoots(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, errors.New("no shoots found")
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return shootKey(&list.Items[i]) < shootKey(&list.Items[j])
+	})
+	return list.Items, nil
+}
+
+// certificateChecker collects the certificates of a shoot and the sources which could not be checked
+type certificateChecker struct {
+	shoot        *gardencorev1beta1.Shoot
+	now          time.Time
+	within       time.Duration
+	server       string
+	certificates []CertificateMeta
+	warnings     []string
+}
+
+// add records the certificates found in the source
+func (c *certificateChecker) add(source string, certificates []namedCertificate) {
+	for _, named := range certificates {
+		notAfter := named.certificate.NotAfter
+		status := certificateValid
+		switch {
+		case !c.now.Before(notAfter):
+			status = certificateExpired
+		case c.now.Add(c.within).After(notAfter):
+			status = certificateExpiring
+		}
+		c.certificates = append(c.certificates, CertificateMeta{
+			Shoot:       shootKey(c.shoot),
+			Source:      source,
+			Certificate: named.name,
+			Subject:     named.certificate.Subject.String(),
+			NotAfter:    notAfter.UTC().Format(time.RFC3339),
+			Status:      status,
+		})
+	}
+}
+
+// warn records that the source could not be checked
+func (c *certificateChecker) warn(source string, err error) {
+	c.warnings = append(c.warnings, fmt.Sprintf("%s cannot be checked: %v", source, err))
+}
+
+// checkKubeconfig records the certificates of the kubeconfig and remembers its server for the TLS handshake
+func (c *certificateChecker) checkKubeconfig(source string, kubeconfig []byte, shootKubeconfig bool) {
+	certificates, server, err := kubeconfigCertificates(kubeconfig)
+	if err != nil {
+		c.warn(source, err)
+		return
+	}
+	c.add(source, certificates)
+	if shootKubeconfig && c.server == "" {
+		c.server = server
+	}
+}
+
+// checkKubeconfigSecret checks the kubeconfig secret of the shoot in the project namespace
+func (c *certificateChecker) checkKubeconfigSecret(gardenClient kubernetes.Interface, gardenErr error) {
+	const source = "kubeconfig secret"
+	if gardenErr != nil {
+		c.warn(source, gardenErr)
+		return
+	}
+	secret, err := gardenClient.CoreV1().Secrets(c.shoot.Namespace).Get(c.shoot.Name+".kubeconfig", metav1.GetOptions{})
+	if err != nil {
+		c.warn(source, err)
+		return
+	}
+	c.checkKubeconfig(source, secret.Data["kubeconfig"], true)
+}
+
+// checkCachedKubeconfigs checks the shoot and seed kubeconfigs cached by gardenctl target, kubeconfigs which are not
+// cached are skipped
+func (c *certificateChecker) checkCachedKubeconfigs(target TargetInterface, kubeconfigReader KubeconfigReader) {
+	stack := target.Stack()
+	cacheDir := filepath.Join(pathGardenHome, "cache", stack[0].Name)
+	paths := map[string]string{}
+	switch stack[1].Kind {
+	case TargetKindProject:
+		paths["cached shoot kubeconfig"] = filepath.Join(cacheDir, "projects", stack[1].Name, c.shoot.Name, "kubeconfig.yaml")
+	case TargetKindSeed:
+		paths["cached shoot kubeconfig"] = filepath.Join(cacheDir, "seeds", stack[1].Name, c.shoot.Name, "kubeconfig.yaml")
+	}
+	if seedName := stringValue(c.shoot.Spec.SeedName); seedName != "" {
+		paths["cached seed kubeconfig"] = filepath.Join(cacheDir, "seeds", seedName, "kubeconfig.yaml")
+	}
+
+	for _, source := range []string{"cached shoot kubeconfig", "cached seed kubeconfig"} {
+		path, ok := paths[source]
+		if !ok {
+			continue
+		}
+		kubeconfig, err := kubeconfigReader.ReadKubeconfig(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			c.warn(source, err)
+			continue
+		}
+		c.checkKubeconfig(source, kubeconfig, source == "cached shoot kubeconfig")
+	}
+}
+
+// checkAPIServer checks the serving certificate of the kube-apiserver of the shoot
+func (c *certificateChecker) checkAPIServer() {
+	const source = "kube-apiserver"
+	switch {
+	case c.shoot.Status.IsHibernated:
+		c.warn(source, errors.New("shoot is hibernated"))
+		return
+	case c.server == "":
+		c.warn(source, errors.New("the server of the shoot is unknown"))
+		return
+	}
+	certificate, err := servingCertificate(c.server, certificateHandshakeTimeout)
+	if err != nil {
+		c.warn(source, err)
+		return
+	}
+	c.add(source, []namedCertificate{{name: "serving certificate", certificate: certificate}})
+}
+
+// checkControlPlaneSecrets checks the certificates of the secrets in the control plane namespace of the shoot
+func (c *certificateChecker) checkControlPlaneSecrets(target TargetInterface) {
+	const source = "control plane secret"
+	if c.shoot.Status.TechnicalID == "" {
+		c.warn(source, errors.New("shoot has no control plane namespace"))
+		return
+	}
+	seedClient, err := target.K8SClientToKind(TargetKindSeed)
+	if err != nil {
+		c.warn(source, err)
+		return
+	}
+	secrets, err := seedClient.CoreV1().Secrets(c.shoot.Status.TechnicalID).List(metav1.ListOptions{})
+	if err != nil {
+		c.warn(source, err)
+		return
+	}
+	sort.Slice(secrets.Items, func(i, j int) bool {
+		return secrets.Items[i].Name < secrets.Items[j].Name
+	})
+	for _, secret := range secrets.Items {
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			if strings.HasSuffix(key, ".crt") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			certificates, err := parseCertificates(secret.Name+"/"+key, secret.Data[key])
+			if err != nil {
+				c.warn(source+" "+secret.Name, err)
+				continue
+			}
+			c.add(source, certificates)
+		}
+	}
+}
+
+// kubeconfigCertificates returns the CA and client certificates embedded in the kubeconfig and the server of its
+// current context
+func kubeconfigCertificates(kubeconfig []byte) ([]namedCertificate, string, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var certificates []namedCertificate
+	clusterNames := make([]string, 0, len(config.Clusters))
+	for name := range config.Clusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	for _, name := range clusterNames {
+		if data := config.Clusters[name].CertificateAuthorityData; len(data) > 0 {
+			parsed, err := parseCertificates("cluster "+name+" CA", data)
+			if err != nil {
+				return nil, "", err
+			}
+			certificates = append(certificates, parsed...)
+		}
+	}
+	userNames := make([]string, 0, len(config.AuthInfos))
+	for name := range config.AuthInfos {
+		userNames = append(userNames, name)
+	}
+	sort.Strings(userNames)
+	for _, name := range userNames {
+		if data := config.AuthInfos[name].ClientCertificateData; len(data) > 0 {
+			parsed, err := parseCertificates("user "+name+" client certificate", data)
+			if err != nil {
+				return nil, "", err
+			}
+			certificates = append(certificates, parsed...)
+		}
+	}
+
+	server := ""
+	if context, ok := config.Contexts[config.CurrentContext]; ok {
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			server = cluster.Server
+		}
+	}
+	return certificates, server, nil
+}
+
+// parseCertificates returns all PEM encoded certificates in the data
+func parseCertificates(name string, data []byte) ([]namedCertificate, error) {
+	var certificates []namedCertificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		certificates = append(certificates, namedCertificate{name: name, certificate: certificate})
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("%s: no PEM encoded certificate found", name)
+	}
+	// several certificates in one file, e.g. a CA bundle, are numbered to tell them apart
+	if len(certificates) > 1 {
+		for i := range certificates {
+			certificates[i].name = fmt.Sprintf("%s #%d", name, i+1)
+		}
+	}
+	return certificates, nil
+}
+
+// servingCertificate returns the certificate the server presents in a TLS handshake. The certificate is only
+// inspected, so it is not verified.
+func servingCertificate(server string, timeout time.Duration) (*x509.Certificate, error) {
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		address = net.JoinHostPort(serverURL.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+		ServerName:         serverURL.Hostname(),
+		InsecureSkipVerify: true, // #nosec G402 the certificate is inspected, not trusted
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	peerCertificates := conn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return nil, fmt.Errorf("%s presented no certificate", address)
+	}
+	return peerCertificates[0], nil
+}
+
+// printCertificates prints the checked certificates as a table
+func printCertificates(certificates []CertificateMeta, now time.Time, writer io.Writer) {
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SHOOT\tSOURCE\tCERTIFICATE\tSUBJECT\tNOT AFTER\tREMAINING\tSTATUS")
+	for _, certificate := range certificates {
+		remaining := "-"
+		if notAfter, err := time.Parse(time.RFC3339, certificate.NotAfter); err == nil {
+			remaining = formatCertificateRemaining(notAfter.Sub(now))
+		}
+		status := certificate.Status
+		if status != certificateValid && isTerminal(writer) {
+			status = fmt.Sprintf(warningColor, status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", certificate.Shoot, certificate.Source, certificate.Certificate, certificate.Subject, certificate.NotAfter, remaining, status)
+	}
+	w.Flush()
+}
+
+// formatCertificateRemaining returns the time until a certificate expires in days, or hours if it is less than a day
+func formatCertificateRemaining(remaining time.Duration) string {
+	if remaining <= -24*time.Hour || remaining >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(remaining.Hours()/24))
+	}
+	return fmt.Sprintf("%dh", int(remaining.Hours()))
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Certs command", func() {
+	var (
+		ctrl             *gomock.Controller
+		targetReader     *mockcmd.MockTargetReader
+		target           *mockcmd.MockTargetInterface
+		kubeconfigReader *mockcmd.MockKubeconfigReader
+		server           *httptest.Server
+		shoots           []*gardencorev1beta1.Shoot
+		gardenClient     *kubernetesfake.Clientset
+		seedClient       *kubernetesfake.Clientset
+	)
+
+	projectNamespace := "garden-prod"
+	seedName := "aws-eu1"
+	now := time.Now()
+
+	certificate := func(commonName string, notAfter time.Time) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: commonName},
+			NotBefore:    now.Add(-365 * 24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	kubeconfig := func(ca, client []byte) []byte {
+		user := "{}"
+		if client != nil {
+			user = "\n    client-certificate-data: " + base64.StdEncoding.EncodeToString(client)
+		}
+		return []byte(`apiVersion: v1
+kind: Config
+current-context: shoot
+clusters:
+- name: shoot
+  cluster:
+    server: ` + server.URL + `
+    certificate-authority-data: ` + base64.StdEncoding.EncodeToString(ca) + `
+contexts:
+- name: shoot
+  context:
+    cluster: shoot
+    user: admin
+users:
+- name: admin
+  user: ` + user + `
+`)
+	}
+	execute := func(args ...string) (string, string, error) {
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+		command := cmd.NewCertsCmd(targetReader, kubeconfigReader, ioStreams)
+		command.SetArgs(append([]string{"check"}, args...))
+		err := command.Execute()
+		return out.String(), errOut.String(), err
+	}
+	row := func(out, shoot, source, certificate string) string {
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, shoot+" ") && strings.Contains(line, "   "+source+"   ") && strings.Contains(line, "   "+certificate+"   ") {
+				return line
+			}
+		}
+		return ""
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		kubeconfigReader = mockcmd.NewMockKubeconfigReader(ctrl)
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		// the handshakes are closed without a request
+		server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		server.StartTLS()
+
+		shoots = []*gardencorev1beta1.Shoot{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+				Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+				Status:     gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--test-shoot"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "other-shoot", Namespace: projectNamespace},
+				Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+				Status:     gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--other-shoot"},
+			},
+		}
+		gardenClient = kubernetesfake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot.kubeconfig", Namespace: projectNamespace},
+			Data: map[string][]byte{"kubeconfig": kubeconfig(
+				certificate("ca", now.Add(365*24*time.Hour)),
+				certificate("system:admin", now.Add(-24*time.Hour)),
+			)},
+		})
+		seedClient = kubernetesfake.NewSimpleClientset(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "shoot--prod--test-shoot"},
+				Data:       map[string][]byte{"ca.crt": certificate("kubernetes", now.Add(365*24*time.Hour)), "ca.key": []byte("key")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "etcd-server-cert", Namespace: "shoot--prod--test-shoot"},
+				Data:       map[string][]byte{"tls.crt": certificate("etcd-server", now.Add(5*24*time.Hour)), "tls.key": []byte("key")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "etcd-server-cert", Namespace: "shoot--prod--other-shoot"},
+				Data:       map[string][]byte{"tls.crt": certificate("other-etcd-server", now.Add(5*24*time.Hour))},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) {
+			return gardencorefake.NewSimpleClientset(
+				&gardencorev1beta1.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+					Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+				},
+				shoots[0],
+				shoots[1],
+			), nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindGarden).Return(gardenClient, nil).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).Return(seedClient, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		ctrl.Finish()
+	})
+
+	Context("with a targeted shoot", func() {
+		BeforeEach(func() {
+			target.EXPECT().Stack().Return([]cmd.TargetMeta{
+				{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+				{Kind: cmd.TargetKindProject, Name: "prod"},
+				{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+			}).AnyTimes()
+			kubeconfigReader.EXPECT().ReadKubeconfig(gomock.Any()).DoAndReturn(func(path string) ([]byte, error) {
+				switch {
+				case strings.HasSuffix(path, "cache/test-garden/projects/prod/test-shoot/kubeconfig.yaml"):
+					return kubeconfig(certificate("ca", now.Add(10*24*time.Hour)), nil), nil
+				case strings.HasSuffix(path, "cache/test-garden/seeds/aws-eu1/kubeconfig.yaml"):
+					return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+				}
+				Fail("unexpected kubeconfig " + path)
+				return nil, nil
+			}).Times(2)
+		})
+
+		It("should list the certificates and flag those which expire within the window", func() {
+			out, errOut, err := execute()
+
+			Expect(err).To(MatchError("found 3 certificates which expired or expire within 720h0m0s"))
+			Expect(cmd.ExitCode(err)).To(Equal(5))
+			Expect(errOut).To(BeEmpty())
+			Expect(strings.Split(strings.TrimSpace(out), "\n")).To(HaveLen(7))
+			Expect(row(out, "garden-prod/test-shoot", "kubeconfig secret", "cluster shoot CA")).To(MatchRegexp(`CN=ca +\S+ +364d +valid$`))
+			Expect(row(out, "garden-prod/test-shoot", "kubeconfig secret", "user admin client certificate")).To(MatchRegexp(`CN=system:admin +\S+ +-1d +expired$`))
+			Expect(row(out, "garden-prod/test-shoot", "cached shoot kubeconfig", "cluster shoot CA")).To(MatchRegexp(`CN=ca +\S+ +9d +expiring$`))
+			Expect(row(out, "garden-prod/test-shoot", "kube-apiserver", "serving certificate")).To(MatchRegexp(`O=Acme Co +\S+ +\d+d +valid$`))
+			Expect(row(out, "garden-prod/test-shoot", "control plane secret", "ca/ca.crt")).To(MatchRegexp(`CN=kubernetes .* valid$`))
+			Expect(row(out, "garden-prod/test-shoot", "control plane secret", "etcd-server-cert/tls.crt")).To(MatchRegexp(`CN=etcd-server .* 4d +expiring$`))
+			Expect(out).NotTo(ContainSubstring("other-etcd-server"))
+		})
+
+		It("should only flag expired certificates without a window", func() {
+			_, _, err := execute("--within", "0s")
+
+			Expect(err).To(MatchError("found 1 certificates which expired or expire within 0s"))
+		})
+
+		It("should warn about the kube-apiserver of a hibernated shoot", func() {
+			shoots[0].Status.IsHibernated = true
+
+			out, errOut, _ := execute()
+
+			Expect(errOut).To(Equal("Warning: garden-prod/test-shoot: kube-apiserver cannot be checked: shoot is hibernated\n"))
+			Expect(out).NotTo(ContainSubstring("serving certificate"))
+		})
+	})
+
+	It("should check the kubeconfig secrets and kube-apiservers of all shoots of the targeted project", func() {
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+		}).AnyTimes()
+
+		out, errOut, err := execute("--all-shoots")
+
+		Expect(err).To(MatchError("found 1 certificates which expired or expire within 720h0m0s"))
+		Expect(errOut).To(Equal("Warning: garden-prod/other-shoot: kubeconfig secret cannot be checked: secrets \"other-shoot.kubeconfig\" not found\n" +
+			"Warning: garden-prod/other-shoot: kube-apiserver cannot be checked: the server of the shoot is unknown\n"))
+		Expect(strings.Split(strings.TrimSpace(out), "\n")).To(HaveLen(4))
+		Expect(row(out, "garden-prod/test-shoot", "kube-apiserver", "serving certificate")).NotTo(BeEmpty())
+		Expect(out).NotTo(ContainSubstring("control plane secret"))
+	})
+
+	It("should require a targeted shoot without --all-shoots", func() {
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+		}).AnyTimes()
+
+		_, _, err := execute()
+
+		Expect(err).To(MatchError("no shoot targeted, target a shoot or use --all-shoots"))
+	})
+})
@@ -0,0 +1,519 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver"
+	openstackv1alpha1 "github.com/gardener/gardener-extension-provider-openstack/pkg/apis/openstack/v1alpha1"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultNodesCIDR is the node network of shoots created by the wizard
+	defaultNodesCIDR = "10.250.0.0/16"
+	// defaultWorkersCIDR is the worker subnet within the node network of shoots created by the wizard
+	defaultWorkersCIDR = "10.250.0.0/19"
+	// defaultPodsCIDR is the pod network of shoots created by the wizard
+	defaultPodsCIDR = "100.96.0.0/11"
+	// defaultServicesCIDR is the service network of shoots created by the wizard
+	defaultServicesCIDR = "100.64.0.0/13"
+	// defaultNetworkingType is the network plugin of shoots created by the wizard
+	defaultNetworkingType = "calico"
+)
+
+// shootCreateFlags are the flags of the create shoot command
+type shootCreateFlags struct {
+	template string
+	vars     []string
+	dryRun   bool
+}
+
+// NewCreateCmd returns a new create command.
+func NewCreateCmd(targetReader TargetReader, prompter Prompter, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create resources in the targeted project, e.g. \"gardenctl create shoot my-shoot\"",
+	}
+	cmd.AddCommand(newCreateShootCmd(targetReader, prompter, ioStreams))
+
+	return cmd
+}
+
+func newCreateShootCmd(targetReader TargetReader, prompter Prompter, ioStreams IOStreams) *cobra.Command {
+	var flags shootCreateFlags
+	cmd := &cobra.Command{
+		Use:          "shoot <name>",
+		Short:        "Create a shoot in the targeted project from a template or with an interactive wizard, e.g. \"gardenctl create shoot my-shoot --template shoot.yaml --var region=eu-west-1\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: create shoot <name>")
+			}
+			name := args[0]
+
+			target := targetReader.ReadTarget(pathTarget)
+			gardenClientset, project, err := getTargetedProject(target)
+			if err != nil {
+				return err
+			}
+			namespace := stringValue(project.Spec.Namespace)
+
+			var shoot *gardencorev1beta1.Shoot
+			if flags.template != "" {
+				shoot, err = shootFromTemplate(flags.template, flags.vars, map[string]string{"name": name, "namespace": namespace, "project": project.Name})
+			} else {
+				shoot, err = shootFromWizard(gardenClientset, namespace, prompter)
+				if err == nil && shoot.Spec.Provider.InfrastructureConfig == nil {
+					if !flags.dryRun {
+						return fmt.Errorf("the wizard cannot generate the infrastructure and control plane configuration of %s shoots, use --dry-run to print the manifest, add the configuration and create the shoot with --template", shoot.Spec.Provider.Type)
+					}
+					fmt.Fprintf(ioStreams.ErrOut, "The infrastructure and control plane configuration of %s shoots is not generated by the wizard, add it before creating the shoot\n", shoot.Spec.Provider.Type)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			shoot.Name, shoot.Namespace = name, namespace
+			setKind(shoot, "Shoot")
+
+			if flags.dryRun {
+				outFormat := "yaml"
+				if flag := cmd.Flag("output"); flag != nil && flag.Changed {
+					outFormat = outputFormat
+				}
+				return printoutResource(shoot, ioStreams.Out, outFormat)
+			}
+			if _, err := gardenClientset.CoreV1beta1().Shoots(namespace).Create(shoot); err != nil {
+				return err
+			}
+			fmt.Fprintf(ioStreams.Out, "Shoot %s created\n", shootKey(shoot))
+
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&flags.template, "template", "f", "", "shoot manifest template, variables are referenced as {{ .name }}, {{ .namespace }}, {{ .project }} or {{ .<var> }}")
+	cmd.Flags().StringArrayVar(&flags.vars, "var", nil, "template variable in the format key=value, can be given multiple times")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "print the shoot manifest instead of creating the shoot")
+
+	return cmd
+}
+
+// shootFromTemplate renders the template file with the variables and parses the result as shoot
+func shootFromTemplate(path string, vars []string, builtins map[string]string) (*gardencorev1beta1.Shoot, error) {
+	data := map[string]string{}
+	for key, value := range builtins {
+		data[key] = value
+	}
+	for _, v := range vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid variable %q, must be in the format key=value", v)
+		}
+		if _, ok := builtins[parts[0]]; ok {
+			return nil, fmt.Errorf("variable %q is set by gardenctl and cannot be overridden", parts[0])
+		}
+		data[parts[0]] = parts[1]
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+
+	shoot := &gardencorev1beta1.Shoot{}
+	if err := yaml.UnmarshalStrict(rendered.Bytes(), shoot); err != nil {
+		return nil, fmt.Errorf("invalid shoot template %s: %v", path, err)
+	}
+	if shoot.Kind != "" && shoot.Kind != "Shoot" {
+		return nil, fmt.Errorf("template %s contains a %s instead of a Shoot", path, shoot.Kind)
+	}
+	return shoot, nil
+}
+
+// shootFromWizard asks the user for the settings of the shoot, offering what is available to the project
+func shootFromWizard(gardenClientset gardencoreclientset.Interface, namespace string, prompter Prompter) (*gardencorev1beta1.Shoot, error) {
+	cloudProfiles, err := gardenClientset.CoreV1beta1().CloudProfiles().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	profileNames := make([]string, 0, len(cloudProfiles.Items))
+	for _, cloudProfile := range cloudProfiles.Items {
+		profileNames = append(profileNames, cloudProfile.Name)
+	}
+	index, err := selectSorted(prompter, "Cloud profile", profileNames)
+	if err != nil {
+		return nil, err
+	}
+	cloudProfile := cloudProfiles.Items[index]
+
+	secretBindings, err := gardenClientset.CoreV1beta1().SecretBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	bindingNames := make([]string, 0, len(secretBindings.Items))
+	for _, secretBinding := range secretBindings.Items {
+		bindingNames = append(bindingNames, secretBinding.Name)
+	}
+	index, err = selectSorted(prompter, "Secret binding", bindingNames)
+	if err != nil {
+		return nil, err
+	}
+	secretBindingName := bindingNames[index]
+
+	regionNames := make([]string, 0, len(cloudProfile.Spec.Regions))
+	for _, region := range cloudProfile.Spec.Regions {
+		regionNames = append(regionNames, region.Name)
+	}
+	index, err = selectSorted(prompter, "Region", regionNames)
+	if err != nil {
+		return nil, err
+	}
+	region := cloudProfile.Spec.Regions[index]
+
+	var zone *gardencorev1beta1.AvailabilityZone
+	if len(region.Zones) > 0 {
+		zoneNames := make([]string, 0, len(region.Zones))
+		for _, z := range region.Zones {
+			zoneNames = append(zoneNames, z.Name)
+		}
+		if index, err = selectSorted(prompter, "Zone", zoneNames); err != nil {
+			return nil, err
+		}
+		zone = &region.Zones[index]
+	}
+
+	kubernetesVersion, err := selectVersion(prompter, "Kubernetes version", cloudProfile.Spec.Kubernetes.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	machineTypes, machineTypeItems := []gardencorev1beta1.MachineType{}, []string{}
+	for _, machineType := range cloudProfile.Spec.MachineTypes {
+		if (machineType.Usable != nil && !*machineType.Usable) || (zone != nil && containsString(zone.UnavailableMachineTypes, machineType.Name)) {
+			continue
+		}
+		machineTypes = append(machineTypes, machineType)
+		machineTypeItems = append(machineTypeItems, fmt.Sprintf("%s (%s CPU, %s memory)", machineType.Name, machineType.CPU.String(), machineType.Memory.String()))
+	}
+	if index, err = selectItem(prompter, "Machine type", machineTypeItems); err != nil {
+		return nil, err
+	}
+	machineType := machineTypes[index]
+
+	imageNames := make([]string, 0, len(cloudProfile.Spec.MachineImages))
+	for _, image := range cloudProfile.Spec.MachineImages {
+		imageNames = append(imageNames, image.Name)
+	}
+	if index, err = selectItem(prompter, "Machine image", imageNames); err != nil {
+		return nil, err
+	}
+	image := cloudProfile.Spec.MachineImages[index]
+	imageVersion, err := selectVersion(prompter, "Machine image version", image.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	var volume *gardencorev1beta1.Volume
+	volumeTypes := []string{}
+	for _, volumeType := range cloudProfile.Spec.VolumeTypes {
+		if (volumeType.Usable == nil || *volumeType.Usable) && (zone == nil || !containsString(zone.UnavailableVolumeTypes, volumeType.Name)) {
+			volumeTypes = append(volumeTypes, volumeType.Name)
+		}
+	}
+	if len(volumeTypes) > 0 {
+		if index, err = selectItem(prompter, "Volume type", volumeTypes); err != nil {
+			return nil, err
+		}
+		size, err := prompter.Input("Volume size", "50Gi")
+		if err != nil {
+			return nil, err
+		}
+		volume = &gardencorev1beta1.Volume{Type: &volumeTypes[index], VolumeSize: size}
+	}
+
+	minimum, err := inputInt(prompter, "Minimum number of nodes", 1)
+	if err != nil {
+		return nil, err
+	}
+	maximum, err := inputInt(prompter, "Maximum number of nodes", minimum+1)
+	if err != nil {
+		return nil, err
+	}
+	if maximum < minimum {
+		return nil, fmt.Errorf("maximum number of nodes %d is less than the minimum %d", maximum, minimum)
+	}
+
+	purposes := []string{string(gardencorev1beta1.ShootPurposeEvaluation), string(gardencorev1beta1.ShootPurposeDevelopment), string(gardencorev1beta1.ShootPurposeTesting), string(gardencorev1beta1.ShootPurposeProduction)}
+	if index, err = selectItem(prompter, "Purpose", purposes); err != nil {
+		return nil, err
+	}
+	purpose := gardencorev1beta1.ShootPurpose(purposes[index])
+
+	worker := gardencorev1beta1.Worker{
+		Name: "worker-1",
+		Machine: gardencorev1beta1.Machine{
+			Type:  machineType.Name,
+			Image: &gardencorev1beta1.ShootMachineImage{Name: image.Name, Version: &imageVersion},
+		},
+		Minimum: int32(minimum),
+		Maximum: int32(maximum),
+		Volume:  volume,
+	}
+	if zone != nil {
+		worker.Zones = []string{zone.Name}
+	}
+	infrastructureConfig, controlPlaneConfig, err := wizardProviderConfigs(&cloudProfile, region.Name, zone, prompter)
+	if err != nil {
+		return nil, err
+	}
+	nodes, pods, services := defaultNodesCIDR, defaultPodsCIDR, defaultServicesCIDR
+
+	return &gardencorev1beta1.Shoot{
+		Spec: gardencorev1beta1.ShootSpec{
+			CloudProfileName:  cloudProfile.Name,
+			Region:            region.Name,
+			SecretBindingName: secretBindingName,
+			Purpose:           &purpose,
+			Kubernetes:        gardencorev1beta1.Kubernetes{Version: kubernetesVersion},
+			Networking:        gardencorev1beta1.Networking{Type: defaultNetworkingType, Nodes: &nodes, Pods: &pods, Services: &services},
+			Provider: gardencorev1beta1.Provider{
+				Type:                 cloudProfile.Spec.Type,
+				InfrastructureConfig: infrastructureConfig,
+				ControlPlaneConfig:   controlPlaneConfig,
+				Workers:              []gardencorev1beta1.Worker{worker},
+			},
+		},
+	}, nil
+}
+
+// wizardProviderConfigs returns a minimal infrastructure and control plane configuration for the provider of the cloud profile,
+// or nil for providers unknown to the wizard
+func wizardProviderConfigs(cloudProfile *gardencorev1beta1.CloudProfile, region string, zone *gardencorev1beta1.AvailabilityZone, prompter Prompter) (*gardencorev1beta1.ProviderConfig, *gardencorev1beta1.ProviderConfig, error) {
+	switch cloudProfile.Spec.Type {
+	case "aws", "gcp", "openstack":
+		if zone == nil {
+			return nil, nil, fmt.Errorf("region %s of cloud profile %s has no zones, but %s shoots need one", region, cloudProfile.Name, cloudProfile.Spec.Type)
+		}
+	}
+
+	var infrastructure, controlPlane interface{}
+	switch cloudProfile.Spec.Type {
+	case "aws":
+		infrastructure = map[string]interface{}{
+			"apiVersion": "aws.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"networks": map[string]interface{}{
+				"vpc": map[string]interface{}{"cidr": defaultNodesCIDR},
+				"zones": []map[string]interface{}{{
+					"name":     zone.Name,
+					"workers":  defaultWorkersCIDR,
+					"public":   "10.250.32.0/20",
+					"internal": "10.250.48.0/20",
+				}},
+			},
+		}
+		controlPlane = map[string]interface{}{
+			"apiVersion": "aws.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "ControlPlaneConfig",
+		}
+	case "azure":
+		infrastructure = map[string]interface{}{
+			"apiVersion": "azure.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"networks": map[string]interface{}{
+				"vnet":    map[string]interface{}{"cidr": defaultNodesCIDR},
+				"workers": defaultWorkersCIDR,
+			},
+			"zoned": zone != nil,
+		}
+		controlPlane = map[string]interface{}{
+			"apiVersion": "azure.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "ControlPlaneConfig",
+		}
+	case "gcp":
+		infrastructure = map[string]interface{}{
+			"apiVersion": "gcp.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"networks":   map[string]interface{}{"workers": defaultWorkersCIDR},
+		}
+		controlPlane = map[string]interface{}{
+			"apiVersion": "gcp.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "ControlPlaneConfig",
+			"zone":       zone.Name,
+		}
+	case "openstack":
+		config, err := getOpenstackCloudProfileConfig(cloudProfile)
+		if err != nil {
+			return nil, nil, err
+		}
+		floatingPools := []string{}
+		for _, pool := range config.Constraints.FloatingPools {
+			if pool.Region == nil || *pool.Region == region {
+				floatingPools = append(floatingPools, pool.Name)
+			}
+		}
+		poolIndex, err := selectSorted(prompter, "Floating pool", floatingPools)
+		if err != nil {
+			return nil, nil, err
+		}
+		loadBalancerProviders := []string{}
+		for _, provider := range config.Constraints.LoadBalancerProviders {
+			if provider.Region == nil || *provider.Region == region {
+				loadBalancerProviders = append(loadBalancerProviders, provider.Name)
+			}
+		}
+		providerIndex, err := selectSorted(prompter, "Load balancer provider", loadBalancerProviders)
+		if err != nil {
+			return nil, nil, err
+		}
+		infrastructure = &openstackv1alpha1.InfrastructureConfig{
+			TypeMeta:         metav1.TypeMeta{APIVersion: openstackv1alpha1.SchemeGroupVersion.String(), Kind: "InfrastructureConfig"},
+			FloatingPoolName: floatingPools[poolIndex],
+			Networks:         openstackv1alpha1.Networks{Workers: defaultWorkersCIDR},
+		}
+		controlPlane = &openstackv1alpha1.ControlPlaneConfig{
+			TypeMeta:             metav1.TypeMeta{APIVersion: openstackv1alpha1.SchemeGroupVersion.String(), Kind: "ControlPlaneConfig"},
+			LoadBalancerProvider: loadBalancerProviders[providerIndex],
+			Zone:                 zone.Name,
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	infrastructureConfig, err := toProviderConfig(infrastructure)
+	if err != nil {
+		return nil, nil, err
+	}
+	controlPlaneConfig, err := toProviderConfig(controlPlane)
+	if err != nil {
+		return nil, nil, err
+	}
+	return infrastructureConfig, controlPlaneConfig, nil
+}
+
+// toProviderConfig serializes the configuration into a provider config of the shoot
+func toProviderConfig(config interface{}) (*gardencorev1beta1.ProviderConfig, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return &gardencorev1beta1.ProviderConfig{RawExtension: runtime.RawExtension{Raw: raw}}, nil
+}
+
+// selectItem lets the user pick one of the items and returns its index
+func selectItem(prompter Prompter, label string, items []string) (int, error) {
+	if len(items) == 0 {
+		return 0, fmt.Errorf("no %s available", strings.ToLower(label))
+	}
+	index, err := prompter.Select(label, items)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 || index >= len(items) {
+		return 0, fmt.Errorf("invalid selection for %s", strings.ToLower(label))
+	}
+	return index, nil
+}
+
+// selectSorted lets the user pick one of the items presented in alphabetical order and returns its index in the given items
+func selectSorted(prompter Prompter, label string, items []string) (int, error) {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return items[order[i]] < items[order[j]] })
+	sorted := make([]string, 0, len(items))
+	for _, i := range order {
+		sorted = append(sorted, items[i])
+	}
+
+	index, err := selectItem(prompter, label, sorted)
+	if err != nil {
+		return 0, err
+	}
+	return order[index], nil
+}
+
+// selectVersion lets the user pick one of the versions which did not expire yet, newest first with preview versions last
+func selectVersion(prompter Prompter, label string, versions []gardencorev1beta1.ExpirableVersion) (string, error) {
+	now := time.Now()
+	candidates := []gardencorev1beta1.ExpirableVersion{}
+	for _, version := range versions {
+		if _, err := semver.NewVersion(version.Version); err != nil || (version.ExpirationDate != nil && version.ExpirationDate.Time.Before(now)) {
+			continue
+		}
+		candidates = append(candidates, version)
+	}
+	isPreview := func(version gardencorev1beta1.ExpirableVersion) bool {
+		return version.Classification != nil && *version.Classification == gardencorev1beta1.ClassificationPreview
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if isPreview(candidates[i]) != isPreview(candidates[j]) {
+			return !isPreview(candidates[i])
+		}
+		return semver.MustParse(candidates[i].Version).GreaterThan(semver.MustParse(candidates[j].Version))
+	})
+
+	items := make([]string, 0, len(candidates))
+	for _, version := range candidates {
+		item := version.Version
+		if version.Classification != nil {
+			item += " (" + string(*version.Classification) + ")"
+		}
+		items = append(items, item)
+	}
+	index, err := selectItem(prompter, label, items)
+	if err != nil {
+		return "", err
+	}
+	return candidates[index].Version, nil
+}
+
+// inputInt asks the user for a non-negative number
+func inputInt(prompter Prompter, label string, defaultValue int) (int, error) {
+	input, err := prompter.Input(label, strconv.Itoa(defaultValue))
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s %q", strings.ToLower(label), input)
+	}
+	return value, nil
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Create shoot command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		prompter     *mockcmd.MockPrompter
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+		dir          string
+	)
+
+	projectNamespace := "garden-prod"
+	preview := gardencorev1beta1.ClassificationPreview
+	notUsable := false
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewCreateCmd(targetReader, prompter, ioStreams)
+		command.SetArgs(append([]string{"shoot"}, args...))
+		err := command.Execute()
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		prompter = mockcmd.NewMockPrompter(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			&gardencorev1beta1.SecretBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-secret", Namespace: projectNamespace},
+				SecretRef:  corev1.SecretReference{Name: "aws-secret", Namespace: projectNamespace},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "gcp"},
+				Spec:       gardencorev1beta1.CloudProfileSpec{Type: "gcp"},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "alicloud"},
+				Spec: gardencorev1beta1.CloudProfileSpec{
+					Type:          "alicloud",
+					Kubernetes:    gardencorev1beta1.KubernetesSettings{Versions: []gardencorev1beta1.ExpirableVersion{{Version: "1.18.2"}}},
+					MachineImages: []gardencorev1beta1.MachineImage{{Name: "gardenlinux", Versions: []gardencorev1beta1.ExpirableVersion{{Version: "27.1.0"}}}},
+					MachineTypes:  []gardencorev1beta1.MachineType{{Name: "ecs.g6.large", CPU: resource.MustParse("2"), Memory: resource.MustParse("8Gi")}},
+					Regions:       []gardencorev1beta1.Region{{Name: "eu-central-1"}},
+				},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws"},
+				Spec: gardencorev1beta1.CloudProfileSpec{
+					Type: "aws",
+					Kubernetes: gardencorev1beta1.KubernetesSettings{
+						Versions: []gardencorev1beta1.ExpirableVersion{{Version: "1.17.9"}, {Version: "1.18.5", Classification: &preview}, {Version: "1.18.2"}},
+					},
+					MachineImages: []gardencorev1beta1.MachineImage{
+						{Name: "gardenlinux", Versions: []gardencorev1beta1.ExpirableVersion{{Version: "27.1.0"}}},
+					},
+					MachineTypes: []gardencorev1beta1.MachineType{
+						{Name: "m5.large", CPU: resource.MustParse("2"), Memory: resource.MustParse("8Gi")},
+						{Name: "m5.xlarge", CPU: resource.MustParse("4"), Memory: resource.MustParse("16Gi")},
+						{Name: "p2.xlarge", CPU: resource.MustParse("4"), Memory: resource.MustParse("61Gi"), Usable: &notUsable},
+					},
+					VolumeTypes: []gardencorev1beta1.VolumeType{{Name: "gp2", Class: "standard"}},
+					Regions: []gardencorev1beta1.Region{
+						{Name: "us-east-1"},
+						{Name: "eu-west-1", Zones: []gardencorev1beta1.AvailabilityZone{{Name: "eu-west-1a", UnavailableMachineTypes: []string{"m5.xlarge"}}, {Name: "eu-west-1b"}}},
+					},
+				},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+
+		var err error
+		dir, err = ioutil.TempDir("", "gardenctl-create")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		os.RemoveAll(dir)
+	})
+
+	Context("with the wizard", func() {
+		var (
+			offered map[string][]string
+			answers map[string]string
+		)
+
+		BeforeEach(func() {
+			offered = map[string][]string{}
+			answers = map[string]string{
+				"Cloud profile":         "aws",
+				"Secret binding":        "aws-secret",
+				"Region":                "eu-west-1",
+				"Zone":                  "eu-west-1a",
+				"Kubernetes version":    "1.18.2",
+				"Machine type":          "m5.large",
+				"Machine image":         "gardenlinux",
+				"Machine image version": "27.1.0",
+				"Volume type":           "gp2",
+				"Purpose":               "development",
+			}
+			prompter.EXPECT().Select(gomock.Any(), gomock.Any()).DoAndReturn(func(label string, items []string) (int, error) {
+				offered[label] = items
+				for i, item := range items {
+					if strings.HasPrefix(item, answers[label]) {
+						return i, nil
+					}
+				}
+				return -1, nil
+			}).AnyTimes()
+			prompter.EXPECT().Input(gomock.Any(), gomock.Any()).DoAndReturn(func(label, defaultValue string) (string, error) {
+				return defaultValue, nil
+			}).AnyTimes()
+		})
+
+		It("should offer what is available and print the manifest", func() {
+			out, err := execute("my-shoot", "--dry-run")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offered["Cloud profile"]).To(Equal([]string{"alicloud", "aws", "gcp"}))
+			Expect(offered["Region"]).To(Equal([]string{"eu-west-1", "us-east-1"}))
+			Expect(offered["Kubernetes version"]).To(Equal([]string{"1.18.2", "1.17.9", "1.18.5 (preview)"}))
+			Expect(offered["Machine type"]).To(Equal([]string{"m5.large (2 CPU, 8Gi memory)"}))
+			Expect(out).To(ContainSubstring("kind: Shoot\n"))
+			Expect(out).To(ContainSubstring("  name: my-shoot\n  namespace: garden-prod\n"))
+			Expect(out).To(ContainSubstring("  cloudProfileName: aws\n"))
+			Expect(out).To(ContainSubstring("  purpose: development\n"))
+			Expect(out).To(ContainSubstring("      maximum: 2\n"))
+			Expect(out).To(ContainSubstring("      zones:\n      - eu-west-1a\n"))
+
+			list, err := clientSet.CoreV1beta1().Shoots(projectNamespace).List(metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+
+		It("should create the shoot", func() {
+			out, err := execute("my-shoot")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("Shoot garden-prod/my-shoot created\n"))
+			shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("my-shoot", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shoot.Spec.Provider.Workers[0].Machine.Type).To(Equal("m5.large"))
+			Expect(*shoot.Spec.Provider.Workers[0].Machine.Image.Version).To(Equal("27.1.0"))
+			Expect(*shoot.Spec.Provider.Workers[0].Volume.Type).To(Equal("gp2"))
+			Expect(shoot.Spec.Kubernetes.Version).To(Equal("1.18.2"))
+			Expect(*shoot.Spec.Networking.Pods).To(Equal("100.96.0.0/11"))
+			Expect(*shoot.Spec.Networking.Services).To(Equal("100.64.0.0/13"))
+			Expect(string(shoot.Spec.Provider.InfrastructureConfig.Raw)).To(ContainSubstring(`"kind":"InfrastructureConfig"`))
+			Expect(string(shoot.Spec.Provider.InfrastructureConfig.Raw)).To(ContainSubstring(`"name":"eu-west-1a"`))
+			Expect(string(shoot.Spec.Provider.ControlPlaneConfig.Raw)).To(ContainSubstring(`"kind":"ControlPlaneConfig"`))
+		})
+
+		It("should not create a shoot whose provider configuration cannot be generated", func() {
+			answers["Cloud profile"] = "alicloud"
+			answers["Region"] = "eu-central-1"
+			answers["Machine type"] = "ecs.g6.large"
+
+			_, err := execute("my-shoot")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("the wizard cannot generate the infrastructure and control plane configuration of alicloud shoots"))
+			list, err := clientSet.CoreV1beta1().Shoots(projectNamespace).List(metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
+
+	Context("with a template", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(dir, "shoot.yaml")
+			Expect(ioutil.WriteFile(path, []byte(`kind: Shoot
+apiVersion: core.gardener.cloud/v1beta1
+metadata:
+  name: {{ .name }}
+  labels:
+    project: {{ .project }}
+spec:
+  cloudProfileName: aws
+  region: {{ .region }}
+  secretBindingName: aws-secret
+  kubernetes:
+    version: "1.18.2"
+`), 0644)).To(Succeed())
+		})
+
+		It("should render the variables", func() {
+			out, err := execute("my-shoot", "-f", path, "--var", "region=eu-west-1", "--dry-run")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("    project: prod\n"))
+			Expect(out).To(ContainSubstring("  name: my-shoot\n  namespace: garden-prod\n"))
+			Expect(out).To(ContainSubstring("  region: eu-west-1\n"))
+		})
+
+		It("should return an error for missing variables", func() {
+			_, err := execute("my-shoot", "-f", path, "--dry-run")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`map has no entry for key "region"`))
+		})
+
+		It("should not allow overriding variables set by gardenctl", func() {
+			_, err := execute("my-shoot", "-f", path, "--var", "region=eu-west-1", "--var", "namespace=garden-dev")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`variable "namespace" is set by gardenctl and cannot be overridden`))
+		})
+	})
+})
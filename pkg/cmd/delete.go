@@ -0,0 +1,228 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	// deletionConfirmationAnnotation must be set to true on a shoot before it can be deleted
+	deletionConfirmationAnnotation = "confirmation.gardener.cloud/deletion"
+	// createdByAnnotation is set by the gardener apiserver to the user who created the shoot
+	createdByAnnotation = "gardener.cloud/created-by"
+	// legacyCreatedByAnnotation is set on shoots created by older gardener versions
+	legacyCreatedByAnnotation = "garden.sapcloud.io/createdBy"
+)
+
+// shootDeleteFlags are the flags of the delete shoot command
+type shootDeleteFlags struct {
+	shootChangeFlags
+	confirmName     string
+	allowProduction bool
+}
+
+// NewDeleteCmd returns a new delete command.
+func NewDeleteCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a resource, e.g. \"gardenctl delete shoot my-shoot\"",
+	}
+	cmd.AddCommand(newDeleteShootCmd(targetReader, configReader, ioStreams))
+
+	return cmd
+}
+
+func newDeleteShootCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var flags shootDeleteFlags
+	cmd := &cobra.Command{
+		Use:          "shoot <name>",
+		Short:        "Delete the given shoot after confirming its name, e.g. \"gardenctl delete shoot my-shoot --wait\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: delete shoot <name>")
+			}
+			if flags.yes && flags.confirmName == "" {
+				return errors.New("--yes requires --confirm-name with the name of the shoot")
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, args[0], "")
+			if err != nil {
+				return err
+			}
+			shoot := &shoots[0]
+
+			machineCount, counted := countShootMachines(target, shoot)
+			if err := renderShootDeletionSummary(shoot, machineCount, counted, time.Now(), ioStreams.Out); err != nil {
+				return err
+			}
+			if purpose := shoot.Spec.Purpose; purpose != nil && *purpose == gardencorev1beta1.ShootPurposeProduction && !flags.allowProduction {
+				return fmt.Errorf("shoot %s has purpose %s, use --allow-production to delete it", shootKey(shoot), *purpose)
+			}
+
+			if err := confirmShootRestrictions(target, configReader, shoots, flags.yes, ioStreams); err != nil {
+				return err
+			}
+			if err := confirmShootName(shoot, flags, ioStreams); err != nil {
+				return err
+			}
+
+			return deleteShoot(gardenClientset, shoot, flags.shootChangeFlags, ioStreams.Out)
+		},
+	}
+	cmd.Flags().StringVar(&flags.confirmName, "confirm-name", "", "name of the shoot to confirm the deletion with --yes")
+	cmd.Flags().BoolVar(&flags.allowProduction, "allow-production", false, "allow deleting a shoot with purpose production")
+	cmd.Flags().BoolVar(&flags.wait, "wait", false, "wait until the shoot is deleted and return an error if the deletion failed")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 30*time.Minute, "maximum time to wait with --wait")
+	cmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "do not ask for confirmation, requires --confirm-name")
+
+	return cmd
+}
+
+// renderShootDeletionSummary prints what is known about the shoot to be deleted, with the autoscaler range of its
+// worker pools instead of the number of machines if they could not be counted
+func renderShootDeletionSummary(shoot *gardencorev1beta1.Shoot, machineCount int, counted bool, now time.Time, writer io.Writer) error {
+	purpose := "<none>"
+	if shoot.Spec.Purpose != nil {
+		purpose = string(*shoot.Spec.Purpose)
+	}
+	age := "<unknown>"
+	if !shoot.CreationTimestamp.IsZero() {
+		age = duration.HumanDuration(now.Sub(shoot.CreationTimestamp.Time))
+	}
+	createdBy := shoot.Annotations[createdByAnnotation]
+	if createdBy == "" {
+		createdBy = shoot.Annotations[legacyCreatedByAnnotation]
+	}
+	if createdBy == "" {
+		createdBy = "<unknown>"
+	}
+	nodes := fmt.Sprintf("%d machines", machineCount)
+	if machineCount == 1 {
+		nodes = "1 machine"
+	}
+	if !counted {
+		var minimum, maximum int32
+		for _, worker := range shoot.Spec.Provider.Workers {
+			minimum += worker.Minimum
+			maximum += worker.Maximum
+		}
+		nodes = fmt.Sprintf("%d-%d (autoscaler range)", minimum, maximum)
+	}
+
+	fmt.Fprintf(writer, "Shoot %s:\n", shootKey(shoot))
+	w := tabwriter.NewWriter(writer, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "  Purpose:\t%s\n", purpose)
+	fmt.Fprintf(w, "  Age:\t%s\n", age)
+	fmt.Fprintf(w, "  Created by:\t%s\n", createdBy)
+	fmt.Fprintf(w, "  Nodes:\t%s in %d worker pools\n", nodes, len(shoot.Spec.Provider.Workers))
+	return w.Flush()
+}
+
+// countShootMachines counts the machines of the shoot in its control plane namespace, it only reads them if the
+// seed of the current target is the one of the shoot
+func countShootMachines(target TargetInterface, shoot *gardencorev1beta1.Shoot) (int, bool) {
+	if shoot.Status.TechnicalID == "" || shoot.Spec.SeedName == nil || !seedOfShootIsTargeted(target, shoot) {
+		return 0, false
+	}
+	machineClientset, err := target.MachineClient()
+	if err != nil {
+		return 0, false
+	}
+	machines, err := getMachines(machineClientset, shoot.Status.TechnicalID)
+	if err != nil {
+		return 0, false
+	}
+	return len(machines.Machines), true
+}
+
+// seedOfShootIsTargeted returns whether the target stack contains the seed of the shoot or the shoot itself
+func seedOfShootIsTargeted(target TargetInterface, shoot *gardencorev1beta1.Shoot) bool {
+	for _, meta := range target.Stack() {
+		if (meta.Kind == TargetKindSeed && meta.Name == *shoot.Spec.SeedName) || (meta.Kind == TargetKindShoot && meta.Name == shoot.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmShootName asks the user to type the name of the shoot unless it is already confirmed with --yes and --confirm-name
+func confirmShootName(shoot *gardencorev1beta1.Shoot, flags shootDeleteFlags, ioStreams IOStreams) error {
+	if flags.yes {
+		if flags.confirmName != shoot.Name {
+			return fmt.Errorf("--confirm-name %q does not match shoot %s", flags.confirmName, shoot.Name)
+		}
+		return nil
+	}
+
+	answer, err := askUser("Type the name of the shoot to confirm the deletion: ", ioStreams)
+	if err != nil {
+		return err
+	}
+	if answer != shoot.Name {
+		return errors.New("aborted, the name does not match the shoot")
+	}
+	return nil
+}
+
+// deleteShoot confirms the deletion with the annotation, deletes the shoot and optionally waits until it is gone
+func deleteShoot(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, flags shootChangeFlags, writer io.Writer) error {
+	shoots := gardenClientset.CoreV1beta1().Shoots(shoot.Namespace)
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, deletionConfirmationAnnotation)
+	if _, err := shoots.Patch(shoot.Name, types.MergePatchType, []byte(patch)); err != nil {
+		return err
+	}
+	if err := shoots.Delete(shoot.Name, &metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Shoot %s will be deleted\n", shootKey(shoot))
+	if !flags.wait {
+		return nil
+	}
+
+	_, err := waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, shootDeletionFailed, flags.timeout, writer)
+	if !errors.Is(err, errShootDeleted) {
+		return err
+	}
+	fmt.Fprintf(writer, "Shoot %s has been deleted\n", shootKey(shoot))
+
+	return nil
+}
+
+// shootDeletionFailed is a condition which is never met because the shoot is gone once its deletion succeeded,
+// and which fails once the deletion failed or was aborted
+func shootDeletionFailed(shoot *gardencorev1beta1.Shoot) (bool, error) {
+	if op := shoot.Status.LastOperation; op != nil && op.Type == gardencorev1beta1.LastOperationTypeDelete {
+		_, err := lastOperationResult(shoot)
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	machinefake "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Delete shoot command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+		purpose      gardencorev1beta1.ShootPurpose
+		stack        []cmd.TargetMeta
+	)
+
+	seedName := "test-seed"
+	projectNamespace := "garden-prod"
+	project := &gardencorev1beta1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+	}
+
+	execute := func(input string, args ...string) (string, error) {
+		ioStreams, in, out, _ := cmd.NewTestIOStreams()
+		in.WriteString(input)
+		command := cmd.NewDeleteCmd(targetReader, configReader, ioStreams)
+		command.SetArgs(append([]string{"shoot"}, args...))
+		err := command.Execute()
+		return out.String(), err
+	}
+	shootExists := func() bool {
+		_, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		purpose = gardencorev1beta1.ShootPurposeDevelopment
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+		}
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (gardencoreclientset.Interface, error) {
+			return clientSet, nil
+		}).AnyTimes()
+	})
+
+	JustBeforeEach(func() {
+		clientSet = gardencorefake.NewSimpleClientset(
+			project,
+			&gardencorev1beta1.Shoot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-shoot",
+					Namespace:         projectNamespace,
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-50 * time.Hour)),
+					Annotations:       map[string]string{"gardener.cloud/created-by": "jane.doe@example.com"},
+				},
+				Spec: gardencorev1beta1.ShootSpec{
+					Purpose:  &purpose,
+					SeedName: &seedName,
+					Provider: gardencorev1beta1.Provider{
+						Workers: []gardencorev1beta1.Worker{{Name: "a", Minimum: 1, Maximum: 3}, {Name: "b", Minimum: 2, Maximum: 2}},
+					},
+				},
+				Status: gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--test-shoot"},
+			},
+		)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should show the shoot and delete it after the name has been typed", func() {
+		var annotations map[string]string
+		clientSet.PrependReactor("delete", "shoots", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			shoot, err := clientSet.Tracker().Get(gardencorev1beta1.SchemeGroupVersion.WithResource("shoots"), projectNamespace, "test-shoot")
+			Expect(err).NotTo(HaveOccurred())
+			annotations = shoot.(*gardencorev1beta1.Shoot).Annotations
+			return false, nil, nil
+		})
+
+		out, err := execute("test-shoot\n", "test-shoot")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`Shoot garden-prod/test-shoot:
+  Purpose:    development
+  Age:        2d2h
+  Created by: jane.doe@example.com
+  Nodes:      3-5 (autoscaler range) in 2 worker pools
+Type the name of the shoot to confirm the deletion: Shoot garden-prod/test-shoot will be deleted
+`))
+		Expect(annotations).To(HaveKeyWithValue("confirmation.gardener.cloud/deletion", "true"))
+		Expect(shootExists()).To(BeFalse())
+	})
+
+	It("should abort if the typed name does not match", func() {
+		_, err := execute("other-shoot\n", "test-shoot")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("aborted, the name does not match the shoot"))
+		Expect(shootExists()).To(BeTrue())
+	})
+
+	It("should require a matching --confirm-name with --yes", func() {
+		_, err := execute("", "test-shoot", "--yes")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("--yes requires --confirm-name with the name of the shoot"))
+
+		_, err = execute("", "test-shoot", "--yes", "--confirm-name", "other-shoot")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(`--confirm-name "other-shoot" does not match shoot test-shoot`))
+		Expect(shootExists()).To(BeTrue())
+	})
+
+	It("should delete the shoot and wait until it is gone", func() {
+		out, err := execute("", "test-shoot", "--yes", "--confirm-name", "test-shoot", "--wait")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(HaveSuffix("Shoot garden-prod/test-shoot will be deleted\nShoot garden-prod/test-shoot has been deleted\n"))
+		Expect(shootExists()).To(BeFalse())
+	})
+
+	Context("with the shoot targeted", func() {
+		BeforeEach(func() {
+			stack = append(stack, cmd.TargetMeta{Kind: cmd.TargetKindShoot, Name: "test-shoot"})
+			machineClientSet := machinefake.NewSimpleClientset(
+				&machinev1alpha1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-a", Namespace: "shoot--prod--test-shoot"}},
+				&machinev1alpha1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-b", Namespace: "shoot--prod--test-shoot"}},
+				&machinev1alpha1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-other", Namespace: "shoot--prod--other"}},
+			)
+			target.EXPECT().MachineClient().Return(machineClientSet, nil)
+		})
+
+		It("should show the number of machines of the shoot", func() {
+			out, err := execute("other-shoot\n", "test-shoot")
+
+			Expect(err).To(HaveOccurred())
+			Expect(out).To(ContainSubstring("  Nodes:      2 machines in 2 worker pools\n"))
+		})
+	})
+
+	Context("with a production shoot", func() {
+		BeforeEach(func() {
+			purpose = gardencorev1beta1.ShootPurposeProduction
+		})
+
+		It("should refuse the deletion", func() {
+			_, err := execute("test-shoot\n", "test-shoot")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("shoot garden-prod/test-shoot has purpose production, use --allow-production to delete it"))
+			Expect(shootExists()).To(BeTrue())
+		})
+
+		It("should delete the shoot with --allow-production", func() {
+			_, err := execute("test-shoot\n", "test-shoot", "--allow-production")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shootExists()).To(BeFalse())
+		})
+	})
+})
@@ -17,279 +17,610 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
+// sections of the diagnostic report which are collected from the clusters
+const (
+	diagSectionLastOperation        = "lastOperation"
+	diagSectionNodes                = "nodes"
+	diagSectionNodeMetrics          = "nodeMetrics"
+	diagSectionSystemPods           = "systemPods"
+	diagSectionDaemonSets           = "daemonSets"
+	diagSectionPodDisruptionBudgets = "podDisruptionBudgets"
+	diagSectionWebhooks             = "webhooks"
+	diagSectionControlPlanePods     = "controlPlanePods"
+)
+
+// diagShootSection collects a section of the diagnostic report from the shoot cluster with the given clients
+type diagShootSection struct {
+	name    string
+	collect func(clients *diagClients, report *DiagReport) error
+}
+
+// diagClients are the clients the diagnostic report of a shoot is collected with, each with the reason why it could
+// not be created. They are created up front, since creating clients through the target changes the global kubeconfig.
+type diagClients struct {
+	shoot      kubernetes.Interface
+	shootErr   error
+	seed       kubernetes.Interface
+	seedErr    error
+	metrics    metricsclientset.Interface
+	metricsErr error
+}
+
+// newDiagClients creates the clients for the shoot and its control plane, the clients of the shoot cluster are not
+// created for a hibernated shoot
+func newDiagClients(shoot *v1beta1.Shoot, target TargetInterface) *diagClients {
+	clients := &diagClients{}
+	if shoot.Status.IsHibernated {
+		clients.shootErr = errors.New("shoot is hibernated")
+		clients.metricsErr = clients.shootErr
+	} else if clients.shoot, clients.shootErr = target.K8SClientToKind(TargetKindShoot); clients.shootErr == nil {
+		clients.metrics, clients.metricsErr = target.MetricsClient()
+	}
+	if shoot.Status.TechnicalID == "" {
+		clients.seedErr = errors.New("shoot has no control plane namespace")
+	} else {
+		clients.seed, clients.seedErr = target.K8SClientToKind(TargetKindSeed)
+	}
+	return clients
+}
+
+// diagShootSections are collected in this order from the shoot cluster
+var diagShootSections = []diagShootSection{
+	{diagSectionNodes, collectDiagNodes},
+	{diagSectionNodeMetrics, collectDiagNodeMetrics},
+	{diagSectionSystemPods, collectDiagSystemPods},
+	{diagSectionDaemonSets, collectDiagDaemonSets},
+	{diagSectionPodDisruptionBudgets, collectDiagPodDisruptionBudgets},
+	{diagSectionWebhooks, collectDiagWebhooks},
+}
+
 // NewDiagCmd returns diagnostic information for a shoot.
 func NewDiagCmd(reader TargetReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		failOn      string
+		bundle      bool
+		bundleDir   string
+		itemTimeout time.Duration
+	)
 	cmd := &cobra.Command{
 		Use:          "diag",
-		Short:        "Print shoot diagnostic information, e.g. \"gardenctl diag\"",
+		Short:        "Print diagnostic information of the targeted shoot or seed, e.g. \"gardenctl diag\" or \"gardenctl diag -o yaml\", or collect it for support with \"gardenctl diag --bundle\"",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var failOnSeverity Severity
+			if failOn != "" {
+				var err error
+				if failOnSeverity, err = parseSeverity(failOn); err != nil {
+					return err
+				}
+			}
+			if bundle && failOn != "" {
+				return errors.New("--fail-on cannot be combined with --bundle")
+			}
 			target := reader.ReadTarget(pathTarget)
+			if isSeedTargeted(target) {
+				if bundle || failOn != "" {
+					return errors.New("--bundle and --fail-on require a targeted shoot")
+				}
+				report, err := getSeedInformation(target.Stack()[1].Name, target)
+				if err != nil {
+					return err
+				}
+				outFormat := outputFormatOrTable(cmd)
+				if outFormat == tableOutputFormat {
+					renderSeedDiagReport(report, ioStreams.Out)
+					return nil
+				}
+				return PrintoutObject(report, ioStreams.Out, outFormat)
+			}
 			if !CheckShootIsTargeted(target) {
-				return errors.New("no shoot targeted")
+				return errors.New("no shoot or seed targeted")
 			}
 
 			shoot, err := FetchShootFromTarget(target)
-			checkError(err)
-			getShootInformation(shoot, target)
+			if err != nil {
+				return err
+			}
+			if shoot == nil {
+				return fmt.Errorf("no shoot found with name %q", target.Stack()[2].Name)
+			}
+			if bundle {
+				fmt.Fprintln(ioStreams.ErrOut, "Collecting diagnostic bundle...")
+				bundlePath, index, err := writeDiagBundle(shoot, target, bundleDir, itemTimeout, time.Now())
+				if err != nil {
+					return err
+				}
+				collected := 0
+				for _, item := range index.Items {
+					if item.Collected {
+						collected++
+					}
+				}
+				fmt.Fprintf(ioStreams.Out, "Diagnostic bundle written to %s, %d of %d items collected\n", bundlePath, collected, len(index.Items))
+				return nil
+			}
+
+			report := getShootInformation(shoot, newDiagClients(shoot, target))
+			evaluateDiagRules(report)
+
+			outFormat := outputFormatOrTable(cmd)
+			if outFormat == tableOutputFormat {
+				renderDiagReport(report, time.Now(), ioStreams.Out)
+			} else if err := PrintoutObject(report, ioStreams.Out, outFormat); err != nil {
+				return err
+			}
+
+			if failOnSeverity != "" {
+				if count := countDiagFindings(report.Findings, failOnSeverity); count > 0 {
+					return &exitError{code: exitCodeFindings, err: fmt.Errorf("found %d problems with severity %s or higher", count, failOnSeverity)}
+				}
+			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&bundle, "bundle", false, "collect the diagnostic report, manifests, events and logs of the shoot into a tar.gz file, credentials are redacted")
+	cmd.Flags().StringVar(&bundleDir, "bundle-dir", ".", "directory in which the bundle is written")
+	cmd.Flags().DurationVar(&itemTimeout, "item-timeout", time.Minute, "maximum time to collect each item of the bundle")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", fmt.Sprintf("exit with code %d if there are findings with this severity or higher, one of info, warning or error", exitCodeFindings))
 	return cmd
 }
 
-//getShootInformation prints all information regarding a shoot
-func getShootInformation(shoot *v1beta1.Shoot, target TargetInterface) {
-	fmt.Println("The shoot diagnostic information are as follows:")
-	fmt.Println()
-	fmt.Println("Shoot: " + shoot.Name)
-	fmt.Println("Kubernetes Version: " + shoot.Spec.Kubernetes.Version)
-	fmt.Println("Created At: " + shoot.ObjectMeta.CreationTimestamp.String())
-	annotationsMap := shoot.GetObjectMeta().GetAnnotations()
-	fmt.Println("Created By: " + annotationsMap["gardener.cloud/created-by"])
-	fmt.Println("Cloud Profile: " + shoot.Spec.CloudProfileName)
-	fmt.Println("Region: " + shoot.Spec.Region)
-	fmt.Println("Purpose: " + *shoot.Spec.Purpose)
-	fmt.Println("Seed Name: " + *shoot.Status.SeedName)
-	fmt.Println()
-
-	fmt.Println("Last Operation:")
-	fmt.Println()
-	lastOperationMsg := []string{shoot.Status.LastOperation.Description, fmt.Sprintf("%v", shoot.Status.LastOperation.Type), fmt.Sprintf("%v", shoot.Status.LastOperation.State)}
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Description", "Last Operation Type", "Last Operation Type State"})
-	table.Append(lastOperationMsg)
-	table.Render()
-	fmt.Println()
-
-	fmt.Println("Shoot Conditions: ")
-	fmt.Println()
-	data := [][]string{}
-	for i := range shoot.Status.Conditions {
-		condition := shoot.Status.Conditions[i]
-		codesValue := condition.Codes
-		codesString := fmt.Sprintf("%v", codesValue)
-		data = append(data, []string{condition.Message, condition.LastTransitionTime.String(), codesString})
-	}
-	table = tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Message", "Last Transition Time", "Codes"})
-	for _, v := range data {
-		table.Append(v)
+// getShootInformation collects all information regarding a shoot with the clients, sections which cannot be collected
+// are recorded as unavailable
+func getShootInformation(shoot *v1beta1.Shoot, clients *diagClients) *DiagReport {
+	report := &DiagReport{
+		Shoot: DiagShootSummary{
+			Name:              shoot.Name,
+			Namespace:         shoot.Namespace,
+			KubernetesVersion: shoot.Spec.Kubernetes.Version,
+			CreatedAt:         formatDiagTime(shoot.CreationTimestamp),
+			CreatedBy:         shoot.Annotations[createdByAnnotation],
+			CloudProfile:      shoot.Spec.CloudProfileName,
+			Region:            shoot.Spec.Region,
+			Seed:              stringValue(shoot.Spec.SeedName),
+			TechnicalID:       shoot.Status.TechnicalID,
+			Hibernated:        shoot.Status.IsHibernated,
+		},
+		Workers: toWorkerPools(shoot).Pools,
 	}
-	table.Render()
-	fmt.Println()
-	fmt.Println("Workers Groups:")
-	fmt.Println()
-	data = [][]string{}
-	table = tablewriter.NewWriter(os.Stdout)
-	for w := range shoot.Spec.Provider.Workers {
-		worker := shoot.Spec.Provider.Workers[w]
-		if worker.Volume != nil {
-			data = append(data, []string{worker.Name, strconv.Itoa(int(worker.Minimum)), strconv.Itoa(int(worker.Maximum)), fmt.Sprintf("%v", worker.MaxUnavailable), fmt.Sprintf("%v", worker.MaxSurge), worker.Machine.Image.Name, *worker.Machine.Image.Version, worker.Machine.Type, fmt.Sprintf("%v", worker.Zones), fmt.Sprintf("%v", worker.Volume.Name), fmt.Sprintf("%v", worker.Volume.Type), worker.Volume.VolumeSize})
-			table.SetHeader([]string{"Worker Name", "Min", "Max", "Max Unavailable", "Max Surge", "Image Name", "Image Version", "Image Type", "Zones", "Volume Name", "Volume Type", "Volume Size"})
-		} else {
-			data = append(data, []string{worker.Name, strconv.Itoa(int(worker.Minimum)), strconv.Itoa(int(worker.Maximum)), fmt.Sprintf("%v", worker.MaxUnavailable), fmt.Sprintf("%v", worker.MaxSurge), worker.Machine.Image.Name, *worker.Machine.Image.Version, worker.Machine.Type, fmt.Sprintf("%v", worker.Zones)})
-			table.SetHeader([]string{"Worker Name", "Min", "Max", "Max Unavailable", "Max Surge", "Image Name", "Image Version", "Image Type", "Zones"})
+	if shoot.Spec.Purpose != nil {
+		report.Shoot.Purpose = string(*shoot.Spec.Purpose)
+	}
+	if shoot.Status.SeedName != nil {
+		report.Shoot.Seed = *shoot.Status.SeedName
+	}
+	if lastOperation := shoot.Status.LastOperation; lastOperation != nil {
+		report.LastOperation = &DiagLastOperation{
+			Type:        string(lastOperation.Type),
+			State:       string(lastOperation.State),
+			Progress:    lastOperation.Progress,
+			Description: lastOperation.Description,
+			LastUpdate:  formatDiagTime(lastOperation.LastUpdateTime),
 		}
+	} else {
+		markDiagUnavailable(report, diagSectionLastOperation, errors.New("shoot has no last operation"))
 	}
-	for _, v := range data {
-		table.Append(v)
+	for _, condition := range shoot.Status.Conditions {
+		diagCondition := DiagCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: formatDiagTime(condition.LastTransitionTime),
+		}
+		for _, code := range condition.Codes {
+			diagCondition.Codes = append(diagCondition.Codes, string(code))
+		}
+		report.Conditions = append(report.Conditions, diagCondition)
 	}
-	table.Render()
 
-	if !shoot.Status.IsHibernated {
-		var err error
-		var shootClient kubernetes.Interface
-		if shootClient, err = target.K8SClientToKind(TargetKindShoot); err != nil {
-			checkError(err)
+	if clients.shootErr != nil {
+		for _, section := range diagShootSections {
+			markDiagUnavailable(report, section.name, clients.shootErr)
 		}
-		var nodes *corev1.NodeList
-		if nodes, err = shootClient.CoreV1().Nodes().List(metav1.ListOptions{}); err != nil {
-			checkError(err)
+	} else {
+		for _, section := range diagShootSections {
+			markDiagUnavailable(report, section.name, section.collect(clients, report))
 		}
+	}
 
-		kubeconfig, err := ioutil.ReadFile(*kubeconfig)
-		checkError(err)
-		clientConfig, err := clientcmd.NewClientConfigFromBytes(kubeconfig)
-		checkError(err)
-		config, err := clientConfig.ClientConfig()
-		checkError(err)
-		metricsClientset, err := metricsv.NewForConfig(config)
-		checkError(err)
-		nodeMetricsList, err := metricsClientset.MetricsV1beta1().NodeMetricses().List(metav1.ListOptions{})
-		checkError(err)
-		fmt.Println()
-		fmt.Println("Node Metrics:")
-		fmt.Println()
-		data = [][]string{}
-		for _, metric := range nodeMetricsList.Items {
-			cpuUsage, _ := metric.Usage.Cpu().AsInt64()
-			memUsage, _ := metric.Usage.Memory().AsInt64()
-
-			data = append(data, []string{metric.GetName(), fmt.Sprintf("%v", cpuUsage), fmt.Sprintf("%v", memUsage/1000)})
-		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Node Name", "CPU Usage (Core)", "Memory Usage (MB)"})
+	markDiagUnavailable(report, diagSectionControlPlanePods, collectDiagControlPlanePods(shoot, clients, report))
+	return report
+}
 
-		for _, v := range data {
-			table.Append(v)
+// markDiagUnavailable records the section as unavailable if it could not be collected
+func markDiagUnavailable(report *DiagReport, section string, err error) {
+	if err != nil {
+		report.Unavailable = append(report.Unavailable, DiagUnavailable{Section: section, Reason: err.Error()})
+	}
+}
+
+func collectDiagNodes(clients *diagClients, report *DiagReport) error {
+	nodes, err := listDiagNodes(clients.shoot)
+	report.Nodes = nodes
+	return err
+}
+
+// listDiagNodes returns the capacity, readiness and resource pressure of the nodes of a cluster
+func listDiagNodes(client kubernetes.Interface) ([]DiagNode, error) {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var diagNodes []DiagNode
+	for _, node := range nodes.Items {
+		diagNode := DiagNode{
+			Name:       node.Name,
+			ProviderID: node.Spec.ProviderID,
+			Ready:      string(corev1.ConditionUnknown),
+			CPU:        node.Status.Capacity.Cpu().String(),
+			Memory:     node.Status.Capacity.Memory().String(),
 		}
-		table.Render()
-		fmt.Println()
-		fmt.Println("System Components:")
-		fmt.Println()
-		systemPods, err := shootClient.CoreV1().Pods("kube-system").List(metav1.ListOptions{})
-		checkError(err)
-		data := [][]string{}
-		for _, pod := range systemPods.Items {
-			readyNumber := 0
-			totalNumber := 0
-			for pss := range pod.Status.ContainerStatuses {
-				if pod.Status.ContainerStatuses[pss].Ready {
-					readyNumber++
-				}
-				if pod.Status.ContainerStatuses[pss].State.Terminated == nil {
-					totalNumber++
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				diagNode.InternalIP = address.Address
+			}
+		}
+		for _, condition := range node.Status.Conditions {
+			switch condition.Type {
+			case corev1.NodeReady:
+				diagNode.Ready = string(condition.Status)
+			case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+				if condition.Status == corev1.ConditionTrue {
+					diagNode.Pressure = append(diagNode.Pressure, string(condition.Type))
 				}
 			}
-			podstatusPhase := string(pod.Status.Phase)
-			podCreationTime := pod.GetCreationTimestamp()
-			age := time.Since(podCreationTime.Time).Round(time.Second)
-			timeString := fmt.Sprintf("%v", podCreationTime)
+		}
+		diagNodes = append(diagNodes, diagNode)
+	}
+	return diagNodes, nil
+}
 
-			data = append(data, []string{pod.GetName(), podstatusPhase, strconv.Itoa(readyNumber), strconv.Itoa(totalNumber), timeString, age.String()})
+// collectDiagNodeMetrics reads the node metrics through the metrics API of the shoot
+func collectDiagNodeMetrics(clients *diagClients, report *DiagReport) error {
+	if clients.metricsErr != nil {
+		return clients.metricsErr
+	}
+	metrics, err := clients.metrics.MetricsV1beta1().NodeMetricses().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, metric := range metrics.Items {
+		report.NodeMetrics = append(report.NodeMetrics, DiagNodeMetrics{
+			Name:   metric.Name,
+			CPU:    metric.Usage.Cpu().String(),
+			Memory: metric.Usage.Memory().String(),
+		})
+	}
+	return nil
+}
+
+func collectDiagSystemPods(clients *diagClients, report *DiagReport) error {
+	pods, err := clients.shoot.CoreV1().Pods(metav1.NamespaceSystem).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		report.SystemPods = append(report.SystemPods, toDiagPod(&pods.Items[i]))
+	}
+	return nil
+}
+
+func collectDiagDaemonSets(clients *diagClients, report *DiagReport) error {
+	daemonSets, err := clients.shoot.AppsV1().DaemonSets(metav1.NamespaceSystem).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, daemonSet := range daemonSets.Items {
+		report.DaemonSets = append(report.DaemonSets, DiagDaemonSet{
+			Name:      daemonSet.Name,
+			Desired:   daemonSet.Status.DesiredNumberScheduled,
+			Current:   daemonSet.Status.CurrentNumberScheduled,
+			Ready:     daemonSet.Status.NumberReady,
+			Available: daemonSet.Status.NumberAvailable,
+		})
+	}
+	return nil
+}
+
+func collectDiagPodDisruptionBudgets(clients *diagClients, report *DiagReport) error {
+	// budgets of workloads in all namespaces block the eviction of pods when nodes are rolled
+	pdbs, err := clients.shoot.PolicyV1beta1().PodDisruptionBudgets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pdb := range pdbs.Items {
+		diagPDB := DiagPDB{
+			Namespace:          pdb.Namespace,
+			Name:               pdb.Name,
+			CurrentHealthy:     pdb.Status.CurrentHealthy,
+			DesiredHealthy:     pdb.Status.DesiredHealthy,
+			DisruptionsAllowed: pdb.Status.PodDisruptionsAllowed,
 		}
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Phase", "Ready", "Total", "Created", "Age"})
-		for _, v := range data {
-			table.Append(v)
+		if pdb.Spec.Selector != nil {
+			diagPDB.Selector = metav1.FormatLabelSelector(pdb.Spec.Selector)
 		}
-		table.Render()
-
-		daemonSets, err := shootClient.AppsV1().DaemonSets("kube-system").List(metav1.ListOptions{})
-		checkError(err)
-		fmt.Println()
-		fmt.Println("DaemonSets:")
-		fmt.Println()
-		data = [][]string{}
-		for _, ds := range daemonSets.Items {
-			data = append(data, []string{ds.GetName(), strconv.Itoa(int(ds.Status.DesiredNumberScheduled)), strconv.Itoa(int(ds.Status.NumberAvailable))})
+		if pdb.Spec.MinAvailable != nil {
+			diagPDB.MinAvailable = pdb.Spec.MinAvailable.String()
 		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Desired Number", "Current Number"})
-		for _, v := range data {
-			table.Append(v)
+		if pdb.Spec.MaxUnavailable != nil {
+			diagPDB.MaxUnavailable = pdb.Spec.MaxUnavailable.String()
 		}
-		table.Render()
+		report.PodDisruptionBudgets = append(report.PodDisruptionBudgets, diagPDB)
+	}
+	return nil
+}
 
-		fmt.Println()
-		fmt.Println("Nodes:")
-		fmt.Println()
-		data = [][]string{}
-		for _, n := range nodes.Items {
-			addString := ""
-			for a := range n.Status.Addresses {
-				if n.Status.Addresses[a].Type == "InternalIP" {
-					addString = n.Status.Addresses[a].Address
-				}
-			}
-			cpuCount, _ := n.Status.Capacity.Cpu().AsInt64()
-			memoryCount, _ := n.Status.Capacity.Memory().AsInt64()
-			data = append(data, []string{n.Name, n.Spec.ProviderID, addString, strconv.Itoa(int(cpuCount)), strconv.Itoa(int(memoryCount / 1000))})
+func collectDiagWebhooks(clients *diagClients, report *DiagReport) error {
+	var kubeSystemLabels labels.Set
+	namespace, err := clients.shoot.CoreV1().Namespaces().Get(metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		kubeSystemLabels = namespace.Labels
+	}
+
+	mutating, err := clients.shoot.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, configuration := range mutating.Items {
+		for _, webhook := range configuration.Webhooks {
+			report.Webhooks = append(report.Webhooks, toDiagWebhook("Mutating", configuration.Name, webhook.Name,
+				(*string)(webhook.FailurePolicy), webhook.NamespaceSelector, webhook.ObjectSelector, kubeSystemLabels))
 		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Node Name", "Provider ID", "Address", "CPU Cores", "Memory (MB)"})
-		for _, v := range data {
-			table.Append(v)
+	}
+
+	validating, err := clients.shoot.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, configuration := range validating.Items {
+		for _, webhook := range configuration.Webhooks {
+			report.Webhooks = append(report.Webhooks, toDiagWebhook("Validating", configuration.Name, webhook.Name,
+				(*string)(webhook.FailurePolicy), webhook.NamespaceSelector, webhook.ObjectSelector, kubeSystemLabels))
 		}
-		table.Render()
-		fmt.Println()
-		fmt.Println("BlockingDisruptionBudgets:")
-		fmt.Println()
-		dbds, err := shootClient.PolicyV1beta1().PodDisruptionBudgets("kube-system").List(metav1.ListOptions{})
-		checkError(err)
-		data = [][]string{}
-		for dbdsIndex := range dbds.Items {
-			pdb := dbds.Items[dbdsIndex]
-			data = append(data, []string{pdb.Spec.Selector.MatchLabels["k8s-app"], fmt.Sprintf("%v", pdb.Spec.MinAvailable), fmt.Sprintf("%v", pdb.Spec.MaxUnavailable)})
+	}
+	return nil
+}
+
+// toDiagWebhook returns the webhook and whether its namespace selector matches the kube-system namespace, missing
+// selectors match all namespaces
+func toDiagWebhook(kind, configuration, name string, failurePolicy *string, namespaceSelector, objectSelector *metav1.LabelSelector, kubeSystemLabels labels.Set) DiagWebhook {
+	webhook := DiagWebhook{
+		Kind:              kind,
+		Configuration:     configuration,
+		Name:              name,
+		FailurePolicy:     stringValue(failurePolicy),
+		MatchesKubeSystem: true,
+	}
+	if namespaceSelector != nil {
+		webhook.NamespaceSelector = metav1.FormatLabelSelector(namespaceSelector)
+		selector, err := metav1.LabelSelectorAsSelector(namespaceSelector)
+		webhook.MatchesKubeSystem = err == nil && selector.Matches(kubeSystemLabels)
+	}
+	if objectSelector != nil {
+		webhook.ObjectSelector = metav1.FormatLabelSelector(objectSelector)
+	}
+	return webhook
+}
+
+// collectDiagControlPlanePods lists the pods in the control plane namespace of the shoot on its seed
+func collectDiagControlPlanePods(shoot *v1beta1.Shoot, clients *diagClients, report *DiagReport) error {
+	if clients.seedErr != nil {
+		return clients.seedErr
+	}
+	pods, err := clients.seed.CoreV1().Pods(shoot.Status.TechnicalID).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		report.ControlPlanePods = append(report.ControlPlanePods, toDiagPod(&pods.Items[i]))
+	}
+	return nil
+}
+
+// toDiagPod returns the status of the pod, the reason is the one of a waiting or terminated container if there is one
+func toDiagPod(pod *corev1.Pod) DiagPod {
+	diagPod := DiagPod{
+		Name:       pod.Name,
+		Phase:      string(pod.Status.Phase),
+		Reason:     pod.Status.Reason,
+		Containers: len(pod.Spec.Containers),
+		Node:       pod.Spec.NodeName,
+		CreatedAt:  formatDiagTime(pod.CreationTimestamp),
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			diagPod.ReadyContainers++
 		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Min Available", "Max Unavailable"})
-		for _, v := range data {
-			table.Append(v)
+		diagPod.Restarts += status.RestartCount
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			diagPod.Reason = status.State.Waiting.Reason
+		} else if status.State.Terminated != nil && status.State.Terminated.Reason != "" && diagPod.Reason == "" {
+			diagPod.Reason = status.State.Terminated.Reason
 		}
-		table.Render()
-		fmt.Println()
-		fmt.Println("MutatingWebhookConfigurations:")
-		fmt.Println()
-		data = [][]string{}
-		mutatingWebhookConfigurations, err := shootClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().List(metav1.ListOptions{})
-		checkError(err)
-		for mwc := range mutatingWebhookConfigurations.Items {
-			whList := mutatingWebhookConfigurations.Items[mwc].Webhooks
-			for whIndex := range whList {
-				wh := whList[whIndex]
-				data = append(data, []string{wh.Name})
-			}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			diagPod.Ready = condition.Status == corev1.ConditionTrue
 		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"MutatingWebhookConfiguration Name"})
+	}
+	return diagPod
+}
+
+// formatDiagTime formats times of the diagnostic report, zero times are left empty
+func formatDiagTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatDiagAge returns the age of a time of the diagnostic report
+func formatDiagAge(value string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "-"
+	}
+	return duration.HumanDuration(now.Sub(t))
+}
+
+// renderDiagReport renders the diagnostic report as tables
+func renderDiagReport(report *DiagReport, now time.Time, writer io.Writer) {
+	renderSection := func(section, title string, header []string, rows [][]string) {
+		renderDiagSection(writer, report.Unavailable, section, title, header, rows)
+	}
 
-		for _, v := range data {
-			table.Append(v)
+	shoot := report.Shoot
+	fmt.Fprintln(writer, "The shoot diagnostic information are as follows:")
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "Shoot: "+shoot.Namespace+"/"+shoot.Name)
+	fmt.Fprintln(writer, "Kubernetes Version: "+valueOrDash(shoot.KubernetesVersion))
+	fmt.Fprintln(writer, "Created At: "+valueOrDash(shoot.CreatedAt))
+	fmt.Fprintln(writer, "Created By: "+valueOrDash(shoot.CreatedBy))
+	fmt.Fprintln(writer, "Cloud Profile: "+valueOrDash(shoot.CloudProfile))
+	fmt.Fprintln(writer, "Region: "+valueOrDash(shoot.Region))
+	fmt.Fprintln(writer, "Purpose: "+valueOrDash(shoot.Purpose))
+	fmt.Fprintln(writer, "Seed Name: "+valueOrDash(shoot.Seed))
+	fmt.Fprintln(writer, "Hibernated: "+strconv.FormatBool(shoot.Hibernated))
+	fmt.Fprintln(writer)
+
+	var rows [][]string
+	if operation := report.LastOperation; operation != nil {
+		rows = append(rows, []string{operation.Type, operation.State, fmt.Sprintf("%d%%", operation.Progress), operation.Description, operation.LastUpdate})
+	}
+	renderSection(diagSectionLastOperation, "Last Operation", []string{"Type", "State", "Progress", "Description", "Last Update"}, rows)
+
+	rows = nil
+	for _, condition := range report.Conditions {
+		rows = append(rows, []string{condition.Type, condition.Status, condition.Message, condition.LastTransitionTime, strings.Join(condition.Codes, ",")})
+	}
+	renderSection("conditions", "Shoot Conditions", []string{"Type", "Status", "Message", "Last Transition Time", "Codes"}, rows)
+
+	rows = nil
+	for _, worker := range report.Workers {
+		rows = append(rows, []string{worker.Name, strconv.Itoa(int(worker.Minimum)), strconv.Itoa(int(worker.Maximum)), valueOrDash(worker.MaxUnavailable),
+			valueOrDash(worker.MaxSurge), valueOrDash(worker.Image), valueOrDash(worker.ImageVersion), worker.MachineType, strings.Join(worker.Zones, ","),
+			valueOrDash(worker.VolumeName), valueOrDash(worker.VolumeType), valueOrDash(worker.VolumeSize)})
+	}
+	renderSection("workers", "Worker Groups", []string{"Worker Name", "Min", "Max", "Max Unavailable", "Max Surge", "Image Name", "Image Version", "Machine Type", "Zones",
+		"Volume Name", "Volume Type", "Volume Size"}, rows)
+
+	rows = nil
+	for _, node := range report.Nodes {
+		rows = append(rows, diagNodeRow(node))
+	}
+	renderSection(diagSectionNodes, "Nodes", diagNodeHeader, rows)
+
+	rows = nil
+	for _, metric := range report.NodeMetrics {
+		rows = append(rows, []string{metric.Name, metric.CPU, metric.Memory})
+	}
+	renderSection(diagSectionNodeMetrics, "Node Metrics", []string{"Node Name", "CPU Usage", "Memory Usage"}, rows)
+
+	renderSection(diagSectionSystemPods, "System Components", diagPodHeader, diagPodRows(report.SystemPods, now))
+
+	rows = nil
+	for _, daemonSet := range report.DaemonSets {
+		rows = append(rows, []string{daemonSet.Name, strconv.Itoa(int(daemonSet.Desired)), strconv.Itoa(int(daemonSet.Current)),
+			strconv.Itoa(int(daemonSet.Ready)), strconv.Itoa(int(daemonSet.Available))})
+	}
+	renderSection(diagSectionDaemonSets, "DaemonSets", []string{"Name", "Desired", "Current", "Ready", "Available"}, rows)
+
+	rows = nil
+	for _, pdb := range report.PodDisruptionBudgets {
+		rows = append(rows, []string{pdb.Namespace, pdb.Name, valueOrDash(pdb.Selector), valueOrDash(pdb.MinAvailable), valueOrDash(pdb.MaxUnavailable),
+			strconv.Itoa(int(pdb.DisruptionsAllowed))})
+	}
+	renderSection(diagSectionPodDisruptionBudgets, "PodDisruptionBudgets", []string{"Namespace", "Name", "Selector", "Min Available", "Max Unavailable", "Allowed Disruptions"}, rows)
+
+	rows = nil
+	for _, webhook := range report.Webhooks {
+		rows = append(rows, []string{webhook.Kind, webhook.Configuration, webhook.Name, valueOrDash(webhook.FailurePolicy),
+			valueOrDash(webhook.NamespaceSelector), valueOrDash(webhook.ObjectSelector)})
+	}
+	renderSection(diagSectionWebhooks, "Webhooks", []string{"Kind", "Configuration", "Name", "Failure Policy", "Namespace Selector", "Object Selector"}, rows)
+
+	renderSection(diagSectionControlPlanePods, "Control Plane Pods", diagPodHeader, diagPodRows(report.ControlPlanePods, now))
+
+	fmt.Fprintln(writer, "Findings:")
+	fmt.Fprintln(writer)
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(writer, "No problems found")
+		fmt.Fprintln(writer)
+	} else {
+		rows = nil
+		for _, finding := range report.Findings {
+			rows = append(rows, []string{string(finding.Severity), finding.Rule, finding.Object, finding.Message, finding.Remediation})
 		}
+		table := tablewriter.NewWriter(writer)
+		table.SetHeader([]string{"Severity", "Rule", "Object", "Message", "Remediation"})
+		table.AppendBulk(rows)
 		table.Render()
-		seedClient, err := target.K8SClientToKind(TargetKindSeed)
-		checkError(err)
-		controlPlanePods, err := seedClient.CoreV1().Pods(shoot.Status.TechnicalID).List(metav1.ListOptions{})
-		checkError(err)
-		fmt.Println()
-		fmt.Println("Control Plane Pods:")
-		fmt.Println()
-		data = [][]string{}
-		for _, pod := range controlPlanePods.Items {
-			readyNumber := 0
-			totalNumber := 0
-			for pss := range pod.Status.ContainerStatuses {
-				if pod.Status.ContainerStatuses[pss].Ready {
-					readyNumber++
-				}
-				if pod.Status.ContainerStatuses[pss].State.Terminated == nil {
-					totalNumber++
-				}
-			}
-			podstatusPhase := string(pod.Status.Phase)
-			podCreationTime := pod.GetCreationTimestamp()
-			age := time.Since(podCreationTime.Time).Round(time.Second)
-			data = append(data, []string{pod.GetName(), podstatusPhase, strconv.Itoa(readyNumber), strconv.Itoa(totalNumber), fmt.Sprintf("%v", podCreationTime), age.String()})
-		}
-		table = tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Phase", "Ready Number", "Total Number", "Creation Time", "Age"})
+		fmt.Fprintln(writer)
+	}
+
+	if shoot.Hibernated {
+		fmt.Fprintln(writer, "This shoot is now in hibernating status")
+		fmt.Fprintln(writer, "Information like Nodes/Metrics/PDBs/Web hooks/etc will not be displayed")
+	}
+}
 
-		for _, v := range data {
-			table.Append(v)
+// renderDiagSection renders a section of a diagnostic report as table, or why it is unavailable
+func renderDiagSection(writer io.Writer, unavailable []DiagUnavailable, section, title string, header []string, rows [][]string) {
+	fmt.Fprintln(writer, title+":")
+	fmt.Fprintln(writer)
+	for _, missing := range unavailable {
+		if missing.Section == section {
+			fmt.Fprintf(writer, "unavailable: %s\n\n", missing.Reason)
+			return
 		}
-		table.Render()
+	}
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(header)
+	table.AppendBulk(rows)
+	table.Render()
+	fmt.Fprintln(writer)
+}
 
-	} else {
-		fmt.Println()
-		fmt.Println("This shoot is now in hibernating status")
-		fmt.Println("Information like Nodes/Metrics/PDBs/Web hooks/etc will not be displayed")
+// diagNodeHeader is the header of the node tables of the diagnostic reports
+var diagNodeHeader = []string{"Node Name", "Provider ID", "Address", "Ready", "CPU", "Memory", "Pressure"}
+
+func diagNodeRow(node DiagNode) []string {
+	pressure := "-"
+	if len(node.Pressure) > 0 {
+		pressure = strings.Join(node.Pressure, ",")
 	}
+	return []string{node.Name, node.ProviderID, node.InternalIP, node.Ready, node.CPU, node.Memory, pressure}
+}
 
+// diagPodHeader is the header of the pod tables of the diagnostic report
+var diagPodHeader = []string{"Name", "Phase", "Reason", "Ready", "Restarts", "Created", "Age"}
+
+func diagPodRows(pods []DiagPod, now time.Time) [][]string {
+	var rows [][]string
+	for _, pod := range pods {
+		rows = append(rows, []string{pod.Name, pod.Phase, valueOrDash(pod.Reason), fmt.Sprintf("%d/%d", pod.ReadyContainers, pod.Containers),
+			strconv.Itoa(int(pod.Restarts)), pod.CreatedAt, formatDiagAge(pod.CreatedAt, now)})
+	}
+	return rows
 }
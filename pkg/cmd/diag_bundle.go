@@ -0,0 +1,473 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	machineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// diagBundleConcurrency is the number of items of a diagnostic bundle which are collected at the same time
+	diagBundleConcurrency = 8
+	// diagBundleLogLines is the number of log lines collected per container
+	diagBundleLogLines = 5000
+	// diagBundleTimeFormat is used in the file names of diagnostic bundles
+	diagBundleTimeFormat = "20060102-150405"
+)
+
+// diagBundleExtensionResources are the extension resources in the control plane namespace whose status is collected
+var diagBundleExtensionResources = []string{"infrastructures", "controlplanes", "workers", "networks", "operatingsystemconfigs", "extensions", "containerruntimes"}
+
+// diagBundleRedactions replace credentials in the collected data
+var diagBundleRedactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "<redacted private key>"},
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), "<redacted token>"},
+	{regexp.MustCompile(`(?i)(bearer[ \t]+)[A-Za-z0-9._~+/-]+=*`), "${1}<redacted>"},
+	{regexp.MustCompile(`(?i)("?[\w.-]*(?:password|passwd|secret|token|api_?key|access_?key|credentials?|client-key-data|client-certificate-data)[\w.-]*"?[ \t]*[:=][ \t]*["']?)[^\s"',}]+`), "${1}<redacted>"},
+}
+
+// redactDiagBundleData replaces private keys, tokens, passwords and similar values in the data
+func redactDiagBundleData(data []byte) []byte {
+	for _, redaction := range diagBundleRedactions {
+		data = redaction.pattern.ReplaceAll(data, []byte(redaction.replacement))
+	}
+	return data
+}
+
+// diagBundleCollector collects the items of a diagnostic bundle concurrently, each within the timeout
+type diagBundleCollector struct {
+	timeout   time.Duration
+	semaphore chan struct{}
+
+	mutex sync.Mutex
+	// pending is the number of items being collected, plus one until wait is called
+	pending int
+	// finished is set once the last item has been collected, items added later are ignored
+	finished bool
+	done     chan struct{}
+	items    []DiagBundleItem
+	files    map[string][]byte
+}
+
+func newDiagBundleCollector(timeout time.Duration) *diagBundleCollector {
+	return &diagBundleCollector{
+		timeout:   timeout,
+		semaphore: make(chan struct{}, diagBundleConcurrency),
+		pending:   1,
+		done:      make(chan struct{}),
+		files:     map[string][]byte{},
+	}
+}
+
+// add collects the data of the item at the path in the background, collect may add further items. Items which
+// collect no data, e.g. because they only add further items, are listed without a file. Items added by a collection
+// which continues after its timeout are ignored once all other items have been collected.
+func (c *diagBundleCollector) add(path string, collect func() ([]byte, error)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.finished {
+		return
+	}
+	c.pending++
+	go c.run(path, collect)
+}
+
+// skip lists the item at the path as not collected
+func (c *diagBundleCollector) skip(path, reason string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.items = append(c.items, DiagBundleItem{Path: path, Error: reason})
+}
+
+func (c *diagBundleCollector) run(path string, collect func() ([]byte, error)) {
+	c.semaphore <- struct{}{}
+	defer func() { <-c.semaphore }()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	results := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		// a failing item must not abort the whole bundle
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				results <- result{err: fmt.Errorf("collection failed: %v", recovered)}
+			}
+		}()
+		data, err := collect()
+		results <- result{data, err}
+	}()
+
+	var r result
+	select {
+	case r = <-results:
+	case <-time.After(c.timeout):
+		// the collection cannot be cancelled, its result is ignored
+		r.err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	item := DiagBundleItem{Path: path, Collected: r.err == nil, Duration: time.Since(start).Round(time.Millisecond).String()}
+	if r.err != nil {
+		item.Error = r.err.Error()
+	}
+	if r.data != nil {
+		r.data = redactDiagBundleData(r.data)
+		item.Bytes = len(r.data)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.items = append(c.items, item)
+	if r.err == nil && r.data != nil {
+		c.files[path] = r.data
+	}
+	c.release()
+}
+
+// release marks an item as collected and finishes the collection after the last one, the mutex must be held
+func (c *diagBundleCollector) release() {
+	c.pending--
+	if c.pending == 0 {
+		c.finished = true
+		close(c.done)
+	}
+}
+
+// wait waits until all items have been collected and returns them sorted by path with the collected files
+func (c *diagBundleCollector) wait() ([]DiagBundleItem, map[string][]byte) {
+	c.mutex.Lock()
+	c.release()
+	c.mutex.Unlock()
+	<-c.done
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	sort.Slice(c.items, func(i, j int) bool {
+		return c.items[i].Path < c.items[j].Path
+	})
+	return c.items, c.files
+}
+
+// writeDiagBundle collects everything needed to analyse problems of the shoot into a timestamped tar.gz file in the
+// directory and returns its path with the index of the collected items
+func writeDiagBundle(shoot *gardencorev1beta1.Shoot, target TargetInterface, dir string, timeout time.Duration, now time.Time) (string, *DiagBundleIndex, error) {
+	name := fmt.Sprintf("gardenctl-diag-%s-%s-%s", shoot.Namespace, shoot.Name, now.UTC().Format(diagBundleTimeFormat))
+	collector := newDiagBundleCollector(timeout)
+	namespace := shoot.Status.TechnicalID
+
+	// all clients are created before the collection starts, since creating them changes the global kubeconfig
+	clients := newDiagClients(shoot, target)
+	gardenClient, gardenErr := target.K8SClientToKind(TargetKindGarden)
+	shootClient, shootErr := clients.shoot, clients.shootErr
+	seedClient, seedErr := clients.seed, clients.seedErr
+	var machineClientset machineclientset.Interface
+	machineErr := seedErr
+	if namespace != "" {
+		machineClientset, machineErr = target.MachineClient()
+	}
+	withClient := func(client kubernetes.Interface, err error, collect func(client kubernetes.Interface) ([]byte, error)) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			if err != nil {
+				return nil, err
+			}
+			return collect(client)
+		}
+	}
+
+	collector.add("shoot.yaml", func() ([]byte, error) {
+		manifest := shoot.DeepCopy()
+		manifest.APIVersion = gardencorev1beta1.SchemeGroupVersion.String()
+		manifest.Kind = "Shoot"
+		manifest.ManagedFields = nil
+		return yaml.Marshal(manifest)
+	})
+	collector.add("diag.json", func() ([]byte, error) {
+		report := getShootInformation(shoot, clients)
+		evaluateDiagRules(report)
+		return json.MarshalIndent(report, "", "  ")
+	})
+	collector.add("events/garden.yaml", withClient(gardenClient, gardenErr, func(client kubernetes.Interface) ([]byte, error) {
+		return collectDiagBundleEvents(client, "garden", shoot.Namespace, shoot.Name)
+	}))
+	collector.add("events/seed.yaml", withClient(seedClient, seedErr, func(client kubernetes.Interface) ([]byte, error) {
+		return collectDiagBundleEvents(client, "seed", namespace, "")
+	}))
+	collector.add("events/shoot.yaml", withClient(shootClient, shootErr, func(client kubernetes.Interface) ([]byte, error) {
+		return collectDiagBundleEvents(client, "shoot", metav1.NamespaceSystem, "")
+	}))
+	collector.add("logs/seed", withClient(seedClient, seedErr, func(client kubernetes.Interface) ([]byte, error) {
+		return nil, addDiagBundleLogs(collector, "logs/seed", client, namespace)
+	}))
+	collector.add("logs/shoot", withClient(shootClient, shootErr, func(client kubernetes.Interface) ([]byte, error) {
+		return nil, addDiagBundleLogs(collector, "logs/shoot", client, metav1.NamespaceSystem)
+	}))
+	collector.add("nodes.yaml", withClient(shootClient, shootErr, func(client kubernetes.Interface) ([]byte, error) {
+		nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range nodes.Items {
+			nodes.Items[i].ManagedFields = nil
+		}
+		return yaml.Marshal(nodes)
+	}))
+	collector.add("machines.yaml", func() ([]byte, error) {
+		if machineErr != nil {
+			return nil, machineErr
+		}
+		machines, err := getMachines(machineClientset, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(machines)
+	})
+	collector.add("terraform.yaml", withClient(seedClient, seedErr, func(client kubernetes.Interface) ([]byte, error) {
+		return collectDiagBundleTerraformStates(client, namespace)
+	}))
+	collector.skip("terraform/*.tfvars", "not collected, terraform variables contain credentials")
+	collector.add("extensions.yaml", withClient(seedClient, seedErr, func(client kubernetes.Interface) ([]byte, error) {
+		return collectDiagBundleExtensions(client, namespace)
+	}))
+
+	items, files := collector.wait()
+	index := &DiagBundleIndex{
+		Shoot:     shootKey(shoot),
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		Items:     items,
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	files["index.json"] = indexData
+
+	bundlePath := filepath.Join(dir, name+".tar.gz")
+	if err := writeTarGz(bundlePath, name, files, now); err != nil {
+		return "", nil, err
+	}
+	return bundlePath, index, nil
+}
+
+// collectDiagBundleEvents returns the events in the namespace, only those of the object with the given name if it is not empty
+func collectDiagBundleEvents(client kubernetes.Interface, source, namespace, name string) ([]byte, error) {
+	events, err := client.CoreV1().Events(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := []EventMeta{}
+	for _, event := range events.Items {
+		if name == "" || event.InvolvedObject.Name == name {
+			metas = append(metas, toEventMeta(source, event))
+		}
+	}
+	sort.SliceStable(metas, func(i, j int) bool {
+		return metas[i].LastSeen < metas[j].LastSeen
+	})
+	return yaml.Marshal(metas)
+}
+
+// toEventMeta returns the event read from the given source
+func toEventMeta(source string, event corev1.Event) EventMeta {
+	meta := EventMeta{
+		Source:    source,
+		Namespace: event.Namespace,
+		Object:    strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+		Type:      event.Type,
+		Reason:    event.Reason,
+		Message:   event.Message,
+		Count:     event.Count,
+		FirstSeen: formatDiagTime(event.FirstTimestamp),
+		LastSeen:  formatDiagTime(event.LastTimestamp),
+	}
+	if meta.LastSeen == "" {
+		meta.LastSeen = event.EventTime.UTC().Format(time.RFC3339)
+		if event.EventTime.IsZero() {
+			meta.LastSeen = formatDiagTime(event.CreationTimestamp)
+		}
+	}
+	if meta.FirstSeen == "" {
+		meta.FirstSeen = meta.LastSeen
+	}
+	if meta.Count == 0 {
+		meta.Count = 1
+	}
+	return meta
+}
+
+// addDiagBundleLogs adds the logs of all containers of the pods in the namespace to the bundle
+func addDiagBundleLogs(collector *diagBundleCollector, dir string, client kubernetes.Interface, namespace string) error {
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			podName, containerName := pod.Name, container.Name
+			collector.add(path.Join(dir, podName, containerName+".log"), func() ([]byte, error) {
+				lines := int64(diagBundleLogLines)
+				return client.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Container: containerName, TailLines: &lines}).
+					Timeout(collector.timeout).DoRaw()
+			})
+		}
+	}
+	return nil
+}
+
+// collectDiagBundleTerraformStates returns the metadata of the terraform states in the namespace, the states themselves
+// are not collected because they contain credentials
+func collectDiagBundleTerraformStates(client kubernetes.Interface, namespace string) ([]byte, error) {
+	configMaps, err := client.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	states := []TerraformStateMeta{}
+	for _, configMap := range configMaps.Items {
+		if !strings.HasSuffix(configMap.Name, ".tf-state") {
+			continue
+		}
+		data := configMap.Data["terraform.tfstate"]
+		meta := TerraformStateMeta{Name: configMap.Name, ResourceVersion: configMap.ResourceVersion, Bytes: len(data)}
+		if data != "" {
+			var state struct {
+				Version          int                        `json:"version"`
+				TerraformVersion string                     `json:"terraform_version"`
+				Serial           int                        `json:"serial"`
+				Lineage          string                     `json:"lineage"`
+				Resources        []json.RawMessage          `json:"resources"`
+				Outputs          map[string]json.RawMessage `json:"outputs"`
+			}
+			if err := json.Unmarshal([]byte(data), &state); err != nil {
+				meta.Error = err.Error()
+			}
+			meta.Version, meta.TerraformVersion, meta.Serial, meta.Lineage = state.Version, state.TerraformVersion, state.Serial, state.Lineage
+			meta.Resources = len(state.Resources)
+			for output := range state.Outputs {
+				meta.Outputs = append(meta.Outputs, output)
+			}
+			sort.Strings(meta.Outputs)
+		}
+		states = append(states, meta)
+	}
+	return yaml.Marshal(states)
+}
+
+// collectDiagBundleExtensions returns the status of the extension resources in the namespace
+func collectDiagBundleExtensions(client kubernetes.Interface, namespace string) ([]byte, error) {
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, errors.New("extension resources cannot be reached")
+	}
+	metas := []ExtensionResourceMeta{}
+	for _, resource := range diagBundleExtensionResources {
+		data, err := restClient.Get().AbsPath("/apis/extensions.gardener.cloud/v1alpha1/namespaces", namespace, resource).DoRaw()
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot list %s: %v", resource, err)
+		}
+		var list struct {
+			Items []struct {
+				Metadata metav1.ObjectMeta `json:"metadata"`
+				Spec     struct {
+					Type string `json:"type"`
+				} `json:"spec"`
+				Status struct {
+					ObservedGeneration int64                  `json:"observedGeneration"`
+					LastOperation      map[string]interface{} `json:"lastOperation"`
+					LastError          map[string]interface{} `json:"lastError"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("cannot read %s: %v", resource, err)
+		}
+		for _, item := range list.Items {
+			metas = append(metas, ExtensionResourceMeta{
+				Resource:           resource,
+				Name:               item.Metadata.Name,
+				Type:               item.Spec.Type,
+				ObservedGeneration: item.Status.ObservedGeneration,
+				LastOperation:      item.Status.LastOperation,
+				LastError:          item.Status.LastError,
+			})
+		}
+	}
+	return yaml.Marshal(metas)
+}
+
+// writeTarGz writes the files into a gzipped tar archive below the directory with the given name
+func writeTarGz(archivePath, dir string, files map[string][]byte, modTime time.Time) (err error) {
+	file, err := os.OpenFile(archivePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := &tar.Header{
+			Name:    path.Join(dir, name),
+			Mode:    0600,
+			Size:    int64(len(files[name])),
+			ModTime: modTime,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// exitCodeFindings is the exit code if the diagnostic report contains findings with the severity given by --fail-on
+const exitCodeFindings = 5
+
+// severityRanks orders the severities of findings
+var severityRanks = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+var (
+	diagRulesMutex sync.Mutex
+	diagRules      = []DiagRule{
+		crashLoopingSystemPodsRule{},
+		blockingPodDisruptionBudgetsRule{},
+		failingKubeSystemWebhooksRule{},
+		notReadyNodesRule{},
+		daemonSetShortfallRule{},
+		notReadyControlPlanePodsRule{},
+	}
+)
+
+// RegisterDiagRule adds a rule which is evaluated by the diag command. A rule with the name of an already registered
+// rule replaces it.
+func RegisterDiagRule(rule DiagRule) {
+	diagRulesMutex.Lock()
+	defer diagRulesMutex.Unlock()
+
+	for i, registered := range diagRules {
+		if registered.Name() == rule.Name() {
+			diagRules[i] = rule
+			return
+		}
+	}
+	diagRules = append(diagRules, rule)
+}
+
+// evaluateDiagRules sets the findings of all registered rules in the report, the most severe first
+func evaluateDiagRules(report *DiagReport) {
+	diagRulesMutex.Lock()
+	rules := append([]DiagRule{}, diagRules...)
+	diagRulesMutex.Unlock()
+
+	report.Findings = nil
+	for _, rule := range rules {
+		report.Findings = append(report.Findings, rule.Evaluate(report)...)
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Object < b.Object
+	})
+}
+
+// parseSeverity returns the severity with the given name
+func parseSeverity(name string) (Severity, error) {
+	severity := Severity(name)
+	if _, ok := severityRanks[severity]; !ok {
+		return "", fmt.Errorf("invalid severity %q, must be one of %s, %s or %s", name, SeverityInfo, SeverityWarning, SeverityError)
+	}
+	return severity, nil
+}
+
+// countDiagFindings returns the number of findings with at least the given severity
+func countDiagFindings(findings []DiagFinding, severity Severity) int {
+	count := 0
+	for _, finding := range findings {
+		if severityRanks[finding.Severity] >= severityRanks[severity] {
+			count++
+		}
+	}
+	return count
+}
+
+// crashLoopingSystemPodsRule finds system components which crash repeatedly
+type crashLoopingSystemPodsRule struct{}
+
+func (crashLoopingSystemPodsRule) Name() string { return "crash-looping-system-pods" }
+
+func (r crashLoopingSystemPodsRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, pod := range report.SystemPods {
+		if pod.Reason == "CrashLoopBackOff" {
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityError,
+				Object:      "pod kube-system/" + pod.Name,
+				Message:     fmt.Sprintf("pod is in CrashLoopBackOff after %d restarts", pod.Restarts),
+				Remediation: fmt.Sprintf("check the logs of the crashed container with \"kubectl -n kube-system logs %s --previous\"", pod.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// blockingPodDisruptionBudgetsRule finds pod disruption budgets which block the eviction of pods when nodes are rolled
+type blockingPodDisruptionBudgetsRule struct{}
+
+func (blockingPodDisruptionBudgetsRule) Name() string { return "blocking-pod-disruption-budgets" }
+
+func (r blockingPodDisruptionBudgetsRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, pdb := range report.PodDisruptionBudgets {
+		if pdb.DisruptionsAllowed == 0 {
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityWarning,
+				Object:      fmt.Sprintf("poddisruptionbudget %s/%s", pdb.Namespace, pdb.Name),
+				Message:     fmt.Sprintf("budget allows no disruptions with %d of %d healthy pods, node rolls are blocked", pdb.CurrentHealthy, pdb.DesiredHealthy),
+				Remediation: "make the selected pods healthy or relax the budget before nodes are rolled, e.g. during maintenance",
+			})
+		}
+	}
+	return findings
+}
+
+// failingKubeSystemWebhooksRule finds webhooks which block requests for kube-system objects if they are unavailable
+type failingKubeSystemWebhooksRule struct{}
+
+func (failingKubeSystemWebhooksRule) Name() string { return "failing-kube-system-webhooks" }
+
+func (r failingKubeSystemWebhooksRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, webhook := range report.Webhooks {
+		if webhook.FailurePolicy == "Fail" && webhook.MatchesKubeSystem {
+			message := fmt.Sprintf("webhook %s with failure policy Fail matches kube-system by its namespace selector, system components cannot be changed while it is unavailable", webhook.Name)
+			if webhook.ObjectSelector != "" {
+				// the labels of the objects are not known, so only the namespace selector is checked
+				message += fmt.Sprintf(" unless its object selector %s excludes them, which is not checked", webhook.ObjectSelector)
+			}
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityWarning,
+				Object:      fmt.Sprintf("%swebhookconfiguration %s", strings.ToLower(webhook.Kind), webhook.Configuration),
+				Message:     message,
+				Remediation: "exclude kube-system with the namespace selector of the webhook or set its failure policy to Ignore",
+			})
+		}
+	}
+	return findings
+}
+
+// notReadyNodesRule finds nodes which are not ready
+type notReadyNodesRule struct{}
+
+func (notReadyNodesRule) Name() string { return "not-ready-nodes" }
+
+func (r notReadyNodesRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, node := range report.Nodes {
+		if node.Ready != string(corev1.ConditionTrue) {
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityError,
+				Object:      "node " + node.Name,
+				Message:     fmt.Sprintf("node is not ready, its ready condition is %s", node.Ready),
+				Remediation: fmt.Sprintf("check the conditions of the node with \"kubectl describe node %s\", nodes which stay not ready are replaced by the machine controller", node.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// daemonSetShortfallRule finds daemon sets with fewer available pods than desired
+type daemonSetShortfallRule struct{}
+
+func (daemonSetShortfallRule) Name() string { return "daemonset-shortfall" }
+
+func (r daemonSetShortfallRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, daemonSet := range report.DaemonSets {
+		if daemonSet.Available < daemonSet.Desired {
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityWarning,
+				Object:      "daemonset kube-system/" + daemonSet.Name,
+				Message:     fmt.Sprintf("%d of %d desired pods are available", daemonSet.Available, daemonSet.Desired),
+				Remediation: fmt.Sprintf("check the pods of the daemon set which are not available with \"kubectl -n kube-system describe daemonset %s\"", daemonSet.Name),
+			})
+		}
+	}
+	return findings
+}
+
+// notReadyControlPlanePodsRule finds control plane pods of the shoot which are not ready
+type notReadyControlPlanePodsRule struct{}
+
+func (notReadyControlPlanePodsRule) Name() string { return "not-ready-control-plane-pods" }
+
+func (r notReadyControlPlanePodsRule) Evaluate(report *DiagReport) []DiagFinding {
+	var findings []DiagFinding
+	for _, pod := range report.ControlPlanePods {
+		if !pod.Ready && pod.Phase != string(corev1.PodSucceeded) {
+			findings = append(findings, DiagFinding{
+				Rule:        r.Name(),
+				Severity:    SeverityError,
+				Object:      fmt.Sprintf("pod %s/%s", report.Shoot.TechnicalID, pod.Name),
+				Message:     fmt.Sprintf("control plane pod is not ready, %d of %d containers are ready", pod.ReadyContainers, pod.Containers),
+				Remediation: fmt.Sprintf("check the events and logs of the pod in namespace %s of seed %s", report.Shoot.TechnicalID, valueOrDash(report.Shoot.Seed)),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// gardenletSelector selects the gardenlet pods in the garden namespace of a seed
+	gardenletSelector = "app=gardener,role=gardenlet"
+	// controlPlaneNamespacePrefix is the prefix of the control plane namespaces of shoots on a seed
+	controlPlaneNamespacePrefix = "shoot--"
+)
+
+// sections of the diagnostic report of a seed
+const (
+	diagSectionCapacity                = "capacity"
+	diagSectionGardenletPods           = "gardenletPods"
+	diagSectionControllerInstallations = "controllerInstallations"
+	diagSectionFailingNamespaces       = "failingNamespaces"
+	diagSectionFailedShoots            = "failedShoots"
+)
+
+// isSeedTargeted returns whether a seed without a shoot is targeted
+func isSeedTargeted(target TargetInterface) bool {
+	stack := target.Stack()
+	return len(stack) == 2 && stack[1].Kind == TargetKindSeed
+}
+
+// getSeedInformation collects the health of the seed, its extensions and the shoots on it, sections which cannot be
+// collected are recorded as unavailable
+func getSeedInformation(seedName string, target TargetInterface) (*SeedDiagReport, error) {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return nil, err
+	}
+	seed, err := gardenClientset.CoreV1beta1().Seeds().Get(seedName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SeedDiagReport{
+		Seed: DiagSeedSummary{
+			Name:              seed.Name,
+			Provider:          seed.Spec.Provider.Type,
+			Region:            seed.Spec.Provider.Region,
+			KubernetesVersion: stringValue(seed.Status.KubernetesVersion),
+		},
+	}
+	if seed.Status.Gardener != nil {
+		report.Seed.GardenerVersion = seed.Status.Gardener.Version
+	}
+	for _, taint := range seed.Spec.Taints {
+		if taint.Value != nil {
+			report.Seed.Taints = append(report.Seed.Taints, taint.Key+"="+*taint.Value)
+		} else {
+			report.Seed.Taints = append(report.Seed.Taints, taint.Key)
+		}
+	}
+	for _, condition := range seed.Status.Conditions {
+		report.Conditions = append(report.Conditions, DiagCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: formatDiagTime(condition.LastTransitionTime),
+		})
+	}
+	markSeedDiagUnavailable(report, diagSectionCapacity, errors.New("capacity and allocatable shoots are not reported by the seeds of this Gardener version"))
+	markSeedDiagUnavailable(report, diagSectionFailedShoots, collectSeedDiagShoots(gardenClientset, report))
+	markSeedDiagUnavailable(report, diagSectionControllerInstallations, collectSeedDiagControllerInstallations(gardenClientset, report))
+
+	if seedClient, err := target.K8SClientToKind(TargetKindSeed); err != nil {
+		for _, section := range []string{diagSectionGardenletPods, diagSectionNodes, diagSectionFailingNamespaces} {
+			markSeedDiagUnavailable(report, section, err)
+		}
+	} else {
+		markSeedDiagUnavailable(report, diagSectionGardenletPods, collectSeedDiagGardenletPods(seedClient, report))
+		report.Nodes, err = listDiagNodes(seedClient)
+		markSeedDiagUnavailable(report, diagSectionNodes, err)
+		markSeedDiagUnavailable(report, diagSectionFailingNamespaces, collectSeedDiagFailingNamespaces(seedClient, report))
+	}
+	return report, nil
+}
+
+// markSeedDiagUnavailable records the section as unavailable if it could not be collected
+func markSeedDiagUnavailable(report *SeedDiagReport, section string, err error) {
+	if err != nil {
+		report.Unavailable = append(report.Unavailable, DiagUnavailable{Section: section, Reason: err.Error()})
+	}
+}
+
+// collectSeedDiagShoots counts the shoots on the seed and collects those whose last operation failed
+func collectSeedDiagShoots(gardenClientset gardencoreclientset.Interface, report *SeedDiagReport) error {
+	shoots, err := gardenClientset.CoreV1beta1().Shoots(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, shoot := range shoots.Items {
+		seedName := stringValue(shoot.Status.SeedName)
+		if seedName == "" {
+			seedName = stringValue(shoot.Spec.SeedName)
+		}
+		if seedName != report.Seed.Name {
+			continue
+		}
+		report.Seed.Shoots++
+
+		operation := shoot.Status.LastOperation
+		if operation == nil || (operation.State != gardencorev1beta1.LastOperationStateFailed && operation.State != gardencorev1beta1.LastOperationStateError) {
+			continue
+		}
+		report.FailedShoots = append(report.FailedShoots, DiagFailedShoot{
+			Shoot:       shootKey(&shoot),
+			Type:        string(operation.Type),
+			State:       string(operation.State),
+			Description: operation.Description,
+			LastUpdate:  formatDiagTime(operation.LastUpdateTime),
+		})
+	}
+	sort.Slice(report.FailedShoots, func(i, j int) bool {
+		return report.FailedShoots[i].Shoot < report.FailedShoots[j].Shoot
+	})
+	return nil
+}
+
+// collectSeedDiagControllerInstallations collects the health of the extension controllers installed on the seed
+func collectSeedDiagControllerInstallations(gardenClientset gardencoreclientset.Interface, report *SeedDiagReport) error {
+	installations, err := gardenClientset.CoreV1beta1().ControllerInstallations().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, installation := range installations.Items {
+		if installation.Spec.SeedRef.Name != report.Seed.Name {
+			continue
+		}
+		meta := toExtensionInstallationMeta(installation)
+		report.ControllerInstallations = append(report.ControllerInstallations, DiagControllerInstallation{
+			Name:         installation.Name,
+			Registration: installation.Spec.RegistrationRef.Name,
+			Installed:    meta.Installed,
+			Healthy:      meta.Healthy,
+			Message:      meta.Message,
+		})
+	}
+	sort.Slice(report.ControllerInstallations, func(i, j int) bool {
+		return report.ControllerInstallations[i].Registration < report.ControllerInstallations[j].Registration
+	})
+	return nil
+}
+
+// collectSeedDiagGardenletPods collects the gardenlet pods running on the seed
+func collectSeedDiagGardenletPods(seedClient kubernetes.Interface, report *SeedDiagReport) error {
+	pods, err := seedClient.CoreV1().Pods("garden").List(metav1.ListOptions{LabelSelector: gardenletSelector})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return errors.New("no gardenlet pods found in namespace garden")
+	}
+	for i := range pods.Items {
+		report.GardenletPods = append(report.GardenletPods, toDiagPod(&pods.Items[i]))
+	}
+	return nil
+}
+
+// collectSeedDiagFailingNamespaces collects the pods of the control plane namespaces which are not ready
+func collectSeedDiagFailingNamespaces(seedClient kubernetes.Interface, report *SeedDiagReport) error {
+	pods, err := seedClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	failing := map[string][]DiagPod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !strings.HasPrefix(pod.Namespace, controlPlaneNamespacePrefix) || pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if diagPod := toDiagPod(pod); !diagPod.Ready {
+			failing[pod.Namespace] = append(failing[pod.Namespace], diagPod)
+		}
+	}
+	for namespace, pods := range failing {
+		report.FailingNamespaces = append(report.FailingNamespaces, DiagFailingNamespace{Namespace: namespace, Pods: pods})
+	}
+	sort.Slice(report.FailingNamespaces, func(i, j int) bool {
+		return report.FailingNamespaces[i].Namespace < report.FailingNamespaces[j].Namespace
+	})
+	return nil
+}
+
+// renderSeedDiagReport renders the diagnostic report of a seed as tables
+func renderSeedDiagReport(report *SeedDiagReport, writer io.Writer) {
+	renderSection := func(section, title string, header []string, rows [][]string) {
+		renderDiagSection(writer, report.Unavailable, section, title, header, rows)
+	}
+
+	seed := report.Seed
+	fmt.Fprintln(writer, "The seed diagnostic information are as follows:")
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "Seed: "+seed.Name)
+	fmt.Fprintln(writer, "Provider: "+valueOrDash(seed.Provider))
+	fmt.Fprintln(writer, "Region: "+valueOrDash(seed.Region))
+	fmt.Fprintln(writer, "Kubernetes Version: "+valueOrDash(seed.KubernetesVersion))
+	fmt.Fprintln(writer, "Gardener Version: "+valueOrDash(seed.GardenerVersion))
+	fmt.Fprintln(writer, "Taints: "+valueOrDash(strings.Join(seed.Taints, ", ")))
+	fmt.Fprintf(writer, "Shoots: %d\n", seed.Shoots)
+	for _, missing := range report.Unavailable {
+		if missing.Section == diagSectionCapacity {
+			fmt.Fprintln(writer, "Capacity: unavailable, "+missing.Reason)
+		}
+	}
+	fmt.Fprintln(writer)
+
+	var rows [][]string
+	for _, condition := range report.Conditions {
+		rows = append(rows, []string{condition.Type, condition.Status, condition.Reason, condition.Message, condition.LastTransitionTime})
+	}
+	renderSection("conditions", "Seed Conditions", []string{"Type", "Status", "Reason", "Message", "Last Transition Time"}, rows)
+
+	rows = nil
+	for _, pod := range report.GardenletPods {
+		rows = append(rows, []string{pod.Name, pod.Phase, valueOrDash(pod.Reason), fmt.Sprintf("%d/%d", pod.ReadyContainers, pod.Containers), fmt.Sprint(pod.Restarts)})
+	}
+	renderSection(diagSectionGardenletPods, "Gardenlet", []string{"Name", "Phase", "Reason", "Ready", "Restarts"}, rows)
+
+	rows = nil
+	for _, installation := range report.ControllerInstallations {
+		rows = append(rows, []string{installation.Registration, installation.Installed, installation.Healthy, installation.Message})
+	}
+	renderSection(diagSectionControllerInstallations, "Extensions", []string{"Registration", "Installed", "Healthy", "Message"}, rows)
+
+	rows = nil
+	for _, node := range report.Nodes {
+		rows = append(rows, diagNodeRow(node))
+	}
+	renderSection(diagSectionNodes, "Nodes", diagNodeHeader, rows)
+
+	rows = nil
+	for _, namespace := range report.FailingNamespaces {
+		for _, pod := range namespace.Pods {
+			rows = append(rows, []string{namespace.Namespace, pod.Name, pod.Phase, valueOrDash(pod.Reason), fmt.Sprintf("%d/%d", pod.ReadyContainers, pod.Containers)})
+		}
+	}
+	renderSection(diagSectionFailingNamespaces, "Control Plane Namespaces with Failing Pods", []string{"Namespace", "Pod", "Phase", "Reason", "Ready"}, rows)
+
+	rows = nil
+	for _, shoot := range report.FailedShoots {
+		rows = append(rows, []string{shoot.Shoot, shoot.Type, shoot.State, shoot.Description, shoot.LastUpdate})
+	}
+	renderSection(diagSectionFailedShoots, "Shoots with Failed Last Operation", []string{"Shoot", "Type", "State", "Description", "Last Update"}, rows)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Diag command for seeds", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		target       *mockcmd.MockTargetInterface
+		seedClient   *kubernetesfake.Clientset
+		seedErr      error
+	)
+
+	seedName := "aws-eu1"
+	otherSeedName := "gcp-eu1"
+	protected := "true"
+
+	shootOnSeed := func(name, seed string, state gardencorev1beta1.LastOperationState) *gardencorev1beta1.Shoot {
+		return &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "garden-prod"},
+			Spec:       gardencorev1beta1.ShootSpec{SeedName: &seed},
+			Status: gardencorev1beta1.ShootStatus{
+				LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: state, Description: "reconciling " + name},
+			},
+		}
+	}
+	installation := func(name, seed string, healthy gardencorev1beta1.ConditionStatus) *gardencorev1beta1.ControllerInstallation {
+		return &gardencorev1beta1.ControllerInstallation{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-" + seed},
+			Spec: gardencorev1beta1.ControllerInstallationSpec{
+				RegistrationRef: corev1.ObjectReference{Name: name},
+				SeedRef:         corev1.ObjectReference{Name: seed},
+			},
+			Status: gardencorev1beta1.ControllerInstallationStatus{Conditions: []gardencorev1beta1.Condition{
+				{Type: gardencorev1beta1.ControllerInstallationInstalled, Status: gardencorev1beta1.ConditionTrue},
+				{Type: gardencorev1beta1.ControllerInstallationHealthy, Status: healthy, Message: name + " is " + string(healthy)},
+			}},
+		}
+	}
+	pod := func(namespace, name string, ready corev1.ConditionStatus, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name}}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewDiagCmd(targetReader, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		seedErr = nil
+
+		seedClient = kubernetesfake.NewSimpleClientset(
+			pod("garden", "gardenlet-1", corev1.ConditionTrue, map[string]string{"app": "gardener", "role": "gardenlet"}),
+			pod("garden", "gardener-resource-manager-1", corev1.ConditionFalse, nil),
+			pod("shoot--prod--a", "kube-apiserver-1", corev1.ConditionFalse, nil),
+			pod("shoot--prod--b", "kube-apiserver-1", corev1.ConditionTrue, nil),
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "seed-node-1"},
+				Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+					{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+					{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse},
+				}},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindSeed, Name: seedName},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Seed{
+				ObjectMeta: metav1.ObjectMeta{Name: seedName},
+				Spec: gardencorev1beta1.SeedSpec{
+					Provider: gardencorev1beta1.SeedProvider{Type: "aws", Region: "eu-west-1"},
+					Taints:   []gardencorev1beta1.SeedTaint{{Key: "seed.gardener.cloud/protected"}, {Key: "seed.gardener.cloud/invisible", Value: &protected}},
+				},
+				Status: gardencorev1beta1.SeedStatus{
+					Gardener: &gardencorev1beta1.Gardener{Version: "v1.5.0"},
+					Conditions: []gardencorev1beta1.Condition{
+						{Type: gardencorev1beta1.SeedGardenletReady, Status: gardencorev1beta1.ConditionTrue},
+						{Type: gardencorev1beta1.SeedBootstrapped, Status: gardencorev1beta1.ConditionTrue},
+						{Type: gardencorev1beta1.SeedExtensionsReady, Status: gardencorev1beta1.ConditionFalse, Message: "provider-aws unhealthy"},
+					},
+				},
+			},
+			shootOnSeed("a", seedName, gardencorev1beta1.LastOperationStateFailed),
+			shootOnSeed("b", seedName, gardencorev1beta1.LastOperationStateSucceeded),
+			shootOnSeed("c", otherSeedName, gardencorev1beta1.LastOperationStateFailed),
+			installation("provider-aws", seedName, gardencorev1beta1.ConditionFalse),
+			installation("networking-calico", seedName, gardencorev1beta1.ConditionTrue),
+			installation("provider-gcp", otherSeedName, gardencorev1beta1.ConditionFalse),
+		), nil).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			if seedErr != nil {
+				return nil, seedErr
+			}
+			return seedClient, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should report the health of the seed, its extensions and shoots", func() {
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("Seed: aws-eu1\nProvider: aws\nRegion: eu-west-1\n"))
+		Expect(out).To(ContainSubstring("Taints: seed.gardener.cloud/protected, seed.gardener.cloud/invisible=true\nShoots: 2\n"))
+		Expect(out).To(ContainSubstring("Capacity: unavailable, "))
+		Expect(out).To(MatchRegexp(`\| ExtensionsReady +\| False +\| +\| provider-aws unhealthy`))
+		Expect(out).To(MatchRegexp(`\| gardenlet-1 \| Running \|`))
+		Expect(out).NotTo(ContainSubstring("gardener-resource-manager-1"))
+		Expect(out).To(MatchRegexp(`\| networking-calico +\| True +\| True +\|`))
+		Expect(out).To(MatchRegexp(`\| provider-aws +\| True +\| False +\| provider-aws is False +\|`))
+		Expect(out).NotTo(ContainSubstring("provider-gcp"))
+		Expect(out).To(MatchRegexp(`\| seed-node-1 +\|.*\| MemoryPressure \|`))
+		Expect(out).To(MatchRegexp(`\| shoot--prod--a +\| kube-apiserver-1 \|`))
+		Expect(out).NotTo(ContainSubstring("shoot--prod--b"))
+		Expect(out).To(MatchRegexp(`\| garden-prod/a \| Reconcile \| Failed \| reconciling a +\|`))
+		Expect(out).NotTo(ContainSubstring("garden-prod/c"))
+	})
+
+	It("should record the sections of an unreachable seed as unavailable", func() {
+		seedErr = errors.New("connection refused")
+
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("Gardenlet:\n\nunavailable: connection refused\n"))
+		Expect(out).To(ContainSubstring("Control Plane Namespaces with Failing Pods:\n\nunavailable: connection refused\n"))
+		Expect(out).To(MatchRegexp(`\| garden-prod/a \| Reconcile \| Failed \|`))
+	})
+
+	It("should not collect bundles of seeds", func() {
+		_, err := execute("--bundle")
+
+		Expect(err).To(MatchError("--bundle and --fail-on require a targeted shoot"))
+	})
+})
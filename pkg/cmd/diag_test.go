@@ -0,0 +1,514 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	machineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+var _ = Describe("Diag command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		target       *mockcmd.MockTargetInterface
+		shoot        *gardencorev1beta1.Shoot
+		shootClient  *kubernetesfake.Clientset
+		seedClient   *kubernetesfake.Clientset
+		gardenClient *kubernetesfake.Clientset
+		shootErr     error
+		stack        []cmd.TargetMeta
+		// clientCreated is called whenever a client is created through the target
+		clientCreated func(kind string)
+	)
+
+	projectNamespace := "garden-prod"
+	fail := admissionregistrationv1beta1.Fail
+	maxUnavailable := intstr.FromInt(1)
+	volumeType := "gp2"
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewDiagCmd(targetReader, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		shootErr = nil
+		clientCreated = func(string) {}
+
+		// purpose, seed and last operation are not set
+		shoot = &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Spec: gardencorev1beta1.ShootSpec{
+				CloudProfileName: "aws",
+				Kubernetes:       gardencorev1beta1.Kubernetes{Version: "1.17.9"},
+				Provider: gardencorev1beta1.Provider{
+					Workers: []gardencorev1beta1.Worker{{
+						Name:    "cpu",
+						Minimum: 1,
+						Maximum: 3,
+						Machine: gardencorev1beta1.Machine{Type: "m5.large"},
+						Volume:  &gardencorev1beta1.Volume{Type: &volumeType, VolumeSize: "50Gi"},
+					}},
+				},
+			},
+			Status: gardencorev1beta1.ShootStatus{
+				TechnicalID: "shoot--prod--test-shoot",
+				Conditions:  []gardencorev1beta1.Condition{{Type: gardencorev1beta1.ShootAPIServerAvailable, Status: gardencorev1beta1.ConditionTrue}},
+			},
+		}
+		shootClient = kubernetesfake.NewSimpleClientset(
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "coredns-1", Namespace: "kube-system"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "coredns"}}},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+					ContainerStatuses: []corev1.ContainerStatus{{
+						RestartCount: 7,
+						State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					}},
+				},
+			},
+			&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberAvailable: 2},
+			},
+			// pod disruption budgets without selector must not break the report
+			&policyv1beta1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "blocking", Namespace: "kube-system"},
+				Spec:       policyv1beta1.PodDisruptionBudgetSpec{MaxUnavailable: &maxUnavailable},
+			},
+			&policyv1beta1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       policyv1beta1.PodDisruptionBudgetSpec{MinAvailable: &maxUnavailable, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+				Status:     policyv1beta1.PodDisruptionBudgetStatus{CurrentHealthy: 1, DesiredHealthy: 1},
+			},
+			&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Labels: map[string]string{"gardener.cloud/purpose": "kube-system"}},
+			},
+			&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+				Webhooks:   []admissionregistrationv1beta1.ValidatingWebhook{{Name: "validate.policy.io", FailurePolicy: &fail}},
+			},
+			&admissionregistrationv1beta1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "sidecars"},
+				Webhooks: []admissionregistrationv1beta1.MutatingWebhook{{
+					Name:          "inject.sidecars.io",
+					FailurePolicy: &fail,
+					NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "gardener.cloud/purpose", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"kube-system"}},
+					}},
+				}},
+			},
+		)
+		seedClient = kubernetesfake.NewSimpleClientset(
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver-1", Namespace: "shoot--prod--test-shoot"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "kube-apiserver"}}},
+				Status: corev1.PodStatus{
+					Phase:             corev1.PodRunning,
+					Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+					ContainerStatuses: []corev1.ContainerStatus{{Ready: true}},
+				},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-shoot.infra.tf-state", Namespace: "shoot--prod--test-shoot"},
+				Data: map[string]string{"terraform.tfstate": `{"version": 4, "terraform_version": "0.12.20", "serial": 12, "lineage": "1a2b",
+					"outputs": {"vpc_id": {"value": "vpc-4711"}}, "resources": [{"type": "aws_vpc", "instances": [{"attributes": {"secret": "s3cr3t"}}]}]}`},
+			},
+			&corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "kube-apiserver-1.1", Namespace: "shoot--prod--test-shoot"},
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "kube-apiserver-1"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "Unhealthy",
+				Message:        "Readiness probe failed with Authorization: Bearer abcdef.123456",
+			},
+			&corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "kube-apiserver-1.2", Namespace: "shoot--prod--test-shoot"},
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "kube-apiserver-1"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "InvalidKubeconfig",
+				Message:        "Cannot load kubeconfig with client-certificate-data: Q0VSVElGSUNBVEU= and client-key-data: UFJJVkFURSBLRVk=",
+			},
+		)
+		gardenClient = kubernetesfake.NewSimpleClientset(
+			&corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "test-shoot.1", Namespace: projectNamespace},
+				InvolvedObject: corev1.ObjectReference{Kind: "Shoot", Name: "test-shoot"},
+				Type:           corev1.EventTypeNormal,
+				Reason:         "Reconciling",
+				Message:        "Reconciling shoot with password=hunter2",
+			},
+			&corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: "other-shoot.1", Namespace: projectNamespace},
+				InvolvedObject: corev1.ObjectReference{Kind: "Shoot", Name: "other-shoot"},
+				Reason:         "Reconciling",
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) {
+			return gardencorefake.NewSimpleClientset(
+				&gardencorev1beta1.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+					Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+				},
+				shoot,
+			), nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindShoot).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			clientCreated("shoot")
+			if shootErr != nil {
+				return nil, shootErr
+			}
+			return shootClient, nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			clientCreated("seed")
+			return seedClient, nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindGarden).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			clientCreated("garden")
+			return gardenClient, nil
+		}).AnyTimes()
+		target.EXPECT().MachineClient().DoAndReturn(func() (machineclientset.Interface, error) {
+			clientCreated("machine")
+			return nil, errors.New("machine client not available")
+		}).AnyTimes()
+		metricsClient := metricsfake.NewSimpleClientset()
+		// the fake tracker does not map the node metrics to their resource, so they are listed by a reactor
+		metricsClient.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &metricsv1beta1.NodeMetricsList{Items: []metricsv1beta1.NodeMetrics{{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}}}, nil
+		})
+		target.EXPECT().MetricsClient().DoAndReturn(func() (*metricsfake.Clientset, error) {
+			clientCreated("metrics")
+			return metricsClient, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should render the report of a shoot without purpose, seed and last operation", func() {
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("Shoot: garden-prod/test-shoot\n"))
+		Expect(out).To(ContainSubstring("Purpose: -\nSeed Name: -\n"))
+		Expect(out).To(MatchRegexp(`\| node-1 +\| +\| +\| False +\| +0 +\| +0 +\|`))
+		Expect(out).To(MatchRegexp(`\| coredns-1 +\| Running \| CrashLoopBackOff \| 0/1 +\| +7 `))
+		Expect(out).To(MatchRegexp(`\| kube-system \| blocking \| - +\| - +\| +1 +\| +0 +\|`))
+		Expect(out).To(MatchRegexp(`\| default +\| app +\| app=web +\| +1 \| - +\| +0 \|`))
+		Expect(out).To(MatchRegexp(`\| Validating \| policy +\| validate.policy.io \| Fail `))
+		Expect(out).To(MatchRegexp(`\| kube-apiserver-1 \| Running \| - +\| 1/1 `))
+		Expect(out).To(ContainSubstring("Last Operation:\n\nunavailable: shoot has no last operation\n"))
+		Expect(out).To(MatchRegexp(`\| cpu +\| +1 \| +3 \| - +\| - +\| - +\| - +\| m5.large +\| +\| - +\| gp2 +\| 50Gi +\|`))
+		Expect(out).To(MatchRegexp(`\| node-1 +\| 250m +\| 1Gi +\|`))
+	})
+
+	It("should record the sections of an unreachable shoot as unavailable", func() {
+		shootErr = errors.New("connection refused")
+
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("Nodes:\n\nunavailable: connection refused\n"))
+		Expect(out).To(ContainSubstring("Webhooks:\n\nunavailable: connection refused\n"))
+		Expect(out).To(MatchRegexp(`\| kube-apiserver-1 \| Running `))
+	})
+
+	It("should return an error if the shoot targeted via its seed does not exist anymore", func() {
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindSeed, Name: "test-seed"},
+			{Kind: cmd.TargetKindShoot, Name: "gone"},
+		}
+
+		_, err := execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(`no shoot found with name "gone"`))
+	})
+
+	Context("when the shoot is hibernated", func() {
+		BeforeEach(func() {
+			shoot.Status.IsHibernated = true
+		})
+
+		It("should not collect the sections of the shoot cluster", func() {
+			out, err := execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("System Components:\n\nunavailable: shoot is hibernated\n"))
+			Expect(out).To(HaveSuffix("This shoot is now in hibernating status\nInformation like Nodes/Metrics/PDBs/Web hooks/etc will not be displayed\n"))
+		})
+	})
+
+	It("should list the findings of the rules, the most severe first", func() {
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchRegexp(`(?s)Findings:.*` +
+			`\| error +\| crash-looping-system-pods +\| pod kube-system/coredns-1 .*` +
+			`\| error +\| not-ready-nodes +\| node node-1 .*` +
+			`\| warning +\| blocking-pod-disruption-budgets +\| poddisruptionbudget .*\| +\| +\| default/app .*` +
+			`\| warning +\| blocking-pod-disruption-budgets +\| poddisruptionbudget .*\| +\| +\| kube-system/blocking .*` +
+			`\| warning +\| daemonset-shortfall +\| daemonset .*` +
+			`\| warning +\| failing-kube-system-webhooks +\| validatingwebhookconfiguration `))
+		Expect(out).NotTo(ContainSubstring("mutatingwebhookconfiguration"))
+		Expect(out).NotTo(ContainSubstring("not-ready-control-plane-pods"))
+	})
+
+	It("should say that the object selector of a webhook is not checked", func() {
+		_, err := shootClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Create(&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "labelled"},
+			Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{{
+				Name:           "validate.labelled.io",
+				FailurePolicy:  &fail,
+				ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"policy": "enforced"}},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		// the table wraps long messages, so they are matched word by word
+		Expect(out).To(MatchRegexp(`(?s)validate\.labelled\.io.*object selector.*policy=enforced.*not checked`))
+		Expect(strings.Count(out, "object selector")).To(Equal(1))
+	})
+
+	It("should exit with a distinct code if there are findings with the --fail-on severity", func() {
+		_, err := execute("--fail-on", "error")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("found 2 problems with severity error or higher"))
+		Expect(cmd.ExitCode(err)).To(Equal(5))
+	})
+
+	It("should reject unknown severities", func() {
+		_, err := execute("--fail-on", "fatal")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(`invalid severity "fatal", must be one of info, warning or error`))
+	})
+
+	Context("with --bundle", func() {
+		var dir string
+
+		// readBundle returns the files of the bundle by their path below its top-level directory
+		readBundle := func(path string) map[string]string {
+			file, err := os.Open(path)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			gzipReader, err := gzip.NewReader(file)
+			Expect(err).NotTo(HaveOccurred())
+			tarReader := tar.NewReader(gzipReader)
+
+			files := map[string]string{}
+			for {
+				header, err := tarReader.Next()
+				if err != nil {
+					break
+				}
+				data, err := ioutil.ReadAll(tarReader)
+				Expect(err).NotTo(HaveOccurred())
+				files[header.Name[strings.Index(header.Name, "/")+1:]] = string(data)
+			}
+			return files
+		}
+		bundleIndex := func(files map[string]string) map[string]cmd.DiagBundleItem {
+			var index cmd.DiagBundleIndex
+			Expect(json.Unmarshal([]byte(files["index.json"]), &index)).To(Succeed())
+			items := map[string]cmd.DiagBundleItem{}
+			for _, item := range index.Items {
+				items[item.Path] = item
+			}
+			return items
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "gardenctl-diag-")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should collect the bundle and list what was not collected", func() {
+			out, err := execute("--bundle", "--bundle-dir", dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			bundles, err := filepath.Glob(filepath.Join(dir, "gardenctl-diag-garden-prod-test-shoot-*.tar.gz"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundles).To(HaveLen(1))
+			Expect(out).To(HavePrefix("Diagnostic bundle written to " + bundles[0]))
+
+			files := readBundle(bundles[0])
+			Expect(files["shoot.yaml"]).To(ContainSubstring("kind: Shoot\n"))
+			Expect(files["diag.json"]).To(ContainSubstring(`"rule": "crash-looping-system-pods"`))
+			Expect(files["events/garden.yaml"]).To(ContainSubstring("object: shoot/test-shoot\n"))
+			Expect(files["events/garden.yaml"]).NotTo(ContainSubstring("other-shoot"))
+			Expect(files["terraform.yaml"]).To(ContainSubstring("lineage: 1a2b\n"))
+			Expect(files["terraform.yaml"]).To(ContainSubstring("- vpc_id\n"))
+			Expect(files["terraform.yaml"]).NotTo(ContainSubstring("vpc-4711"))
+			Expect(files["terraform.yaml"]).NotTo(ContainSubstring("s3cr3t"))
+
+			items := bundleIndex(files)
+			Expect(items["nodes.yaml"].Collected).To(BeTrue())
+			Expect(items["logs/shoot"].Collected).To(BeTrue())
+			Expect(items).To(HaveKey("logs/shoot/coredns-1/coredns.log"))
+			Expect(items).To(HaveKey("logs/seed/kube-apiserver-1/kube-apiserver.log"))
+			Expect(items["machines.yaml"]).To(Equal(cmd.DiagBundleItem{Path: "machines.yaml", Error: "machine client not available", Duration: items["machines.yaml"].Duration}))
+			Expect(items["extensions.yaml"].Error).To(Equal("extension resources cannot be reached"))
+			Expect(items["terraform/*.tfvars"].Collected).To(BeFalse())
+		})
+
+		It("should create all clients of a project-targeted shoot before the items are collected", func() {
+			// creating a client through the target changes the global kubeconfig, which the collection must not race
+			// with, so the fake clients read what the creation writes and "go test -race" reports overlaps
+			var (
+				kubeconfig string
+				created    []string
+			)
+			clientCreated = func(kind string) {
+				kubeconfig = kind
+				created = append(created, kind)
+			}
+			for _, client := range []*kubernetesfake.Clientset{gardenClient, seedClient, shootClient} {
+				client.PrependReactor("*", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+					if kubeconfig == "" {
+						return true, nil, errors.New("no client created")
+					}
+					return false, nil, nil
+				})
+			}
+
+			_, err := execute("--bundle", "--bundle-dir", dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(Equal([]string{"shoot", "metrics", "seed", "garden", "machine"}))
+			bundles, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+			items := bundleIndex(readBundle(bundles[0]))
+			Expect(items["diag.json"].Collected).To(BeTrue())
+			Expect(items["nodes.yaml"].Collected).To(BeTrue())
+			Expect(items["machines.yaml"].Error).To(Equal("machine client not available"))
+		})
+
+		It("should redact credentials", func() {
+			_, err := execute("--bundle", "--bundle-dir", dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			bundles, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+			files := readBundle(bundles[0])
+			Expect(files["events/garden.yaml"]).To(ContainSubstring("password=<redacted>"))
+			Expect(files["events/seed.yaml"]).To(ContainSubstring("Authorization: Bearer <redacted>"))
+			Expect(files["events/seed.yaml"]).NotTo(ContainSubstring("abcdef"))
+			Expect(files["events/seed.yaml"]).To(ContainSubstring("client-certificate-data: <redacted>"))
+			Expect(files["events/seed.yaml"]).To(ContainSubstring("client-key-data: <redacted>"))
+			Expect(files["events/seed.yaml"]).NotTo(ContainSubstring("Q0VSVElGSUNBVEU="))
+			Expect(files["events/seed.yaml"]).NotTo(ContainSubstring("UFJJVkFURSBLRVk="))
+		})
+
+		It("should record items which are not collected in time", func() {
+			gardenClient.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				time.Sleep(500 * time.Millisecond)
+				return false, nil, nil
+			})
+
+			_, err := execute("--bundle", "--bundle-dir", dir, "--item-timeout", "100ms")
+
+			Expect(err).NotTo(HaveOccurred())
+			bundles, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+			files := readBundle(bundles[0])
+			Expect(files).NotTo(HaveKey("events/garden.yaml"))
+			Expect(bundleIndex(files)["events/garden.yaml"].Error).To(Equal("timed out after 100ms"))
+		})
+	})
+
+	It("should evaluate registered rules", func() {
+		cmd.RegisterDiagRule(&testDiagRule{})
+
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchRegexp(`\| info +\| test-rule +\| shoot garden-prod/test-shoot +\| registered rules are evaluated \|`))
+	})
+})
+
+// testDiagRule reports every shoot
+type testDiagRule struct{}
+
+func (testDiagRule) Name() string { return "test-rule" }
+
+func (r testDiagRule) Evaluate(report *cmd.DiagReport) []cmd.DiagFinding {
+	return []cmd.DiagFinding{{
+		Rule:     r.Name(),
+		Severity: cmd.SeverityInfo,
+		Object:   "shoot " + report.Shoot.Namespace + "/" + report.Shoot.Name,
+		Message:  "registered rules are evaluated",
+	}}
+}
@@ -0,0 +1,473 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// editHeader explains the edited file to the user
+	editHeader = `# Please edit the shoot below. Lines beginning with a '#' will be ignored,
+# and an empty file will abort the edit. The status and the fields managed
+# by gardener are not shown.
+`
+	// lastAppliedConfigAnnotation is managed by kubectl apply
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+	// diffContextLines is the number of unchanged lines shown around changes
+	diffContextLines = 3
+)
+
+// managedShootAnnotations are not shown for editing and kept as they are
+var managedShootAnnotations = []string{lastAppliedConfigAnnotation}
+
+// editableShoot contains the fields of a shoot which can be edited
+type editableShoot struct {
+	APIVersion string                      `json:"apiVersion"`
+	Kind       string                      `json:"kind"`
+	Metadata   editableShootMeta           `json:"metadata"`
+	Spec       gardencorev1beta1.ShootSpec `json:"spec"`
+}
+
+// editableShootMeta contains the metadata of a shoot which can be edited
+type editableShootMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NewEditCmd returns a new edit command.
+func NewEditCmd(targetReader TargetReader, configReader ConfigReader, editor Editor, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit a resource in $EDITOR, e.g. \"gardenctl edit shoot\"",
+	}
+	cmd.AddCommand(newEditShootCmd(targetReader, configReader, editor, ioStreams))
+
+	return cmd
+}
+
+func newEditShootCmd(targetReader TargetReader, configReader ConfigReader, editor Editor, ioStreams IOStreams) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:          "shoot [name]",
+		Short:        "Edit the spec of the targeted or given shoot in $EDITOR, validate it and show the changes before applying them",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("command must be in the format: edit shoot [name]")
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, name, "")
+			if err != nil {
+				return err
+			}
+			if err := confirmShootRestrictions(target, configReader, shoots, yes, ioStreams); err != nil {
+				return err
+			}
+
+			return editShoot(gardenClientset, &shoots[0], editor, yes, ioStreams)
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "apply the changes without asking for confirmation")
+
+	return cmd
+}
+
+// editShoot lets the user edit the shoot until it is valid and applies the changes. If the shoot has been modified
+// in the meantime, the user is offered to edit the changes again on top of the latest version.
+func editShoot(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, editor Editor, yes bool, ioStreams IOStreams) error {
+	cloudProfile, err := gardenClientset.CoreV1beta1().CloudProfiles().Get(shoot.Spec.CloudProfileName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile("", "gardenctl-edit-*.yaml")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	original, err := toEditableShootYAML(shoot)
+	if err != nil {
+		return err
+	}
+	content, notes := original, []string(nil)
+	var problems []string
+	for {
+		if err := ioutil.WriteFile(file.Name(), []byte(editHeader+formatEditNotes(notes)+content), 0600); err != nil {
+			return err
+		}
+		if err := editor.Edit(file.Name()); err != nil {
+			return fmt.Errorf("editor failed: %v", err)
+		}
+		data, err := ioutil.ReadFile(file.Name())
+		if err != nil {
+			return err
+		}
+		edited := stripEditComments(string(data))
+		switch {
+		case strings.TrimSpace(edited) == "":
+			fmt.Fprintln(ioStreams.Out, "Edit cancelled, the file is empty")
+			return nil
+		case edited == original:
+			fmt.Fprintln(ioStreams.Out, "Edit cancelled, no changes made")
+			return nil
+		case len(problems) > 0 && edited == content:
+			return fmt.Errorf("shoot %s is invalid:\n  - %s", shootKey(shoot), strings.Join(problems, "\n  - "))
+		}
+		content = edited
+
+		var updated *gardencorev1beta1.Shoot
+		updated, problems = parseEditedShoot(shoot, edited, cloudProfile, time.Now())
+		if len(problems) > 0 {
+			notes = append([]string{"The edited shoot is invalid, save it unchanged to abort:"}, problems...)
+			continue
+		}
+
+		fmt.Fprintf(ioStreams.Out, "Shoot %s:\n", shootKey(shoot))
+		renderLineDiff(original, edited, isTerminal(ioStreams.Out), ioStreams.Out)
+		if changes := rollingUpdateChanges(shoot, updated); len(changes) > 0 {
+			warning := fmt.Sprintf("Warning: the following changes roll the nodes of the shoot:\n  - %s\n", strings.Join(changes, "\n  - "))
+			if isTerminal(ioStreams.ErrOut) {
+				warning = fmt.Sprintf(warningColor, warning)
+			}
+			fmt.Fprint(ioStreams.ErrOut, warning)
+		}
+		if !yes {
+			confirmed, err := confirm("Do you want to apply the changes? Type 'yes' to confirm: ", ioStreams)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Fprintln(ioStreams.Out, "Edit cancelled")
+				return nil
+			}
+		}
+
+		_, err = gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Update(updated)
+		if !apierrors.IsConflict(err) {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(ioStreams.Out, "Shoot %s edited\n", shootKey(shoot))
+			return nil
+		}
+
+		fmt.Fprintf(ioStreams.ErrOut, "Shoot %s has been modified in the meantime\n", shootKey(shoot))
+		confirmed, confirmErr := confirm("Do you want to edit your changes again on top of the latest version? Type 'yes' to confirm: ", ioStreams)
+		if confirmErr != nil {
+			return confirmErr
+		}
+		if !confirmed {
+			return err
+		}
+		if shoot, err = gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Get(shoot.Name, metav1.GetOptions{}); err != nil {
+			return err
+		}
+		if original, err = toEditableShootYAML(shoot); err != nil {
+			return err
+		}
+		notes = []string{"The shoot has been modified in the meantime, your changes will be compared with its latest version."}
+	}
+}
+
+// toEditableShootYAML returns the editable fields of the shoot as YAML
+func toEditableShootYAML(shoot *gardencorev1beta1.Shoot) (string, error) {
+	editable := editableShoot{
+		APIVersion: gardencorev1beta1.SchemeGroupVersion.String(),
+		Kind:       "Shoot",
+		Metadata: editableShootMeta{
+			Name:      shoot.Name,
+			Namespace: shoot.Namespace,
+			Labels:    shoot.Labels,
+		},
+		Spec: shoot.Spec,
+	}
+	for key, value := range shoot.Annotations {
+		if containsString(managedShootAnnotations, key) {
+			continue
+		}
+		if editable.Metadata.Annotations == nil {
+			editable.Metadata.Annotations = map[string]string{}
+		}
+		editable.Metadata.Annotations[key] = value
+	}
+
+	data, err := yaml.Marshal(editable)
+	return string(data), err
+}
+
+// parseEditedShoot returns the shoot with the edited fields, or the problems of the edited shoot
+func parseEditedShoot(shoot *gardencorev1beta1.Shoot, edited string, cloudProfile *gardencorev1beta1.CloudProfile, now time.Time) (*gardencorev1beta1.Shoot, []string) {
+	var editable editableShoot
+	if err := yaml.UnmarshalStrict([]byte(edited), &editable); err != nil {
+		return nil, []string{err.Error()}
+	}
+	if problems := validateEditedShoot(shoot, &editable, cloudProfile, now); len(problems) > 0 {
+		return nil, problems
+	}
+
+	updated := shoot.DeepCopy()
+	updated.Labels = editable.Metadata.Labels
+	updated.Annotations = editable.Metadata.Annotations
+	for _, key := range managedShootAnnotations {
+		if value, ok := shoot.Annotations[key]; ok {
+			if updated.Annotations == nil {
+				updated.Annotations = map[string]string{}
+			}
+			updated.Annotations[key] = value
+		}
+	}
+	updated.Spec = editable.Spec
+	return updated, nil
+}
+
+// validateEditedShoot checks the edited shoot against the current one and its cloud profile
+func validateEditedShoot(shoot *gardencorev1beta1.Shoot, editable *editableShoot, cloudProfile *gardencorev1beta1.CloudProfile, now time.Time) []string {
+	var problems []string
+	if editable.Metadata.Name != shoot.Name || editable.Metadata.Namespace != shoot.Namespace {
+		problems = append(problems, "metadata.name and metadata.namespace cannot be changed")
+	}
+	spec := &editable.Spec
+	if spec.CloudProfileName != shoot.Spec.CloudProfileName {
+		problems = append(problems, "spec.cloudProfileName cannot be changed")
+	}
+	if spec.Region != shoot.Spec.Region {
+		problems = append(problems, "spec.region cannot be changed")
+	}
+	if spec.Provider.Type != shoot.Spec.Provider.Type {
+		problems = append(problems, "spec.provider.type cannot be changed")
+	}
+
+	if spec.Kubernetes.Version != shoot.Spec.Kubernetes.Version {
+		versions, err := getKubernetesVersions(shoot, cloudProfile, now)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if _, err := selectKubernetesVersion(versions, kubernetesUpgradeFlags{to: spec.Kubernetes.Version}); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	current := map[string]*gardencorev1beta1.Worker{}
+	for i := range shoot.Spec.Provider.Workers {
+		current[shoot.Spec.Provider.Workers[i].Name] = &shoot.Spec.Provider.Workers[i]
+	}
+	seen := map[string]bool{}
+	for _, worker := range spec.Provider.Workers {
+		if worker.Name == "" {
+			problems = append(problems, "worker pools must have a name")
+			continue
+		}
+		if seen[worker.Name] {
+			problems = append(problems, fmt.Sprintf("worker pool %s is defined more than once", worker.Name))
+		}
+		seen[worker.Name] = true
+		if err := validateWorkerScaling(worker.Minimum, worker.Maximum, len(worker.Zones)); err != nil {
+			problems = append(problems, fmt.Sprintf("worker pool %s: %v", worker.Name, err))
+		}
+
+		old := current[worker.Name]
+		if old == nil || old.Machine.Type != worker.Machine.Type || !apiequality.Semantic.DeepEqual(old.Zones, worker.Zones) {
+			if err := validateMachineType(cloudProfile, spec.Region, worker.Zones, worker.Machine.Type); err != nil {
+				problems = append(problems, fmt.Sprintf("worker pool %s: %v", worker.Name, err))
+			}
+		}
+		if image := worker.Machine.Image; image != nil && image.Version != nil && (old == nil || !apiequality.Semantic.DeepEqual(old.Machine.Image, image)) {
+			if err := validateMachineImageVersion(cloudProfile, image.Name, *image.Version, now); err != nil {
+				problems = append(problems, fmt.Sprintf("worker pool %s: %v", worker.Name, err))
+			}
+		}
+	}
+	return problems
+}
+
+// rollingUpdateChanges returns the changes of the shoot which cause a rolling update of nodes
+func rollingUpdateChanges(shoot, updated *gardencorev1beta1.Shoot) []string {
+	var changes []string
+	if !apiequality.Semantic.DeepEqual(shoot.Spec.Kubernetes.Kubelet, updated.Spec.Kubernetes.Kubelet) {
+		changes = append(changes, "kubelet configuration of all worker pools")
+	}
+	for _, old := range shoot.Spec.Provider.Workers {
+		for _, worker := range updated.Spec.Provider.Workers {
+			if worker.Name != old.Name {
+				continue
+			}
+			if !apiequality.Semantic.DeepEqual(old.Machine.Image, worker.Machine.Image) {
+				changes = append(changes, fmt.Sprintf("machine image of worker pool %s", worker.Name))
+			}
+			if old.Machine.Type != worker.Machine.Type {
+				changes = append(changes, fmt.Sprintf("machine type of worker pool %s", worker.Name))
+			}
+			if !apiequality.Semantic.DeepEqual(workerKubelet(&old), workerKubelet(&worker)) {
+				changes = append(changes, fmt.Sprintf("kubelet configuration of worker pool %s", worker.Name))
+			}
+		}
+	}
+	return changes
+}
+
+// workerKubelet returns the kubelet configuration of the worker pool, if any
+func workerKubelet(worker *gardencorev1beta1.Worker) *gardencorev1beta1.KubeletConfig {
+	if worker.Kubernetes == nil {
+		return nil
+	}
+	return worker.Kubernetes.Kubelet
+}
+
+// formatEditNotes formats the notes as comments for the edited file
+func formatEditNotes(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("#\n")
+	for i, note := range notes {
+		if i == 0 {
+			fmt.Fprintf(&builder, "# %s\n", note)
+		} else {
+			fmt.Fprintf(&builder, "#   - %s\n", strings.Replace(note, "\n", "\n#     ", -1))
+		}
+	}
+	builder.WriteString("#\n")
+	return builder.String()
+}
+
+// stripEditComments removes the lines starting with a '#'
+func stripEditComments(content string) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// renderLineDiff prints the changed lines with some context in the unified diff format, coloured if requested
+func renderLineDiff(a, b string, colored bool, writer io.Writer) {
+	for _, line := range unifiedLineDiff(strings.Split(strings.TrimSuffix(a, "\n"), "\n"), strings.Split(strings.TrimSuffix(b, "\n"), "\n"), diffContextLines) {
+		switch {
+		case !colored:
+			fmt.Fprintln(writer, line)
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintf(writer, "\033[31m%s\033[0m\n", line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintf(writer, "\033[32m%s\033[0m\n", line)
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprintf(writer, "\033[36m%s\033[0m\n", line)
+		default:
+			fmt.Fprintln(writer, line)
+		}
+	}
+}
+
+// unifiedLineDiff returns the hunks of a unified diff from a to b based on their longest common subsequence
+func unifiedLineDiff(a, b []string, context int) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type diffOp struct {
+		kind   byte
+		line   string
+		ai, bi int
+	}
+	var ops []diffOp
+	var changed []int
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			changed = append(changed, len(ops))
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			changed = append(changed, len(ops))
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var lines []string
+	for k := 0; k < len(changed); {
+		// extend the hunk as long as the next change is within the context of the previous one
+		end := k
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*context {
+			end++
+		}
+		from, to := changed[k]-context, changed[end]+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		var aCount, bCount int
+		var hunk []string
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+			hunk = append(hunk, string(op.kind)+op.line)
+		}
+		lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", ops[from].ai+1, aCount, ops[from].bi+1, bCount))
+		lines = append(lines, hunk...)
+		k = end + 1
+	}
+	return lines
+}
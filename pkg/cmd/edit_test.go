@@ -0,0 +1,222 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Edit shoot command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		editor       *mockcmd.MockEditor
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+		edited       []string
+	)
+
+	projectNamespace := "garden-prod"
+	imageVersion := "27.1.0"
+
+	// replaceInFile returns an editor action which replaces the old with the new text
+	replaceInFile := func(old, new string) func(path string) error {
+		return func(path string) error {
+			data, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			edited = append(edited, string(data))
+			return ioutil.WriteFile(path, []byte(strings.Replace(string(data), old, new, 1)), 0600)
+		}
+	}
+	execute := func(input string, args ...string) (string, string, error) {
+		ioStreams, in, out, errOut := cmd.NewTestIOStreams()
+		in.WriteString(input)
+		command := cmd.NewEditCmd(targetReader, configReader, editor, ioStreams)
+		command.SetArgs(append([]string{"shoot"}, args...))
+		err := command.Execute()
+		return out.String(), errOut.String(), err
+	}
+	getShoot := func() *gardencorev1beta1.Shoot {
+		shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return shoot
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		editor = mockcmd.NewMockEditor(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		edited = nil
+
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			&gardencorev1beta1.Shoot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-shoot",
+					Namespace:       projectNamespace,
+					ResourceVersion: "42",
+					Annotations:     map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}", "team": "a"},
+				},
+				Spec: gardencorev1beta1.ShootSpec{
+					CloudProfileName: "aws",
+					Region:           "eu-west-1",
+					Kubernetes:       gardencorev1beta1.Kubernetes{Version: "1.17.9"},
+					Provider: gardencorev1beta1.Provider{
+						Type: "aws",
+						Workers: []gardencorev1beta1.Worker{{
+							Name:    "cpu",
+							Minimum: 1,
+							Maximum: 3,
+							Machine: gardencorev1beta1.Machine{Type: "m5.large", Image: &gardencorev1beta1.ShootMachineImage{Name: "gardenlinux", Version: &imageVersion}},
+						}},
+					},
+				},
+				Status: gardencorev1beta1.ShootStatus{TechnicalID: "shoot--prod--test-shoot"},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws"},
+				Spec: gardencorev1beta1.CloudProfileSpec{
+					Kubernetes: gardencorev1beta1.KubernetesSettings{
+						Versions: []gardencorev1beta1.ExpirableVersion{{Version: "1.17.9"}, {Version: "1.18.2"}},
+					},
+					MachineImages: []gardencorev1beta1.MachineImage{
+						{Name: "gardenlinux", Versions: []gardencorev1beta1.ExpirableVersion{{Version: "27.1.0"}}},
+					},
+					MachineTypes: []gardencorev1beta1.MachineType{
+						{Name: "m5.large", CPU: resource.MustParse("2"), Memory: resource.MustParse("8Gi")},
+						{Name: "m5.xlarge", CPU: resource.MustParse("4"), Memory: resource.MustParse("16Gi")},
+					},
+					Regions: []gardencorev1beta1.Region{{Name: "eu-west-1"}},
+				},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should only show the editable fields", func() {
+		editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("", ""))
+
+		out, _, err := execute("")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Edit cancelled, no changes made\n"))
+		Expect(edited[0]).To(HavePrefix("# Please edit the shoot below."))
+		Expect(edited[0]).To(ContainSubstring("  annotations:\n    team: a\n  name: test-shoot\n  namespace: garden-prod\nspec:\n"))
+		Expect(edited[0]).NotTo(ContainSubstring("status:"))
+		Expect(edited[0]).NotTo(ContainSubstring("resourceVersion"))
+		Expect(edited[0]).NotTo(ContainSubstring("last-applied-configuration"))
+	})
+
+	It("should show the diff, warn about rolling updates and apply the changes", func() {
+		editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("type: m5.large", "type: m5.xlarge"))
+
+		out, errOut, err := execute("yes\n")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("Shoot garden-prod/test-shoot:\n@@ "))
+		Expect(out).To(ContainSubstring("\n-        type: m5.large\n+        type: m5.xlarge\n"))
+		Expect(out).To(HaveSuffix("Do you want to apply the changes? Type 'yes' to confirm: Shoot garden-prod/test-shoot edited\n"))
+		Expect(errOut).To(ContainSubstring("machine type of worker pool cpu"))
+		Expect(errOut).NotTo(ContainSubstring("\x1b["))
+		shoot := getShoot()
+		Expect(shoot.Spec.Provider.Workers[0].Machine.Type).To(Equal("m5.xlarge"))
+		Expect(shoot.Annotations).To(HaveKeyWithValue("kubectl.kubernetes.io/last-applied-configuration", "{}"))
+		Expect(shoot.Status.TechnicalID).To(Equal("shoot--prod--test-shoot"))
+	})
+
+	It("should reopen the editor with the problems of an invalid shoot", func() {
+		gomock.InOrder(
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("version: 1.17.9", "version: 1.19.1")),
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("version: 1.19.1", "version: 1.18.2")),
+		)
+
+		out, _, err := execute("", "--yes")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(edited[1]).To(ContainSubstring("# The edited shoot is invalid, save it unchanged to abort:\n#   - version 1.19.1 is not offered by cloud profile aws\n"))
+		Expect(out).To(HaveSuffix("Shoot garden-prod/test-shoot edited\n"))
+		Expect(getShoot().Spec.Kubernetes.Version).To(Equal("1.18.2"))
+	})
+
+	It("should abort if an invalid shoot is saved unchanged", func() {
+		gomock.InOrder(
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("maximum: 3", "maximum: 0")),
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("", "")),
+		)
+
+		_, _, err := execute("", "--yes")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("shoot garden-prod/test-shoot is invalid:\n  - worker pool cpu: maximum 0 must not be less than minimum 1"))
+		Expect(getShoot().Spec.Provider.Workers[0].Maximum).To(Equal(int32(3)))
+	})
+
+	It("should offer to edit the changes again on a conflict", func() {
+		conflicts := 1
+		clientSet.PrependReactor("update", "shoots", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicts == 0 {
+				return false, nil, nil
+			}
+			conflicts--
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "core.gardener.cloud", Resource: "shoots"}, "test-shoot", nil)
+		})
+		gomock.InOrder(
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("maximum: 3", "maximum: 5")),
+			editor.EXPECT().Edit(gomock.Any()).DoAndReturn(replaceInFile("", "")),
+		)
+
+		out, errOut, err := execute("yes\nyes\nyes\n")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errOut).To(ContainSubstring("Shoot garden-prod/test-shoot has been modified in the meantime\n"))
+		Expect(edited[1]).To(ContainSubstring("      maximum: 5\n"))
+		Expect(out).To(HaveSuffix("Shoot garden-prod/test-shoot edited\n"))
+		Expect(getShoot().Spec.Provider.Workers[0].Maximum).To(Equal(int32(5)))
+	})
+})
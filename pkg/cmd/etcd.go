@@ -0,0 +1,480 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// etcdMain is the etcd which stores the resources of a shoot
+	etcdMain = "etcd-main"
+	// etcdEvents is the etcd which stores the events of a shoot
+	etcdEvents = "etcd-events"
+	// etcdClientPort is the port etcd serves its clients on
+	etcdClientPort = 2379
+	// etcdClientSecret is the secret in the control plane namespace with the client certificate of etcd
+	etcdClientSecret = "etcd-client-tls"
+	// etcdBackupRestoreContainer is the name of the backup-restore sidecar container of the etcd pods
+	etcdBackupRestoreContainer = "backup-restore"
+	// etcdBackupRestorePort is the port the backup-restore sidecar serves its HTTP API on
+	etcdBackupRestorePort = 8080
+)
+
+// sections of the etcd status which can be unavailable
+const (
+	etcdSectionPod       = "pod"
+	etcdSectionMember    = "member"
+	etcdSectionSnapshots = "snapshots"
+)
+
+// etcdSnapshotPollInterval is the interval in which the latest snapshots are read while waiting for a triggered snapshot
+var etcdSnapshotPollInterval = 2 * time.Second
+
+// backupRestoreSnapshot is a snapshot as returned by the HTTP API of the backup-restore sidecar
+type backupRestoreSnapshot struct {
+	Kind          string    `json:"kind"`
+	StartRevision int64     `json:"startRevision"`
+	LastRevision  int64     `json:"lastRevision"`
+	CreatedOn     time.Time `json:"createdOn"`
+	SnapName      string    `json:"snapName"`
+}
+
+// backupRestoreLatestSnapshots are the latest snapshots as returned by the HTTP API of the backup-restore sidecar
+type backupRestoreLatestSnapshots struct {
+	FullSnapshot   *backupRestoreSnapshot   `json:"fullSnapshot"`
+	DeltaSnapshots []*backupRestoreSnapshot `json:"deltaSnapshots"`
+}
+
+// backupRestoreClient calls the HTTP API of the backup-restore sidecar of an etcd
+type backupRestoreClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewEtcdCmd returns a new etcd command.
+func NewEtcdCmd(targetReader TargetReader, portForwarder PortForwarder, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcd",
+		Short: "Inspect the etcds of the targeted shoot, e.g. \"gardenctl etcd status\"",
+	}
+	cmd.AddCommand(newEtcdStatusCmd(targetReader, portForwarder, ioStreams), newEtcdSnapshotCmd(targetReader, portForwarder, ioStreams))
+
+	return cmd
+}
+
+func newEtcdStatusCmd(targetReader TargetReader, portForwarder PortForwarder, ioStreams IOStreams) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the health, member status, database size and latest snapshots of etcd-main and etcd-events",
+		Long: `Show the health, member status, database size and latest snapshots of etcd-main and etcd-events of the targeted
+shoot. The member status and the database size are read from the metrics of etcd, the latest snapshots from the
+backup-restore sidecar. Both are reached through port forwarding to the etcd pods on the seed.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: etcd status")
+			}
+			shoot, seedClient, err := targetedControlPlane(targetReader)
+			if err != nil {
+				return err
+			}
+
+			var statuses []EtcdStatus
+			for _, name := range []string{etcdMain, etcdEvents} {
+				statuses = append(statuses, getEtcdStatus(seedClient, portForwarder, shoot.Status.TechnicalID, name, timeout))
+			}
+			if outFormat := outputFormatOrTable(cmd); outFormat != tableOutputFormat {
+				return PrintoutObject(statuses, ioStreams.Out, outFormat)
+			}
+			printEtcdStatuses(statuses, time.Now(), ioStreams.Out)
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "maximum time to wait for etcd and the backup-restore sidecar to respond")
+
+	return cmd
+}
+
+func newEtcdSnapshotCmd(targetReader TargetReader, portForwarder PortForwarder, ioStreams IOStreams) *cobra.Command {
+	var (
+		full    bool
+		events  bool
+		timeout time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Take snapshots of the etcds of the targeted shoot, e.g. \"gardenctl etcd snapshot trigger --full\"",
+	}
+	triggerCmd := &cobra.Command{
+		Use:   "trigger",
+		Short: "Take an out-of-schedule snapshot of etcd-main or etcd-events and wait until it has been stored",
+		Long: fmt.Sprintf(`Take an out-of-schedule snapshot of etcd-main, or etcd-events with --events, of the targeted shoot and wait until
+it is listed as the latest snapshot by the backup-restore sidecar. A delta snapshot is taken unless --full is given.
+
+Exit codes:
+  0  the snapshot has been taken
+  1  the snapshot could not be triggered
+  %d  the snapshot has not been listed within --timeout`, exitCodeTimeout),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: etcd snapshot trigger [--full] [--events]")
+			}
+			shoot, seedClient, err := targetedControlPlane(targetReader)
+			if err != nil {
+				return err
+			}
+			name, kind := etcdMain, "delta"
+			if events {
+				name = etcdEvents
+			}
+			if full {
+				kind = "full"
+			}
+
+			pod, err := seedClient.CoreV1().Pods(shoot.Status.TechnicalID).Get(name+"-0", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if !hasContainer(pod.Spec.Containers, etcdBackupRestoreContainer) {
+				return fmt.Errorf("%s has no %s sidecar, backups are not configured", name, etcdBackupRestoreContainer)
+			}
+			port, stop, err := portForwarder.ForwardPort(TargetKindSeed, pod.Namespace, pod.Name, etcdBackupRestorePort)
+			if err != nil {
+				return err
+			}
+			defer stop()
+			client := &backupRestoreClient{baseURL: fmt.Sprintf("http://127.0.0.1:%d", port), client: &http.Client{Timeout: timeout}}
+
+			fmt.Fprintf(ioStreams.ErrOut, "Triggering %s snapshot of %s...\n", kind, name)
+			snapshot, err := waitForEtcdSnapshot(client, kind, time.Now(), timeout)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(ioStreams.Out, "%s snapshot %s of %s taken at %s, revisions %d to %d\n", strings.ToUpper(kind[:1])+kind[1:], snapshot.Name, name, snapshot.CreatedOn, snapshot.StartRevision, snapshot.LastRevision)
+			return nil
+		},
+	}
+	triggerCmd.Flags().BoolVar(&full, "full", false, "take a full snapshot instead of a delta snapshot")
+	triggerCmd.Flags().BoolVar(&events, "events", false, "take the snapshot of etcd-events instead of etcd-main")
+	triggerCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "maximum time to wait for the snapshot")
+	cmd.AddCommand(triggerCmd)
+
+	return cmd
+}
+
+// targetedControlPlane returns the targeted shoot and a client of its seed
+func targetedControlPlane(targetReader TargetReader) (*gardencorev1beta1.Shoot, kubernetes.Interface, error) {
+	target := targetReader.ReadTarget(pathTarget)
+	if !CheckShootIsTargeted(target) {
+		return nil, nil, errors.New("no shoot targeted")
+	}
+	shoot, err := FetchShootFromTarget(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if shoot == nil {
+		return nil, nil, fmt.Errorf("no shoot found with name %q", target.Stack()[2].Name)
+	}
+	if shoot.Status.TechnicalID == "" {
+		return nil, nil, fmt.Errorf("shoot %s has no control plane namespace", shootKey(shoot))
+	}
+	seedClient, err := target.K8SClientToKind(TargetKindSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shoot, seedClient, nil
+}
+
+// getEtcdStatus returns the status of the etcd in the control plane namespace, parts which cannot be read are recorded
+// as unavailable
+func getEtcdStatus(seedClient kubernetes.Interface, portForwarder PortForwarder, namespace, name string, timeout time.Duration) EtcdStatus {
+	status := EtcdStatus{Name: name}
+	unavailable := func(section string, err error) {
+		status.Unavailable = append(status.Unavailable, DiagUnavailable{Section: section, Reason: err.Error()})
+	}
+
+	pod, err := seedClient.CoreV1().Pods(namespace).Get(name+"-0", metav1.GetOptions{})
+	if err != nil {
+		unavailable(etcdSectionPod, err)
+		unavailable(etcdSectionMember, errors.New("pod is unavailable"))
+		unavailable(etcdSectionSnapshots, errors.New("pod is unavailable"))
+		return status
+	}
+	diagPod := toDiagPod(pod)
+	status.Pod = &diagPod
+
+	if err := readEtcdMetrics(seedClient, portForwarder, namespace, pod.Name, name, timeout, &status); err != nil {
+		unavailable(etcdSectionMember, err)
+	}
+
+	if !hasContainer(pod.Spec.Containers, etcdBackupRestoreContainer) {
+		unavailable(etcdSectionSnapshots, fmt.Errorf("no %s sidecar, backups are not configured", etcdBackupRestoreContainer))
+		return status
+	}
+	port, stop, err := portForwarder.ForwardPort(TargetKindSeed, namespace, pod.Name, etcdBackupRestorePort)
+	if err != nil {
+		unavailable(etcdSectionSnapshots, err)
+		return status
+	}
+	defer stop()
+	client := &backupRestoreClient{baseURL: fmt.Sprintf("http://127.0.0.1:%d", port), client: &http.Client{Timeout: timeout}}
+	latest, err := client.latest()
+	if err != nil {
+		unavailable(etcdSectionSnapshots, err)
+		return status
+	}
+	status.FullSnapshot = toEtcdSnapshot(latest.FullSnapshot)
+	status.DeltaSnapshot = toEtcdSnapshot(latestDeltaSnapshot(latest))
+	return status
+}
+
+// readEtcdMetrics reads the member status and the database size from the metrics of etcd with its client certificate
+func readEtcdMetrics(seedClient kubernetes.Interface, portForwarder PortForwarder, namespace, pod, name string, timeout time.Duration, status *EtcdStatus) error {
+	secret, err := seedClient.CoreV1().Secrets(namespace).Get(etcdClientSecret, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.X509KeyPair(secret.Data["tls.crt"], secret.Data["tls.key"])
+	if err != nil {
+		return fmt.Errorf("invalid client certificate in secret %s: %v", etcdClientSecret, err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(secret.Data["ca.crt"]) {
+		return fmt.Errorf("no CA certificate in secret %s", etcdClientSecret)
+	}
+
+	port, stop, err := portForwarder.ForwardPort(TargetKindSeed, namespace, pod, etcdClientPort)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      rootCAs,
+			// the serving certificate of etcd is issued for its service names, not for the forwarded local port
+			ServerName: name + "-local",
+		}},
+	}
+	response, err := client.Get(fmt.Sprintf("https://127.0.0.1:%d/metrics", port))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("/metrics returned %s", response.Status)
+	}
+	metrics, err := parseEtcdMetrics(response.Body)
+	if err != nil {
+		return err
+	}
+
+	hasLeader, ok := metrics["etcd_server_has_leader"]
+	if !ok {
+		return errors.New("etcd does not report etcd_server_has_leader")
+	}
+	switch {
+	case hasLeader == 0:
+		status.Member = "no leader"
+	case metrics["etcd_server_is_leader"] == 1:
+		status.Member = "leader"
+	default:
+		status.Member = "follower"
+	}
+	if size, ok := metrics["etcd_mvcc_db_total_size_in_bytes"]; ok {
+		status.DBSizeBytes = int64(size)
+	} else if size, ok := metrics["etcd_debugging_mvcc_db_total_size_in_bytes"]; ok {
+		status.DBSizeBytes = int64(size)
+	}
+	return nil
+}
+
+// parseEtcdMetrics returns the values of the metrics without labels in the Prometheus text format
+func parseEtcdMetrics(reader io.Reader) (map[string]float64, error) {
+	metrics := map[string]float64{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") || strings.Contains(fields[0], "{") {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		metrics[fields[0]] = value
+	}
+	return metrics, scanner.Err()
+}
+
+// get calls the path of the backup-restore API and decodes the JSON response into the value
+func (c *backupRestoreClient) get(path string, value interface{}) error {
+	response, err := c.client.Get(c.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", path, response.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, value)
+}
+
+// latest returns the latest full and delta snapshots
+func (c *backupRestoreClient) latest() (*backupRestoreLatestSnapshots, error) {
+	latest := &backupRestoreLatestSnapshots{}
+	if err := c.get("/snapshot/latest", latest); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// waitForEtcdSnapshot triggers a snapshot of the kind, full or delta, and waits until it is listed as the latest one
+func waitForEtcdSnapshot(client *backupRestoreClient, kind string, triggeredAt time.Time, timeout time.Duration) (*EtcdSnapshot, error) {
+	deadline := triggeredAt.Add(timeout)
+	triggered := &backupRestoreSnapshot{}
+	if err := client.get("/snapshot/"+kind, triggered); err != nil {
+		return nil, fmt.Errorf("cannot trigger %s snapshot: %v", kind, err)
+	}
+	// older sidecars do not return the snapshot, then any snapshot taken after the trigger is awaited
+	isTriggered := func(snapshot *backupRestoreSnapshot) bool {
+		if snapshot == nil {
+			return false
+		}
+		if triggered.SnapName != "" {
+			return snapshot.SnapName == triggered.SnapName
+		}
+		return !snapshot.CreatedOn.Before(triggeredAt.Truncate(time.Second))
+	}
+
+	for {
+		latest, err := client.latest()
+		if err != nil {
+			return nil, err
+		}
+		candidates := latest.DeltaSnapshots
+		if kind == "full" {
+			candidates = []*backupRestoreSnapshot{latest.FullSnapshot}
+		}
+		for _, snapshot := range candidates {
+			if isTriggered(snapshot) {
+				return toEtcdSnapshot(snapshot), nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, &exitError{code: exitCodeTimeout, err: fmt.Errorf("the %s snapshot has not been listed within %s", kind, timeout)}
+		}
+		wait := etcdSnapshotPollInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		time.Sleep(wait)
+	}
+}
+
+// latestDeltaSnapshot returns the most recent delta snapshot, or nil if there is none
+func latestDeltaSnapshot(latest *backupRestoreLatestSnapshots) *backupRestoreSnapshot {
+	var newest *backupRestoreSnapshot
+	for _, snapshot := range latest.DeltaSnapshots {
+		if snapshot != nil && (newest == nil || snapshot.LastRevision > newest.LastRevision) {
+			newest = snapshot
+		}
+	}
+	return newest
+}
+
+// toEtcdSnapshot returns the snapshot of the backup-restore API as shown by gardenctl
+func toEtcdSnapshot(snapshot *backupRestoreSnapshot) *EtcdSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	return &EtcdSnapshot{
+		Name:          snapshot.SnapName,
+		Kind:          snapshot.Kind,
+		StartRevision: snapshot.StartRevision,
+		LastRevision:  snapshot.LastRevision,
+		CreatedOn:     snapshot.CreatedOn.UTC().Format(time.RFC3339),
+	}
+}
+
+// hasContainer returns whether a container with the name is in the list
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printEtcdStatuses prints the statuses of the etcds as a table followed by the parts which are unavailable
+func printEtcdStatuses(statuses []EtcdStatus, now time.Time, writer io.Writer) {
+	formatSnapshot := func(snapshot *EtcdSnapshot) string {
+		if snapshot == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s (%s ago)", snapshot.CreatedOn, formatDiagAge(snapshot.CreatedOn, now))
+	}
+
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ETCD\tPHASE\tREADY\tRESTARTS\tMEMBER\tDB SIZE\tLATEST FULL SNAPSHOT\tLATEST DELTA SNAPSHOT")
+	for _, status := range statuses {
+		phase, ready, restarts := "-", "-", "-"
+		if status.Pod != nil {
+			phase = status.Pod.Phase
+			ready = fmt.Sprintf("%d/%d", status.Pod.ReadyContainers, status.Pod.Containers)
+			restarts = fmt.Sprint(status.Pod.Restarts)
+		}
+		dbSize := "-"
+		if status.DBSizeBytes > 0 {
+			dbSize = resource.NewQuantity(status.DBSizeBytes, resource.BinarySI).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", status.Name, phase, ready, restarts, valueOrDash(status.Member), dbSize, formatSnapshot(status.FullSnapshot), formatSnapshot(status.DeltaSnapshot))
+	}
+	w.Flush()
+
+	for _, status := range statuses {
+		for _, missing := range status.Unavailable {
+			fmt.Fprintf(writer, "%s %s unavailable: %s\n", status.Name, missing.Section, missing.Reason)
+		}
+	}
+}
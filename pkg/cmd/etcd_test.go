@@ -0,0 +1,282 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// etcdTestCertificate issues a certificate for the key, signed by the parent or self-signed if parent is nil
+func etcdTestCertificate(template *x509.Certificate, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, []byte, *x509.Certificate) {
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), certificate
+}
+
+var _ = Describe("Etcd command", func() {
+	var (
+		ctrl          *gomock.Controller
+		targetReader  *mockcmd.MockTargetReader
+		target        *mockcmd.MockTargetInterface
+		portForwarder *mockcmd.MockPortForwarder
+		seedClient    *kubernetesfake.Clientset
+		etcdServer    *httptest.Server
+		sidecars      map[string]*httptest.Server
+		mutex         sync.Mutex
+		fullSnapshot  map[string]interface{}
+		forwarded     []string
+		stack         []cmd.TargetMeta
+	)
+
+	controlPlaneNamespace := "shoot--prod--test-shoot"
+	projectNamespace := "garden-prod"
+	createdOn := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		return key
+	}
+	etcdPod := func(name string, containers ...string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-0", Namespace: controlPlaneNamespace},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: container, Ready: true, RestartCount: 1})
+		}
+		return pod
+	}
+	serverPort := func(server *httptest.Server) int {
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		port, err := strconv.Atoi(serverURL.Port())
+		Expect(err).NotTo(HaveOccurred())
+		return port
+	}
+	execute := func(args ...string) (string, string, error) {
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+		command := cmd.NewEtcdCmd(targetReader, portForwarder, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), errOut.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		portForwarder = mockcmd.NewMockPortForwarder(ctrl)
+		forwarded = nil
+		fullSnapshot = map[string]interface{}{
+			"kind": "Full", "startRevision": 0, "lastRevision": 40, "createdOn": createdOn, "snapName": "Full-00000000-00000040-1",
+		}
+
+		caKey, serverKey, clientKey := newKey(), newKey(), newKey()
+		caPEM, _, ca := etcdTestCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "etcd-ca"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, caKey, nil, nil)
+		serverPEM, serverKeyPEM, _ := etcdTestCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "etcd-server"}, DNSNames: []string{"etcd-main-local", "etcd-events-local"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, serverKey, ca, caKey)
+		clientPEM, clientKeyPEM, _ := etcdTestCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "etcd-client"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, clientKey, ca, caKey)
+		serverCertificate, err := tls.X509KeyPair(serverPEM, serverKeyPEM)
+		Expect(err).NotTo(HaveOccurred())
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(ca)
+
+		etcdServer = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "# TYPE etcd_server_has_leader gauge")
+			fmt.Fprintln(w, "etcd_server_has_leader 1")
+			fmt.Fprintln(w, "etcd_server_is_leader 1")
+			fmt.Fprintln(w, `etcd_server_id{server_id="8e9e05c52164694d"} 1`)
+			fmt.Fprintln(w, "etcd_mvcc_db_total_size_in_bytes 4.194304e+06")
+		}))
+		etcdServer.TLS = &tls.Config{Certificates: []tls.Certificate{serverCertificate}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+		etcdServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		etcdServer.StartTLS()
+
+		sidecars = map[string]*httptest.Server{
+			"etcd-main-0": httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				switch r.URL.Path {
+				case "/snapshot/latest":
+					Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+						"fullSnapshot": fullSnapshot,
+						"deltaSnapshots": []map[string]interface{}{
+							{"kind": "Incr", "startRevision": 41, "lastRevision": 45, "createdOn": createdOn.Add(time.Hour), "snapName": "Incr-00000041-00000045-2"},
+							{"kind": "Incr", "startRevision": 46, "lastRevision": 50, "createdOn": createdOn.Add(90 * time.Minute), "snapName": "Incr-00000046-00000050-3"},
+						},
+					})).To(Succeed())
+				case "/snapshot/full":
+					fullSnapshot = map[string]interface{}{
+						"kind": "Full", "startRevision": 0, "lastRevision": 60, "createdOn": time.Now().UTC(), "snapName": "Full-00000000-00000060-4",
+					}
+					Expect(json.NewEncoder(w).Encode(fullSnapshot)).To(Succeed())
+				case "/snapshot/delta":
+					Expect(json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Incr", "snapName": "Incr-00000061-00000062-5"})).To(Succeed())
+				default:
+					http.NotFound(w, r)
+				}
+			})),
+			"etcd-events-0": httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "no snapshot found", http.StatusInternalServerError)
+			})),
+		}
+
+		seedClient = kubernetesfake.NewSimpleClientset(
+			etcdPod("etcd-main", "etcd", "backup-restore"),
+			etcdPod("etcd-events", "etcd", "backup-restore"),
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "etcd-client-tls", Namespace: controlPlaneNamespace},
+				Data:       map[string][]byte{"ca.crt": caPEM, "tls.crt": clientPEM, "tls.key": clientKeyPEM},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) {
+			return gardencorefake.NewSimpleClientset(
+				&gardencorev1beta1.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+					Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+				},
+				&gardencorev1beta1.Shoot{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+					Status:     gardencorev1beta1.ShootStatus{TechnicalID: controlPlaneNamespace},
+				},
+			), nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).Return(seedClient, nil).AnyTimes()
+		portForwarder.EXPECT().ForwardPort(cmd.TargetKindSeed, controlPlaneNamespace, gomock.Any(), gomock.Any()).DoAndReturn(func(kind cmd.TargetKind, namespace, pod string, port int) (int, func(), error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			forwarded = append(forwarded, fmt.Sprintf("%s:%d", pod, port))
+			if port == 2379 {
+				return serverPort(etcdServer), func() {}, nil
+			}
+			return serverPort(sidecars[pod]), func() {}, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		etcdServer.Close()
+		for _, sidecar := range sidecars {
+			sidecar.Close()
+		}
+		ctrl.Finish()
+	})
+
+	It("should show the health, member status, database size and latest snapshots of the etcds", func() {
+		out, _, err := execute("status")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchRegexp(`ETCD +PHASE +READY +RESTARTS +MEMBER +DB SIZE +LATEST FULL SNAPSHOT +LATEST DELTA SNAPSHOT\n`))
+		Expect(out).To(MatchRegexp(`etcd-main +Running +2/2 +2 +leader +4Mi +%s \(1[12]\d+m ago\) +%s \(30m ago\)\n`, createdOn.Format(time.RFC3339), createdOn.Add(90*time.Minute).Format(time.RFC3339)))
+		Expect(out).To(MatchRegexp(`etcd-events +Running +2/2 +2 +leader +4Mi +- +-\n`))
+		Expect(out).To(HaveSuffix("etcd-events snapshots unavailable: /snapshot/latest returned 500 Internal Server Error: no snapshot found\n"))
+		Expect(forwarded).To(ConsistOf("etcd-main-0:2379", "etcd-main-0:8080", "etcd-events-0:2379", "etcd-events-0:8080"))
+	})
+
+	It("should return an error if the shoot targeted via its seed does not exist anymore", func() {
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindSeed, Name: "test-seed"},
+			{Kind: cmd.TargetKindShoot, Name: "gone"},
+		}
+
+		_, _, err := execute("status")
+
+		Expect(err).To(MatchError(`no shoot found with name "gone"`))
+	})
+
+	It("should record the member status as unavailable without the client certificate", func() {
+		Expect(seedClient.CoreV1().Secrets(controlPlaneNamespace).Delete("etcd-client-tls", nil)).To(Succeed())
+
+		out, _, err := execute("status")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchRegexp(`etcd-main +Running +2/2 +2 +- +- +\S+`))
+		Expect(out).To(ContainSubstring(`etcd-main member unavailable: secrets "etcd-client-tls" not found`))
+	})
+
+	It("should trigger a full snapshot and wait until it is the latest one", func() {
+		out, errOut, err := execute("snapshot", "trigger", "--full")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errOut).To(Equal("Triggering full snapshot of etcd-main...\n"))
+		Expect(out).To(MatchRegexp(`^Full snapshot Full-00000000-00000060-4 of etcd-main taken at \S+, revisions 0 to 60\n$`))
+	})
+
+	It("should time out if the triggered snapshot is not listed", func() {
+		_, _, err := execute("snapshot", "trigger", "--timeout", "100ms")
+
+		Expect(err).To(MatchError("the delta snapshot has not been listed within 100ms"))
+		Expect(cmd.ExitCode(err)).To(Equal(4))
+	})
+
+	It("should not trigger snapshots of etcds without backup-restore sidecar", func() {
+		Expect(seedClient.CoreV1().Pods(controlPlaneNamespace).Update(etcdPod("etcd-events", "etcd"))).NotTo(BeNil())
+
+		_, _, err := execute("snapshot", "trigger", "--events")
+
+		Expect(err).To(MatchError("etcd-events has no backup-restore sidecar, backups are not configured"))
+	})
+})
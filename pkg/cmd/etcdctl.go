@@ -0,0 +1,263 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	osuser "os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	// etcdContainer is the name of the etcd container of the etcd pods
+	etcdContainer = "etcd"
+	// etcdCertificateDir is the directory the certificates are mounted to in the etcd container
+	etcdCertificateDir = "/var/etcd/ssl"
+)
+
+// etcdctlReadOnlyCommands are the etcdctl commands which do not modify etcd, with their read-only subcommands or nil if
+// all of their subcommands are read-only
+var etcdctlReadOnlyCommands = map[string][]string{
+	"get":      nil,
+	"watch":    nil,
+	"version":  nil,
+	"endpoint": {"health", "status", "hashkv"},
+	"member":   {"list"},
+	"alarm":    {"list"},
+	"lease":    {"list", "timetolive"},
+	"user":     {"list", "get"},
+	"role":     {"list", "get"},
+}
+
+// etcdctlValueFlags are the global etcdctl flags which take their value as separate argument
+var etcdctlValueFlags = map[string]bool{
+	"-w":                   true,
+	"--write-out":          true,
+	"--command-timeout":    true,
+	"--dial-timeout":       true,
+	"--keepalive-time":     true,
+	"--keepalive-timeout":  true,
+	"--user":               true,
+	"--password":           true,
+	"--discovery-srv":      true,
+	"--discovery-srv-name": true,
+}
+
+// etcdctlConnectionFlags are set by gardenctl to connect to the etcd of the shoot
+var etcdctlConnectionFlags = []string{"--endpoints", "--cacert", "--cert", "--key"}
+
+// NewEtcdctlCmd returns a new etcdctl command.
+func NewEtcdctlCmd(targetReader TargetReader, podExecutor PodExecutor, historyWriter HistoryWriter, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcdctl [--events] [--allow-write] <args>",
+		Short: "Run etcdctl against etcd-main or etcd-events of the targeted shoot, e.g. \"gardenctl etcdctl endpoint status -w table\"",
+		Long: `Run etcdctl in the etcd container of etcd-main, or etcd-events with --events, of the targeted shoot. The endpoint and the
+client certificates are set by gardenctl. The flags of gardenctl have to be given before the arguments of etcdctl.
+
+Only commands which do not modify etcd are allowed, e.g. get, watch, endpoint status or alarm list. Other commands
+require --allow-write. Every invocation is recorded in the audit trail in the gardenctl home directory.`,
+		SilenceUsage: true,
+		// the flags of etcdctl are passed through, the flags of gardenctl are parsed by etcdctlFlags
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			events, allowWrite, help, args := etcdctlFlags(args)
+			if help {
+				return cmd.Help()
+			}
+			if len(args) == 0 {
+				return errors.New("command must be in the format: etcdctl [--events] [--allow-write] <args>")
+			}
+			for _, arg := range args {
+				for _, flag := range etcdctlConnectionFlags {
+					if arg == flag || strings.HasPrefix(arg, flag+"=") {
+						return fmt.Errorf("flag %s is set by gardenctl and cannot be overridden", flag)
+					}
+				}
+			}
+			name := etcdMain
+			if events {
+				name = etcdEvents
+			}
+
+			// every invocation with valid arguments is recorded with its result, also if it fails before etcdctl runs
+			var (
+				shoot  *gardencorev1beta1.Shoot
+				result string
+			)
+			defer func() {
+				switch {
+				case result != "":
+				case err != nil:
+					result = "failed: " + err.Error()
+				default:
+					result = "succeeded"
+				}
+				key := "-"
+				if shoot != nil {
+					key = shootKey(shoot)
+				}
+				entry := map[string]string{
+					"time":       time.Now().UTC().Format(time.RFC3339),
+					"user":       currentUserName(),
+					"shoot":      key,
+					"etcd":       name,
+					"command":    "etcdctl " + strings.Join(redactEtcdctlArgs(args), " "),
+					"allowWrite": strconv.FormatBool(allowWrite),
+					"result":     result,
+				}
+				if err := historyWriter.WriteStringln(pathAudit, entry); err != nil {
+					fmt.Fprintf(ioStreams.ErrOut, "Warning: the invocation cannot be recorded in the audit trail: %v\n", err)
+				}
+			}()
+
+			shoot, seedClient, err := targetedControlPlane(targetReader)
+			if err != nil {
+				return err
+			}
+			if command, readOnly := etcdctlCommand(args); !readOnly && !allowWrite {
+				result = "blocked"
+				return fmt.Errorf("etcdctl %s may modify etcd, use --allow-write to run it", command)
+			}
+			pod, err := seedClient.CoreV1().Pods(shoot.Status.TechnicalID).Get(name+"-0", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if pod.Status.Phase != corev1.PodRunning {
+				return fmt.Errorf("%s is not running", pod.Name)
+			}
+
+			err = podExecutor.Exec(TargetKindSeed, pod.Namespace, pod.Name, etcdContainer, etcdctlShellCommand(name, args), ioStreams.Out, ioStreams.ErrOut)
+			var exitErr utilexec.ExitError
+			if errors.As(err, &exitErr) {
+				result = fmt.Sprintf("exit code %d", exitErr.ExitStatus())
+				return &exitError{code: exitErr.ExitStatus(), err: err}
+			}
+			return err
+		},
+	}
+	cmd.Flags().Bool("events", false, "run etcdctl against etcd-events instead of etcd-main")
+	cmd.Flags().Bool("allow-write", false, "allow etcdctl commands which may modify etcd")
+
+	return cmd
+}
+
+// etcdctlFlags returns the flags of gardenctl given before the arguments of etcdctl and the remaining arguments
+func etcdctlFlags(args []string) (events, allowWrite, help bool, remaining []string) {
+	for len(args) > 0 {
+		switch args[0] {
+		case "--events":
+			events = true
+		case "--allow-write":
+			allowWrite = true
+		case "-h", "--help":
+			help = true
+		case "--":
+			return events, allowWrite, help, args[1:]
+		default:
+			return events, allowWrite, help, args
+		}
+		args = args[1:]
+	}
+	return events, allowWrite, help, args
+}
+
+// etcdctlCommand returns the etcdctl command of the arguments and whether it is known not to modify etcd
+func etcdctlCommand(args []string) (string, bool) {
+	var positional []string
+	for i := 0; i < len(args) && len(positional) < 2; i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			if !strings.Contains(arg, "=") && etcdctlValueFlags[arg] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) == 0 {
+		return "", false
+	}
+
+	subcommands, ok := etcdctlReadOnlyCommands[positional[0]]
+	if !ok {
+		return positional[0], false
+	}
+	if subcommands == nil {
+		return positional[0], true
+	}
+	command := strings.Join(positional, " ")
+	if len(positional) < 2 {
+		return command, false
+	}
+	for _, subcommand := range subcommands {
+		if positional[1] == subcommand {
+			return command, true
+		}
+	}
+	return command, false
+}
+
+// redactEtcdctlArgs returns the arguments with the passwords replaced for the audit trail
+func redactEtcdctlArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "--password":
+			arg = "***"
+		case strings.HasPrefix(arg, "--password="):
+			arg = "--password=***"
+		case strings.HasPrefix(arg, "--user=") && strings.Contains(arg, ":"):
+			arg = arg[:strings.Index(arg, ":")] + ":***"
+		case i > 0 && args[i-1] == "--user" && strings.Contains(arg, ":"):
+			arg = arg[:strings.Index(arg, ":")] + ":***"
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// etcdctlShellCommand returns the command which runs etcdctl with the arguments against the etcd with its client
+// certificate in the etcd container
+func etcdctlShellCommand(name string, args []string) []string {
+	arguments := []string{
+		"--endpoints=https://" + name + "-local:" + strconv.Itoa(etcdClientPort),
+		"--cacert=" + etcdCertificateDir + "/ca/ca.crt",
+		"--cert=" + etcdCertificateDir + "/client/tls.crt",
+		"--key=" + etcdCertificateDir + "/client/tls.key",
+	}
+	quoted := make([]string, 0, len(arguments)+len(args))
+	for _, arg := range append(arguments, args...) {
+		quoted = append(quoted, "'"+strings.Replace(arg, "'", `'"'"'`, -1)+"'")
+	}
+	return []string{"/bin/sh", "-c", "ETCDCTL_API=3 exec etcdctl " + strings.Join(quoted, " ")}
+}
+
+// currentUserName returns the name of the local user running gardenctl
+func currentUserName() string {
+	if current, err := osuser.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+var _ = Describe("Etcdctl command", func() {
+	var (
+		ctrl          *gomock.Controller
+		targetReader  *mockcmd.MockTargetReader
+		target        *mockcmd.MockTargetInterface
+		podExecutor   *mockcmd.MockPodExecutor
+		historyWriter *mockcmd.MockHistoryWriter
+		audited       []map[string]string
+		stack         []cmd.TargetMeta
+		seedClient    *kubernetesfake.Clientset
+	)
+
+	controlPlaneNamespace := "shoot--prod--test-shoot"
+	projectNamespace := "garden-prod"
+
+	etcdPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-0", Namespace: controlPlaneNamespace},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	execute := func(args ...string) (string, string, error) {
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+		command := cmd.NewEtcdctlCmd(targetReader, podExecutor, historyWriter, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), errOut.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		podExecutor = mockcmd.NewMockPodExecutor(ctrl)
+		historyWriter = mockcmd.NewMockHistoryWriter(ctrl)
+		audited = nil
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}
+		seedClient = kubernetesfake.NewSimpleClientset(etcdPod("etcd-main"), etcdPod("etcd-events"))
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta {
+			return stack
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) {
+			return gardencorefake.NewSimpleClientset(
+				&gardencorev1beta1.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+					Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+				},
+				&gardencorev1beta1.Shoot{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+					Status:     gardencorev1beta1.ShootStatus{TechnicalID: controlPlaneNamespace},
+				},
+			), nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			return seedClient, nil
+		}).AnyTimes()
+		historyWriter.EXPECT().WriteStringln(gomock.Any(), gomock.Any()).DoAndReturn(func(path string, entry interface{}) error {
+			audited = append(audited, entry.(map[string]string))
+			return nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should run read-only commands against etcd-main and record them in the audit trail", func() {
+		podExecutor.EXPECT().Exec(cmd.TargetKindSeed, controlPlaneNamespace, "etcd-main-0", "etcd", []string{
+			"/bin/sh", "-c", "ETCDCTL_API=3 exec etcdctl '--endpoints=https://etcd-main-local:2379' '--cacert=/var/etcd/ssl/ca/ca.crt' '--cert=/var/etcd/ssl/client/tls.crt' '--key=/var/etcd/ssl/client/tls.key' 'get' '/registry/it'\"'\"'s' '--prefix' '--keys-only'",
+		}, gomock.Any(), gomock.Any()).DoAndReturn(func(kind cmd.TargetKind, namespace, pod, container string, command []string, stdout, stderr io.Writer) error {
+			fmt.Fprintln(stdout, "/registry/it's/a-key")
+			return nil
+		})
+
+		out, _, err := execute("get", "/registry/it's", "--prefix", "--keys-only")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("/registry/it's/a-key\n"))
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("shoot", "garden-prod/test-shoot"))
+		Expect(audited[0]).To(HaveKeyWithValue("etcd", "etcd-main"))
+		Expect(audited[0]).To(HaveKeyWithValue("command", "etcdctl get /registry/it's --prefix --keys-only"))
+		Expect(audited[0]).To(HaveKeyWithValue("allowWrite", "false"))
+		Expect(audited[0]).To(HaveKeyWithValue("result", "succeeded"))
+	})
+
+	It("should run commands against etcd-events with --events", func() {
+		podExecutor.EXPECT().Exec(cmd.TargetKindSeed, controlPlaneNamespace, "etcd-events-0", "etcd", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		_, _, err := execute("--events", "-w", "table", "endpoint", "status")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("etcd", "etcd-events"))
+		Expect(audited[0]).To(HaveKeyWithValue("command", "etcdctl -w table endpoint status"))
+	})
+
+	It("should block commands which may modify etcd without --allow-write", func() {
+		blocked := map[string][]string{
+			"put":           {"put", "foo", "bar"},
+			"del":           {"del", "foo"},
+			"member remove": {"member", "remove", "8e9e05c52164694d"},
+			"defrag":        {"--user", "root:secret", "defrag"},
+		}
+		for command, args := range blocked {
+			_, _, err := execute(args...)
+
+			Expect(err).To(MatchError(fmt.Sprintf("etcdctl %s may modify etcd, use --allow-write to run it", command)))
+		}
+		Expect(audited).To(HaveLen(4))
+		for _, entry := range audited {
+			Expect(entry).To(HaveKeyWithValue("result", "blocked"))
+		}
+		Expect(audited).To(ContainElement(HaveKeyWithValue("command", "etcdctl --user root:*** defrag")))
+	})
+
+	It("should run commands which may modify etcd with --allow-write", func() {
+		podExecutor.EXPECT().Exec(cmd.TargetKindSeed, controlPlaneNamespace, "etcd-main-0", "etcd", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		_, _, err := execute("--allow-write", "del", "foo", "--password=secret")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("command", "etcdctl del foo --password=***"))
+		Expect(audited[0]).To(HaveKeyWithValue("allowWrite", "true"))
+		Expect(audited[0]).To(HaveKeyWithValue("result", "succeeded"))
+	})
+
+	It("should not allow to override the connection flags", func() {
+		_, _, err := execute("--endpoints=https://127.0.0.1:2379", "get", "foo")
+
+		Expect(err).To(MatchError("flag --endpoints is set by gardenctl and cannot be overridden"))
+		Expect(audited).To(BeEmpty())
+	})
+
+	It("should pass the exit code of etcdctl through", func() {
+		podExecutor.EXPECT().Exec(cmd.TargetKindSeed, controlPlaneNamespace, "etcd-main-0", "etcd", gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(utilexec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2})
+
+		_, _, err := execute("get", "foo")
+
+		Expect(err).To(HaveOccurred())
+		Expect(cmd.ExitCode(err)).To(Equal(2))
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("result", "exit code 2"))
+	})
+
+	It("should record invocations which fail before etcdctl runs in the audit trail", func() {
+		stack = stack[:2]
+
+		_, _, err := execute("get", "foo")
+
+		Expect(err).To(MatchError("no shoot targeted"))
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("shoot", "-"))
+		Expect(audited[0]).To(HaveKeyWithValue("command", "etcdctl get foo"))
+		Expect(audited[0]).To(HaveKeyWithValue("result", "failed: no shoot targeted"))
+	})
+
+	It("should record in the audit trail that the etcd pod is unavailable", func() {
+		seedClient = kubernetesfake.NewSimpleClientset(etcdPod("etcd-main"))
+
+		_, _, err := execute("--events", "get", "foo")
+
+		Expect(err).To(MatchError(`pods "etcd-events-0" not found`))
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("shoot", "garden-prod/test-shoot"))
+		Expect(audited[0]).To(HaveKeyWithValue("etcd", "etcd-events"))
+		Expect(audited[0]).To(HaveKeyWithValue("result", `failed: pods "etcd-events-0" not found`))
+	})
+
+	It("should record in the audit trail that the etcd pod is not running", func() {
+		pending := etcdPod("etcd-main")
+		pending.Status.Phase = corev1.PodPending
+		seedClient = kubernetesfake.NewSimpleClientset(pending)
+
+		_, _, err := execute("get", "foo")
+
+		Expect(err).To(MatchError("etcd-main-0 is not running"))
+		Expect(audited).To(HaveLen(1))
+		Expect(audited[0]).To(HaveKeyWithValue("result", "failed: etcd-main-0 is not running"))
+	})
+})
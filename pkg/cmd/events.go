@@ -0,0 +1,439 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// eventTablePadding is the number of spaces between the columns of the events table
+	eventTablePadding = 3
+	// eventWatchInitialBackoff is the time waited before a watch of events which ended is restarted
+	eventWatchInitialBackoff = time.Second
+	// eventWatchMaxBackoff is the longest time waited before a watch of events which failed repeatedly is restarted
+	eventWatchMaxBackoff = 16 * time.Second
+)
+
+// eventSources are the clusters events are read from, in the order they are listed for events seen at the same time
+var eventSources = []string{"garden", "seed", "shoot"}
+
+// eventSource is a namespace of a cluster whose events are read
+type eventSource struct {
+	name      string
+	client    kubernetes.Interface
+	namespace string
+	// object restricts the events to those of the object with this name if it is not empty
+	object string
+}
+
+// eventFilter restricts the events which are printed
+type eventFilter struct {
+	eventType string
+	kind      string
+	name      string
+	since     time.Time
+}
+
+// NewEventsCmd returns a new events command.
+func NewEventsCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		watchEvents bool
+		since       time.Duration
+		eventType   string
+		involved    string
+	)
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show the events of the targeted shoot from the garden, seed and shoot cluster, e.g. \"gardenctl events --type Warning --since 1h\"",
+		Long: `Show the events of the targeted shoot merged into one timeline. The events are read from
+  garden  the shoot resource in the project namespace
+  seed    the control plane namespace of the shoot
+  shoot   the kube-system namespace of the shoot
+Clusters which cannot be reached are skipped with a warning.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: events [--watch] [--since duration] [--type Normal|Warning] [--involved kind/name]")
+			}
+			filter, err := newEventFilter(eventType, involved, since, time.Now())
+			if err != nil {
+				return err
+			}
+			target := targetReader.ReadTarget(pathTarget)
+			if !CheckShootIsTargeted(target) {
+				return errors.New("no shoot targeted")
+			}
+			shoot, err := FetchShootFromTarget(target)
+			if err != nil {
+				return err
+			}
+			if shoot == nil {
+				return fmt.Errorf("no shoot found with name %q", target.Stack()[2].Name)
+			}
+
+			sources := []eventSource{}
+			addSource := func(source eventSource, kind TargetKind, unavailable error) {
+				if unavailable == nil {
+					source.client, unavailable = target.K8SClientToKind(kind)
+				}
+				if unavailable != nil {
+					fmt.Fprintf(ioStreams.ErrOut, "Warning: events of the %s are unavailable: %v\n", source.name, unavailable)
+					return
+				}
+				sources = append(sources, source)
+			}
+			addSource(eventSource{name: "garden", namespace: shoot.Namespace, object: shoot.Name}, TargetKindGarden, nil)
+			var seedErr, shootErr error
+			if shoot.Status.TechnicalID == "" {
+				seedErr = errors.New("shoot has no control plane namespace")
+			}
+			if shoot.Status.IsHibernated {
+				shootErr = errors.New("shoot is hibernated")
+			}
+			addSource(eventSource{name: "seed", namespace: shoot.Status.TechnicalID}, TargetKindSeed, seedErr)
+			addSource(eventSource{name: "shoot", namespace: metav1.NamespaceSystem}, TargetKindShoot, shootErr)
+
+			return printEvents(cmd.Context(), sources, filter, watchEvents, outputFormatOrTable(cmd), ioStreams)
+		},
+	}
+	cmd.Flags().BoolVarP(&watchEvents, "watch", "w", false, "watch for new events after listing the existing ones until interrupted")
+	cmd.Flags().DurationVar(&since, "since", 0, "only show events seen within this duration, e.g. 1h")
+	cmd.Flags().StringVar(&eventType, "type", "", "only show events of this type: Normal or Warning")
+	cmd.Flags().StringVar(&involved, "involved", "", "only show events of this object, in the format kind/name, e.g. pod/kube-apiserver-0")
+
+	return cmd
+}
+
+// newEventFilter returns the filter for the flags of the events command
+func newEventFilter(eventType, involved string, since time.Duration, now time.Time) (*eventFilter, error) {
+	filter := &eventFilter{}
+	switch strings.ToLower(eventType) {
+	case "":
+	case "normal":
+		filter.eventType = corev1.EventTypeNormal
+	case "warning":
+		filter.eventType = corev1.EventTypeWarning
+	default:
+		return nil, fmt.Errorf("invalid --type %q, must be Normal or Warning", eventType)
+	}
+	if involved != "" {
+		parts := strings.Split(involved, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --involved %q, must be in the format kind/name", involved)
+		}
+		filter.kind, filter.name = strings.ToLower(parts[0]), parts[1]
+	}
+	if since < 0 {
+		return nil, fmt.Errorf("invalid --since %s, must not be negative", since)
+	}
+	if since > 0 {
+		filter.since = now.Add(-since)
+	}
+	return filter, nil
+}
+
+// matches returns whether the event passes the filter
+func (f *eventFilter) matches(meta EventMeta) bool {
+	if f.eventType != "" && meta.Type != f.eventType {
+		return false
+	}
+	if f.name != "" && meta.Object != f.kind+"/"+f.name {
+		return false
+	}
+	if !f.since.IsZero() {
+		if lastSeen, err := time.Parse(time.RFC3339, meta.LastSeen); err == nil && lastSeen.Before(f.since) {
+			return false
+		}
+	}
+	return true
+}
+
+// listOptions returns the options to list or watch the events of the source
+func (s eventSource) listOptions(resourceVersion string) metav1.ListOptions {
+	options := metav1.ListOptions{ResourceVersion: resourceVersion}
+	if s.object != "" {
+		options.FieldSelector = fields.OneTermEqualSelector("involvedObject.name", s.object).String()
+	}
+	return options
+}
+
+// printEvents prints the merged events of the sources and, if watchEvents is set, watches them for new events until
+// the context is cancelled or gardenctl is interrupted
+func printEvents(ctx context.Context, sources []eventSource, filter *eventFilter, watchEvents bool, outFormat string, ioStreams IOStreams) error {
+	if len(sources) == 0 {
+		return errors.New("events could not be read from any cluster")
+	}
+	var (
+		metas            []EventMeta
+		listed           []eventSource
+		resourceVersions = map[string]string{}
+		// seen are the resource versions of the events by source and UID, events are only printed once per version
+		seen = map[string]string{}
+	)
+	accept := func(source eventSource, event corev1.Event) (EventMeta, bool) {
+		key := source.name + "/" + string(event.UID)
+		if seen[key] == event.ResourceVersion {
+			return EventMeta{}, false
+		}
+		seen[key] = event.ResourceVersion
+		if source.object != "" && event.InvolvedObject.Name != source.object {
+			return EventMeta{}, false
+		}
+		meta := toEventMeta(source.name, event)
+		return meta, filter.matches(meta)
+	}
+	for _, source := range sources {
+		events, err := source.client.CoreV1().Events(source.namespace).List(source.listOptions(""))
+		if err != nil {
+			fmt.Fprintf(ioStreams.ErrOut, "Warning: events of the %s are unavailable: %v\n", source.name, err)
+			continue
+		}
+		listed = append(listed, source)
+		resourceVersions[source.name] = events.ResourceVersion
+		for _, event := range events.Items {
+			if meta, ok := accept(source, event); ok {
+				metas = append(metas, meta)
+			}
+		}
+	}
+	if len(listed) == 0 {
+		return errors.New("events could not be read from any cluster")
+	}
+	metas = mergeEvents(metas)
+
+	table := &eventTable{writer: ioStreams.Out}
+	if outFormat != tableOutputFormat {
+		if err := PrintoutObject(metas, ioStreams.Out, outFormat); err != nil {
+			return err
+		}
+	} else {
+		rows := [][]string{{"LAST SEEN", "SOURCE", "TYPE", "REASON", "OBJECT", "COUNT", "MESSAGE"}}
+		for _, meta := range metas {
+			rows = append(rows, eventRow(meta))
+		}
+		table.print(rows...)
+	}
+	if !watchEvents {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	received := make(chan sourceEvent)
+	errOut := &syncWriter{writer: ioStreams.ErrOut}
+	for _, source := range listed {
+		go watchEventSource(ctx, source, resourceVersions[source.name], received, errOut)
+	}
+	for {
+		var next sourceEvent
+		select {
+		case next = <-received:
+		case <-ctx.Done():
+			return nil
+		}
+		meta, ok := accept(next.source, next.event)
+		if !ok {
+			continue
+		}
+		if outFormat != tableOutputFormat {
+			if outFormat == "yaml" {
+				fmt.Fprintln(ioStreams.Out, "---")
+			}
+			if err := PrintoutObject(meta, ioStreams.Out, outFormat); err != nil {
+				return err
+			}
+			fmt.Fprintln(ioStreams.Out)
+			continue
+		}
+		table.print(eventRow(meta))
+	}
+}
+
+// sourceEvent is an event received from a source
+type sourceEvent struct {
+	source eventSource
+	event  corev1.Event
+}
+
+// watchEventSource sends the events of the source to received from the resource version on until the context is
+// cancelled. A watch which ends or fails is restarted with a backoff from a new list of the events, whose events are
+// sent again.
+func watchEventSource(ctx context.Context, source eventSource, resourceVersion string, received chan<- sourceEvent, errOut io.Writer) {
+	send := func(event corev1.Event) bool {
+		select {
+		case received <- sourceEvent{source: source, event: event}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	listed := true
+	backoff := eventWatchInitialBackoff
+	for {
+		if !listed {
+			events, err := source.client.CoreV1().Events(source.namespace).List(source.listOptions(""))
+			if err != nil {
+				fmt.Fprintf(errOut, "Warning: events of the %s cannot be listed, retrying in %s: %v\n", source.name, backoff, err)
+			} else {
+				listed, resourceVersion = true, events.ResourceVersion
+				for _, event := range events.Items {
+					if !send(event) {
+						return
+					}
+				}
+			}
+		}
+		if listed {
+			watcher, err := source.client.CoreV1().Events(source.namespace).Watch(source.listOptions(resourceVersion))
+			if err != nil {
+				fmt.Fprintf(errOut, "Warning: events of the %s cannot be watched, retrying in %s: %v\n", source.name, backoff, err)
+			} else {
+				backoff = eventWatchInitialBackoff
+				if !forwardEvents(ctx, watcher, send) {
+					return
+				}
+			}
+			// the resource version may have expired, the events are listed again
+			listed = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > eventWatchMaxBackoff {
+			backoff = eventWatchMaxBackoff
+		}
+	}
+}
+
+// forwardEvents sends the added and modified events of the watch until it ends or fails. It stops the watch and
+// returns false if the context is cancelled or an event could not be sent.
+func forwardEvents(ctx context.Context, watcher watch.Interface, send func(corev1.Event) bool) bool {
+	defer watcher.Stop()
+	for {
+		select {
+		case result, ok := <-watcher.ResultChan():
+			if !ok || result.Type == watch.Error {
+				return true
+			}
+			if event, isEvent := result.Object.(*corev1.Event); isEvent && result.Type != watch.Deleted && !send(*event) {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// mergeEvents de-duplicates the events which only differ in their counts and times and sorts them by the time they
+// were last seen
+func mergeEvents(metas []EventMeta) []EventMeta {
+	merged := []EventMeta{}
+	index := map[string]int{}
+	for _, meta := range metas {
+		key := strings.Join([]string{meta.Source, meta.Namespace, meta.Object, meta.Type, meta.Reason, meta.Message}, "\x00")
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, meta)
+			continue
+		}
+		existing := &merged[i]
+		existing.Count += meta.Count
+		if meta.FirstSeen < existing.FirstSeen {
+			existing.FirstSeen = meta.FirstSeen
+		}
+		if meta.LastSeen > existing.LastSeen {
+			existing.LastSeen = meta.LastSeen
+		}
+	}
+	sourceRank := map[string]int{}
+	for i, source := range eventSources {
+		sourceRank[source] = i
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].LastSeen != merged[j].LastSeen {
+			return merged[i].LastSeen < merged[j].LastSeen
+		}
+		return sourceRank[merged[i].Source] < sourceRank[merged[j].Source]
+	})
+	return merged
+}
+
+// eventRow returns the cells of the event in the events table
+func eventRow(meta EventMeta) []string {
+	return []string{valueOrDash(meta.LastSeen), meta.Source, meta.Type, meta.Reason, meta.Object, strconv.Itoa(int(meta.Count)), meta.Message}
+}
+
+// eventTable prints the rows of the events table like a tabwriter. The widths of the columns are kept between the
+// calls of print, so that the rows of events received while watching line up with the rows printed before.
+type eventTable struct {
+	writer io.Writer
+	widths []int
+}
+
+// print prints the rows, the last cell of each row is not padded
+func (t *eventTable) print(rows ...[]string) {
+	for _, row := range rows {
+		for i, cell := range row[:len(row)-1] {
+			if i == len(t.widths) {
+				t.widths = append(t.widths, 0)
+			}
+			if width := utf8.RuneCountInString(cell) + eventTablePadding; width > t.widths[i] {
+				t.widths[i] = width
+			}
+		}
+	}
+	for _, row := range rows {
+		line := &strings.Builder{}
+		for i, cell := range row[:len(row)-1] {
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", t.widths[i]-utf8.RuneCountInString(cell)))
+		}
+		line.WriteString(row[len(row)-1])
+		fmt.Fprintln(t.writer, line.String())
+	}
+}
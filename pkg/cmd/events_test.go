@@ -0,0 +1,284 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Events command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		target       *mockcmd.MockTargetInterface
+		shoot        *gardencorev1beta1.Shoot
+		gardenClient *kubernetesfake.Clientset
+		seedClient   *kubernetesfake.Clientset
+		shootClient  *kubernetesfake.Clientset
+		seedErr      error
+		stack        []cmd.TargetMeta
+	)
+
+	projectNamespace := "garden-prod"
+	controlPlaneNamespace := "shoot--prod--test-shoot"
+	now := time.Now()
+
+	event := func(namespace, uid, kind, name, eventType, reason, message string, lastSeen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: uid, Namespace: namespace, UID: types.UID(uid), ResourceVersion: "1"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        message,
+			Count:          1,
+			FirstTimestamp: metav1.NewTime(lastSeen),
+			LastTimestamp:  metav1.NewTime(lastSeen),
+		}
+	}
+	executeContext := func(ctx context.Context, args ...string) (string, string, error) {
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+		command := cmd.NewEventsCmd(targetReader, ioStreams)
+		command.SetArgs(args)
+		err := command.ExecuteContext(ctx)
+		return out.String(), errOut.String(), err
+	}
+	execute := func(args ...string) (string, string, error) {
+		return executeContext(context.Background(), args...)
+	}
+	lines := func(out string) []string {
+		return strings.Split(strings.TrimSpace(out), "\n")
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+		seedErr = nil
+
+		shoot = &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Status:     gardencorev1beta1.ShootStatus{TechnicalID: controlPlaneNamespace},
+		}
+		gardenClient = kubernetesfake.NewSimpleClientset(
+			event(projectNamespace, "g1", "Shoot", "test-shoot", corev1.EventTypeNormal, "Reconciling", "reconciling the shoot", now.Add(-50*time.Minute)),
+			event(projectNamespace, "g2", "Shoot", "test-shoot", corev1.EventTypeWarning, "ReconcileError", "infrastructure failed", now.Add(-10*time.Minute)),
+			event(projectNamespace, "g3", "Shoot", "other-shoot", corev1.EventTypeWarning, "ReconcileError", "other shoot failed", now.Add(-5*time.Minute)),
+		)
+		seedClient = kubernetesfake.NewSimpleClientset(
+			event(controlPlaneNamespace, "s1", "Pod", "kube-apiserver-0", corev1.EventTypeWarning, "Unhealthy", "readiness probe failed", now.Add(-30*time.Minute)),
+			event(controlPlaneNamespace, "s2", "Pod", "kube-apiserver-0", corev1.EventTypeWarning, "Unhealthy", "readiness probe failed", now.Add(-20*time.Minute)),
+			event(controlPlaneNamespace, "s3", "Pod", "etcd-main-0", corev1.EventTypeNormal, "Pulled", "image pulled", now.Add(-2*time.Hour)),
+		)
+		shootClient = kubernetesfake.NewSimpleClientset(
+			event(metav1.NamespaceSystem, "k1", "Pod", "coredns-0", corev1.EventTypeWarning, "BackOff", "back-off restarting failed container", now.Add(-40*time.Minute)),
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (*gardencorefake.Clientset, error) {
+			return gardencorefake.NewSimpleClientset(
+				&gardencorev1beta1.Project{
+					ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+					Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+				},
+				shoot,
+			), nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindGarden).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			return gardenClient, nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindSeed).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			if seedErr != nil {
+				return nil, seedErr
+			}
+			return seedClient, nil
+		}).AnyTimes()
+		target.EXPECT().K8SClientToKind(cmd.TargetKindShoot).DoAndReturn(func(cmd.TargetKind) (*kubernetesfake.Clientset, error) {
+			return shootClient, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should merge the events of the garden, seed and shoot into one timeline", func() {
+		out, errOut, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errOut).To(BeEmpty())
+		rows := lines(out)
+		Expect(rows).To(HaveLen(6))
+		Expect(rows[0]).To(MatchRegexp(`^LAST SEEN +SOURCE +TYPE +REASON +OBJECT +COUNT +MESSAGE$`))
+		Expect(rows[1]).To(MatchRegexp(`seed +Normal +Pulled +pod/etcd-main-0 +1 +image pulled$`))
+		Expect(rows[2]).To(MatchRegexp(`garden +Normal +Reconciling +shoot/test-shoot +1 +reconciling the shoot$`))
+		Expect(rows[3]).To(MatchRegexp(`shoot +Warning +BackOff +pod/coredns-0 +1 +back-off restarting failed container$`))
+		Expect(rows[4]).To(MatchRegexp(`seed +Warning +Unhealthy +pod/kube-apiserver-0 +2 +readiness probe failed$`))
+		Expect(rows[4]).To(HavePrefix(now.Add(-20 * time.Minute).UTC().Format(time.RFC3339)))
+		Expect(rows[5]).To(MatchRegexp(`garden +Warning +ReconcileError +shoot/test-shoot +1 +infrastructure failed$`))
+		Expect(out).NotTo(ContainSubstring("other-shoot"))
+	})
+
+	It("should filter the events by type, involved object and age", func() {
+		out, _, err := execute("--type", "warning", "--since", "35m")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines(out)).To(HaveLen(3))
+		Expect(out).To(ContainSubstring("pod/kube-apiserver-0"))
+		Expect(out).To(ContainSubstring("shoot/test-shoot"))
+
+		out, _, err = execute("--involved", "Pod/coredns-0")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines(out)).To(HaveLen(2))
+		Expect(lines(out)[1]).To(ContainSubstring("pod/coredns-0"))
+	})
+
+	It("should reject invalid filters", func() {
+		_, _, err := execute("--type", "Error")
+		Expect(err).To(MatchError(`invalid --type "Error", must be Normal or Warning`))
+
+		_, _, err = execute("--involved", "coredns-0")
+		Expect(err).To(MatchError(`invalid --involved "coredns-0", must be in the format kind/name`))
+	})
+
+	It("should return an error if the shoot targeted via its seed does not exist anymore", func() {
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindSeed, Name: "test-seed"},
+			{Kind: cmd.TargetKindShoot, Name: "gone"},
+		}
+
+		_, _, err := execute()
+		Expect(err).To(MatchError(`no shoot found with name "gone"`))
+	})
+
+	It("should skip clusters which are unavailable with a warning", func() {
+		seedErr = errors.New("connection refused")
+		shoot.Status.IsHibernated = true
+
+		out, errOut, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errOut).To(Equal("Warning: events of the seed are unavailable: connection refused\nWarning: events of the shoot are unavailable: shoot is hibernated\n"))
+		Expect(lines(out)).To(HaveLen(3))
+	})
+
+	It("should print new events in line with the listed ones until interrupted", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		watchers := map[string]*watch.FakeWatcher{}
+		for name, client := range map[string]*kubernetesfake.Clientset{"garden": gardenClient, "seed": seedClient, "shoot": shootClient} {
+			watcher := watch.NewFake()
+			watchers[name] = watcher
+			client.PrependWatchReactor("events", k8stesting.DefaultWatchReactor(watcher, nil))
+		}
+		go func() {
+			defer GinkgoRecover()
+			updated := event(controlPlaneNamespace, "s2", "Pod", "kube-apiserver-0", corev1.EventTypeWarning, "Unhealthy", "readiness probe failed", now)
+			updated.Count = 3
+			updated.ResourceVersion = "2"
+			watchers["seed"].Modify(event(controlPlaneNamespace, "s1", "Pod", "kube-apiserver-0", corev1.EventTypeWarning, "Unhealthy", "readiness probe failed", now.Add(-30*time.Minute)))
+			watchers["seed"].Modify(updated)
+			watchers["garden"].Add(event(projectNamespace, "g4", "Shoot", "other-shoot", corev1.EventTypeNormal, "Reconciled", "other shoot reconciled", now))
+			watchers["garden"].Add(event(projectNamespace, "g5", "Shoot", "test-shoot", corev1.EventTypeNormal, "Reconciled", "reconciled the shoot", now))
+			// the deleted events are ignored, they are sent after the others to wait until those are printed
+			for _, watcher := range watchers {
+				watcher.Delete(event(metav1.NamespaceSystem, "k1", "Pod", "coredns-0", corev1.EventTypeWarning, "BackOff", "back-off", now))
+			}
+			cancel()
+		}()
+
+		out, _, err := executeContext(ctx, "--watch")
+
+		Expect(err).NotTo(HaveOccurred())
+		rows := lines(out)
+		Expect(rows).To(HaveLen(8))
+		Expect(rows[6:]).To(ConsistOf(
+			MatchRegexp(`seed +Warning +Unhealthy +pod/kube-apiserver-0 +3 +readiness probe failed$`),
+			MatchRegexp(`garden +Normal +Reconciled +shoot/test-shoot +1 +reconciled the shoot$`),
+		))
+		column := strings.Index(rows[0], "SOURCE")
+		for _, row := range rows[1:] {
+			Expect(row[column:]).To(MatchRegexp(`^(garden|seed|shoot) `))
+		}
+	})
+
+	It("should list and watch the events again if the watch expires", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var (
+			mutex    sync.Mutex
+			watchers []*watch.FakeWatcher
+		)
+		seedClient.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			watchers = append(watchers, watch.NewFake())
+			return true, watchers[len(watchers)-1], nil
+		})
+		watcher := func(i int) *watch.FakeWatcher {
+			for {
+				mutex.Lock()
+				if len(watchers) > i {
+					defer mutex.Unlock()
+					return watchers[i]
+				}
+				mutex.Unlock()
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		go func() {
+			defer GinkgoRecover()
+			watcher(0).Error(&metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired})
+			watcher(1).Add(event(controlPlaneNamespace, "s4", "Pod", "etcd-main-0", corev1.EventTypeWarning, "Unhealthy", "liveness probe failed", now))
+			watcher(1).Delete(event(controlPlaneNamespace, "s4", "Pod", "etcd-main-0", corev1.EventTypeWarning, "Unhealthy", "liveness probe failed", now))
+			cancel()
+		}()
+
+		out, errOut, err := executeContext(ctx, "--watch", "--type", "Warning")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errOut).To(BeEmpty())
+		rows := lines(out)
+		Expect(rows).To(HaveLen(5))
+		Expect(rows[4]).To(MatchRegexp(`seed +Warning +Unhealthy +pod/etcd-main-0 +1 +liveness probe failed$`))
+	})
+})
@@ -0,0 +1,229 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// kubeconfigExportOptions are the options of "get shoot --export-kubeconfig"
+type kubeconfigExportOptions struct {
+	path      string
+	namespace string
+	minify    bool
+	validate  bool
+}
+
+// exportShootKubeconfig writes a self-contained kubeconfig of the given or targeted shoot to a file
+func exportShootKubeconfig(name string, targetReader TargetReader, kubeconfigWriter KubeconfigWriter, options kubeconfigExportOptions, writer io.Writer) error {
+	raw, shoot, err := getShootKubeconfig(name, targetReader, kubeconfigWriter)
+	if err != nil {
+		return err
+	}
+
+	target := targetReader.ReadTarget(pathTarget)
+	projectName, err := projectNameOfShoot(target, shoot)
+	if err != nil {
+		return err
+	}
+	contextName := fmt.Sprintf("%s--%s--%s", target.Stack()[0].Name, projectName, shoot.Name)
+
+	config, err := buildPortableKubeconfig(raw, contextName, options.namespace, options.minify)
+	if err != nil {
+		return err
+	}
+	if options.validate {
+		if err := validateKubeconfig(config, writer); err != nil {
+			return err
+		}
+	}
+
+	out, err := serializeKubeconfig(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(options.path), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(options.path, out, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Kubeconfig with context %s written to %s\n", contextName, options.path)
+
+	return nil
+}
+
+// buildPortableKubeconfig embeds all referenced files into the kubeconfig and renames the current context,
+// its cluster and its user to the given name
+func buildPortableKubeconfig(raw []byte, name, namespace string, minify bool) (*clientcmdapi.Config, error) {
+	config, err := clientcmd.Load(raw)
+	if err != nil {
+		return nil, err
+	}
+	if config.CurrentContext == "" {
+		return nil, fmt.Errorf("kubeconfig has no current context")
+	}
+	if minify {
+		if err := clientcmdapi.MinifyConfig(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return nil, err
+	}
+	for _, authInfo := range config.AuthInfos {
+		if authInfo.TokenFile == "" {
+			continue
+		}
+		token, err := ioutil.ReadFile(authInfo.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authInfo.Token, authInfo.TokenFile = strings.TrimSpace(string(token)), ""
+	}
+
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found in kubeconfig", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", context.Cluster)
+	}
+	authInfo, ok := config.AuthInfos[context.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %q not found in kubeconfig", context.AuthInfo)
+	}
+
+	// keep the old cluster and user entries if other contexts still refer to them
+	delete(config.Contexts, config.CurrentContext)
+	clusterInUse, authInfoInUse := false, false
+	for _, other := range config.Contexts {
+		clusterInUse = clusterInUse || other.Cluster == context.Cluster
+		authInfoInUse = authInfoInUse || other.AuthInfo == context.AuthInfo
+	}
+	if !clusterInUse {
+		delete(config.Clusters, context.Cluster)
+	}
+	if !authInfoInUse {
+		delete(config.AuthInfos, context.AuthInfo)
+	}
+	context.Cluster, context.AuthInfo = name, name
+	if namespace != "" {
+		context.Namespace = namespace
+	}
+	config.Contexts[name] = context
+	config.Clusters[name] = cluster
+	config.AuthInfos[name] = authInfo
+	config.CurrentContext = name
+
+	return config, nil
+}
+
+// serializeKubeconfig converts the kubeconfig to its versioned form and encodes it as yaml
+func serializeKubeconfig(config *clientcmdapi.Config) ([]byte, error) {
+	versioned := &clientcmdv1.Config{}
+	if err := clientcmdlatest.Scheme.Convert(config, versioned, nil); err != nil {
+		return nil, err
+	}
+	versioned.APIVersion, versioned.Kind = clientcmdv1.SchemeGroupVersion.Version, "Config"
+
+	return yaml.Marshal(versioned)
+}
+
+// validateKubeconfig checks that the certificates of the current context are valid and the API server is reachable
+func validateKubeconfig(config *clientcmdapi.Config, writer io.Writer) error {
+	context := config.Contexts[config.CurrentContext]
+	if err := checkCertificatesValidity("certificate authority", config.Clusters[context.Cluster].CertificateAuthorityData); err != nil {
+		return err
+	}
+	if err := checkCertificatesValidity("client certificate", config.AuthInfos[context.AuthInfo].ClientCertificateData); err != nil {
+		return err
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*config, config.CurrentContext, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return err
+	}
+	restConfig.Timeout = 10 * time.Second
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	version, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("API server %s is not reachable: %v", restConfig.Host, err)
+	}
+	fmt.Fprintf(writer, "API server %s is reachable, version %s\n", restConfig.Host, version.GitVersion)
+
+	return nil
+}
+
+// checkCertificatesValidity returns an error if one of the PEM encoded certificates is not yet or no longer valid
+func checkCertificatesValidity(description string, data []byte) error {
+	now := time.Now()
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %v", description, err)
+		}
+		if now.Before(certificate.NotBefore) {
+			return fmt.Errorf("%s %q is not valid before %s", description, certificate.Subject.CommonName, certificate.NotBefore.Format(time.RFC3339))
+		}
+		if now.After(certificate.NotAfter) {
+			return fmt.Errorf("%s %q expired on %s", description, certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// projectNameOfShoot returns the name of the targeted project or the project owning the namespace of the shoot
+func projectNameOfShoot(target TargetInterface, shoot *v1beta1.Shoot) (string, error) {
+	if stack := target.Stack(); len(stack) > 1 && stack[1].Kind == TargetKindProject {
+		return stack[1].Name, nil
+	}
+
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return "", err
+	}
+	projects, err := projectNamesByNamespace(gardenClientset)
+	if err != nil {
+		return "", err
+	}
+	if name, ok := projects[shoot.Namespace]; ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("no project found for namespace %q", shoot.Namespace)
+}
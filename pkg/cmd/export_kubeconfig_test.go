@@ -0,0 +1,202 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var _ = Describe("Export kubeconfig", func() {
+	var (
+		ctrl             *gomock.Controller
+		targetReader     *mockcmd.MockTargetReader
+		configReader     *mockcmd.MockConfigReader
+		kubeconfigReader *mockcmd.MockKubeconfigReader
+		kubeconfigWriter *mockcmd.MockKubeconfigWriter
+		target           *mockcmd.MockTargetInterface
+		dir              string
+	)
+
+	seedName := "test-seed"
+	projectNamespace := "garden-prod"
+	technicalID := "shoot--prod--test-shoot"
+	shootKubeconfig := `apiVersion: v1
+kind: Config
+current-context: shoot--prod--test-shoot
+clusters:
+- name: shoot--prod--test-shoot
+  cluster:
+    server: https://api.test-shoot.prod.example.com
+    certificate-authority-data: Y2EtZGF0YQ==
+- name: shoot--prod--test-shoot-internal
+  cluster:
+    server: https://api.test-shoot.prod.internal.example.com
+contexts:
+- name: shoot--prod--test-shoot
+  context:
+    cluster: shoot--prod--test-shoot
+    user: shoot--prod--test-shoot
+- name: shoot--prod--test-shoot-internal
+  context:
+    cluster: shoot--prod--test-shoot-internal
+    user: shoot--prod--test-shoot
+users:
+- name: shoot--prod--test-shoot
+  user:
+    token: secret-token
+`
+
+	gardenClientSet := gardencorefake.NewSimpleClientset(
+		&gardencorev1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+		},
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Spec:       gardencorev1beta1.ShootSpec{SeedName: &seedName},
+			Status:     gardencorev1beta1.ShootStatus{TechnicalID: technicalID},
+		},
+		&gardencorev1beta1.Seed{
+			ObjectMeta: metav1.ObjectMeta{Name: seedName},
+			Spec: gardencorev1beta1.SeedSpec{
+				SecretRef: &corev1.SecretReference{Name: "seed-secret", Namespace: "garden"},
+			},
+		},
+	)
+	kubernetesClientSet := kubernetesfake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "seed-secret", Namespace: "garden"},
+			Data:       map[string][]byte{"kubeconfig": []byte("seed-kubeconfig")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubecfg", Namespace: technicalID},
+			Data:       map[string][]byte{"kubeconfig": []byte(shootKubeconfig)},
+		},
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		kubeconfigReader = mockcmd.NewMockKubeconfigReader(ctrl)
+		kubeconfigWriter = mockcmd.NewMockKubeconfigWriter(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(gardenClientSet, nil).AnyTimes()
+		target.EXPECT().K8SClientToKind(gomock.Any()).Return(kubernetesClientSet, nil).AnyTimes()
+		kubeconfigWriter.EXPECT().Write(gomock.Any(), []byte("seed-kubeconfig")).Return(nil)
+
+		var err error
+		dir, err = ioutil.TempDir("", "gardenctl-export")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		os.RemoveAll(dir)
+	})
+
+	It("should export a minified kubeconfig with renamed context", func() {
+		path := filepath.Join(dir, "ci", "kubeconfig.yaml")
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"shoot", "--export-kubeconfig", path, "--minify", "--namespace", "ci"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("Kubeconfig with context test-garden--prod--test-shoot written to " + path))
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		config, err := clientcmd.LoadFromFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.CurrentContext).To(Equal("test-garden--prod--test-shoot"))
+		Expect(config.Contexts).To(HaveLen(1))
+		Expect(config.Contexts["test-garden--prod--test-shoot"].Cluster).To(Equal("test-garden--prod--test-shoot"))
+		Expect(config.Contexts["test-garden--prod--test-shoot"].AuthInfo).To(Equal("test-garden--prod--test-shoot"))
+		Expect(config.Contexts["test-garden--prod--test-shoot"].Namespace).To(Equal("ci"))
+		Expect(config.Clusters).To(HaveLen(1))
+		Expect(config.Clusters["test-garden--prod--test-shoot"].CertificateAuthorityData).To(Equal([]byte("ca-data")))
+		Expect(config.AuthInfos["test-garden--prod--test-shoot"].Token).To(Equal("secret-token"))
+	})
+
+	It("should keep entries still used by other contexts without minify", func() {
+		path := filepath.Join(dir, "kubeconfig.yaml")
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"shoot", "--export-kubeconfig", path})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		config, err := clientcmd.LoadFromFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Contexts).To(HaveLen(2))
+		Expect(config.Clusters).To(HaveKey("shoot--prod--test-shoot-internal"))
+		Expect(config.AuthInfos).To(HaveKey("shoot--prod--test-shoot"))
+		Expect(config.AuthInfos).To(HaveKey("test-garden--prod--test-shoot"))
+	})
+})
+
+var _ = Describe("Export kubeconfig with conflicting arguments", func() {
+	Describe("NewGetCmd", func() {
+		It("should reject other kinds than shoot", func() {
+			ctrl := gomock.NewController(GinkgoT())
+			defer ctrl.Finish()
+
+			ioStreams, _, out, _ := cmd.NewTestIOStreams()
+			command := cmd.NewGetCmd(mockcmd.NewMockTargetReader(ctrl), mockcmd.NewMockConfigReader(ctrl), mockcmd.NewMockKubeconfigReader(ctrl), mockcmd.NewMockKubeconfigWriter(ctrl), ioStreams)
+			command.SetArgs([]string{"seed", "--export-kubeconfig", "kubeconfig.yaml"})
+			err := command.Execute()
+
+			Expect(err).To(MatchError("--export-kubeconfig is only supported for shoots, not for seed"))
+			Expect(out.String()).To(BeEmpty())
+		})
+
+		It("should reject --expand", func() {
+			ctrl := gomock.NewController(GinkgoT())
+			defer ctrl.Finish()
+
+			ioStreams, _, _, _ := cmd.NewTestIOStreams()
+			command := cmd.NewGetCmd(mockcmd.NewMockTargetReader(ctrl), mockcmd.NewMockConfigReader(ctrl), mockcmd.NewMockKubeconfigReader(ctrl), mockcmd.NewMockKubeconfigWriter(ctrl), ioStreams)
+			command.SetArgs([]string{"shoot", "--expand", "--export-kubeconfig", "kubeconfig.yaml"})
+			err := command.Execute()
+
+			Expect(err).To(MatchError("--expand cannot be combined with --export-kubeconfig"))
+		})
+	})
+})
@@ -0,0 +1,190 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	extensionHealthy      = "ok"
+	extensionUnhealthy    = "FAIL"
+	extensionProgressing  = "?"
+	extensionNotInstalled = "-"
+)
+
+// printExtensions prints which extensions are installed on which seed and whether they are healthy
+func printExtensions(target TargetInterface, seedName string, writer io.Writer, outFormat string) error {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+
+	extensions, err := getExtensions(gardenClientset, seedName)
+	if err != nil {
+		return err
+	}
+
+	if outFormat == tableOutputFormat {
+		return renderExtensionsMatrix(extensions, writer)
+	}
+	return PrintoutObject(extensions, writer, outFormat)
+}
+
+// getExtensions matches the controller registrations with their controller installations, optionally only for the given seed
+func getExtensions(gardenClientset gardencoreclientset.Interface, seedName string) (*Extensions, error) {
+	extensions := &Extensions{}
+	if seedName != "" {
+		if _, err := gardenClientset.CoreV1beta1().Seeds().Get(seedName, metav1.GetOptions{}); err != nil {
+			return nil, err
+		}
+		extensions.Seeds = []string{seedName}
+	} else {
+		seedList, err := gardenClientset.CoreV1beta1().Seeds().List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, seed := range seedList.Items {
+			extensions.Seeds = append(extensions.Seeds, seed.Name)
+		}
+		sort.Strings(extensions.Seeds)
+	}
+
+	registrationList, err := gardenClientset.CoreV1beta1().ControllerRegistrations().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	installationList, err := gardenClientset.CoreV1beta1().ControllerInstallations().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	installationsPerRegistration := make(map[string][]ExtensionInstallationMeta)
+	for _, installation := range installationList.Items {
+		if seedName != "" && installation.Spec.SeedRef.Name != seedName {
+			continue
+		}
+		registration := installation.Spec.RegistrationRef.Name
+		installationsPerRegistration[registration] = append(installationsPerRegistration[registration], toExtensionInstallationMeta(installation))
+	}
+
+	for _, registration := range registrationList.Items {
+		for _, resource := range registration.Spec.Resources {
+			extensions.Extensions = append(extensions.Extensions, ExtensionMeta{
+				Registration:  registration.Name,
+				Kind:          resource.Kind,
+				Type:          resource.Type,
+				Installations: installationsPerRegistration[registration.Name],
+			})
+		}
+	}
+	sort.Slice(extensions.Extensions, func(i, j int) bool {
+		if extensions.Extensions[i].Kind != extensions.Extensions[j].Kind {
+			return extensions.Extensions[i].Kind < extensions.Extensions[j].Kind
+		}
+		return extensions.Extensions[i].Type < extensions.Extensions[j].Type
+	})
+
+	return extensions, nil
+}
+
+// renderExtensionsMatrix renders a matrix of extension kind/type against seeds with a health marker per cell
+func renderExtensionsMatrix(extensions *Extensions, writer io.Writer) error {
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\n", "EXTENSION", strings.Join(extensions.Seeds, "\t"))
+
+	for _, extension := range extensions.Extensions {
+		markers := make([]string, 0, len(extensions.Seeds))
+		for _, seed := range extensions.Seeds {
+			markers = append(markers, extensionMarker(extension.Installations, seed))
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\n", extension.Kind, extension.Type, strings.Join(markers, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "\n%s: installed and healthy, %s: not installed or not healthy, %s: progressing or unknown, %s: not installed on seed\n", extensionHealthy, extensionUnhealthy, extensionProgressing, extensionNotInstalled)
+	for _, extension := range extensions.Extensions {
+		for _, installation := range extension.Installations {
+			if installation.Message != "" {
+				fmt.Fprintf(writer, "%s/%s on %s: %s\n", extension.Kind, extension.Type, installation.Seed, installation.Message)
+			}
+		}
+	}
+
+	return nil
+}
+
+// extensionMarker returns the health marker of an extension on the given seed
+func extensionMarker(installations []ExtensionInstallationMeta, seed string) string {
+	for _, installation := range installations {
+		if installation.Seed != seed {
+			continue
+		}
+		switch {
+		case installation.Installed == string(gardencorev1beta1.ConditionTrue) && installation.Healthy == string(gardencorev1beta1.ConditionTrue):
+			return extensionHealthy
+		case installation.Installed == string(gardencorev1beta1.ConditionFalse) || installation.Healthy == string(gardencorev1beta1.ConditionFalse):
+			return extensionUnhealthy
+		default:
+			return extensionProgressing
+		}
+	}
+	return extensionNotInstalled
+}
+
+func toExtensionInstallationMeta(installation gardencorev1beta1.ControllerInstallation) ExtensionInstallationMeta {
+	meta := ExtensionInstallationMeta{
+		Seed:      installation.Spec.SeedRef.Name,
+		Installed: string(gardencorev1beta1.ConditionUnknown),
+		Healthy:   string(gardencorev1beta1.ConditionUnknown),
+	}
+
+	var messages []string
+	if condition := getCondition(installation.Status.Conditions, gardencorev1beta1.ControllerInstallationInstalled); condition != nil {
+		meta.Installed = string(condition.Status)
+		if condition.Status == gardencorev1beta1.ConditionFalse {
+			messages = append(messages, condition.Message)
+		}
+	}
+	if condition := getCondition(installation.Status.Conditions, gardencorev1beta1.ControllerInstallationHealthy); condition != nil {
+		meta.Healthy = string(condition.Status)
+		if condition.Status == gardencorev1beta1.ConditionFalse {
+			messages = append(messages, condition.Message)
+		}
+	}
+	meta.Message = strings.Join(messages, "; ")
+
+	return meta
+}
+
+// getCondition returns the condition with the given type or nil if it does not exist
+func getCondition(conditions []gardencorev1beta1.Condition, conditionType gardencorev1beta1.ConditionType) *gardencorev1beta1.Condition {
+	for index, condition := range conditions {
+		if condition.Type == conditionType {
+			return &conditions[index]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Extensions", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+	)
+
+	targetMeta := []cmd.TargetMeta{
+		{
+			Kind: cmd.TargetKindGarden,
+			Name: "test-garden",
+		},
+	}
+
+	installation := func(name, registration, seed string, installed, healthy gardencorev1beta1.ConditionStatus, message string) *gardencorev1beta1.ControllerInstallation {
+		return &gardencorev1beta1.ControllerInstallation{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: gardencorev1beta1.ControllerInstallationSpec{
+				RegistrationRef: corev1.ObjectReference{Name: registration},
+				SeedRef:         corev1.ObjectReference{Name: seed},
+			},
+			Status: gardencorev1beta1.ControllerInstallationStatus{
+				Conditions: []gardencorev1beta1.Condition{
+					{Type: gardencorev1beta1.ControllerInstallationInstalled, Status: installed},
+					{Type: gardencorev1beta1.ControllerInstallationHealthy, Status: healthy, Message: message},
+				},
+			},
+		}
+	}
+
+	clientSet := gardencorefake.NewSimpleClientset(
+		&gardencorev1beta1.Seed{ObjectMeta: metav1.ObjectMeta{Name: "seed-a"}},
+		&gardencorev1beta1.Seed{ObjectMeta: metav1.ObjectMeta{Name: "seed-b"}},
+		&gardencorev1beta1.ControllerRegistration{
+			ObjectMeta: metav1.ObjectMeta{Name: "provider-aws"},
+			Spec: gardencorev1beta1.ControllerRegistrationSpec{
+				Resources: []gardencorev1beta1.ControllerResource{
+					{Kind: "Infrastructure", Type: "aws"},
+				},
+			},
+		},
+		&gardencorev1beta1.ControllerRegistration{
+			ObjectMeta: metav1.ObjectMeta{Name: "os-coreos"},
+			Spec: gardencorev1beta1.ControllerRegistrationSpec{
+				Resources: []gardencorev1beta1.ControllerResource{
+					{Kind: "OperatingSystemConfig", Type: "coreos"},
+				},
+			},
+		},
+		installation("provider-aws-a", "provider-aws", "seed-a", gardencorev1beta1.ConditionTrue, gardencorev1beta1.ConditionTrue, ""),
+		installation("provider-aws-b", "provider-aws", "seed-b", gardencorev1beta1.ConditionTrue, gardencorev1beta1.ConditionFalse, "deployment unhealthy"),
+		installation("os-coreos-a", "os-coreos", "seed-a", gardencorev1beta1.ConditionTrue, gardencorev1beta1.ConditionUnknown, ""),
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return(targetMeta).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should render the extension matrix for all seeds", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"extensions"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("EXTENSION                      seed-a   seed-b"))
+		Expect(out.String()).To(ContainSubstring("Infrastructure/aws             ok       FAIL"))
+		Expect(out.String()).To(ContainSubstring("OperatingSystemConfig/coreos   ?        -"))
+		Expect(out.String()).To(ContainSubstring("Infrastructure/aws on seed-b: deployment unhealthy"))
+	})
+
+	It("should only show the given seed", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"extensions", "--seed", "seed-b"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("Infrastructure/aws             FAIL"))
+		Expect(out.String()).To(ContainSubstring("OperatingSystemConfig/coreos   -"))
+		Expect(out.String()).NotTo(ContainSubstring("seed-a"))
+	})
+})
@@ -28,19 +28,40 @@ import (
 // NewGetCmd returns a new get command.
 func NewGetCmd(targetReader TargetReader, configReader ConfigReader,
 	kubeconfigReader KubeconfigReader, kubeconfigWriter KubeconfigWriter, ioStreams IOStreams) *cobra.Command {
+	var (
+		expand        bool
+		exportOptions kubeconfigExportOptions
+	)
 	cmd := &cobra.Command{
-		Use:          "get [(garden|project|seed|shoot|target) <name>]",
-		Short:        "Get single resource instance or target stack, e.g. CRD of a shoot (default: current target). \"gardenctl get target\" returns current stack, \"gardenctl get shoot\" returns current shoot",
+		Use:          "get [(garden|project|seed|shoot|cloudprofile|secretbinding|quota|plant|backupbucket|backupentry|controllerinstallation|target) <name>]",
+		Short:        "Get single resource instance or target stack, e.g. CRD of a shoot (default: current target). \"gardenctl get target\" returns current stack, \"gardenctl get shoot\" returns kubeconfig of current shoot, \"gardenctl get shoot --expand\" returns current shoot with its related objects",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			if len(args) < 1 || len(args) > 2 {
-				return errors.New("command must be in the format: get [(garden|project|seed|shoot|target) <name>]")
+				return errors.New("command must be in the format: get [(garden|project|seed|shoot|cloudprofile|secretbinding|quota|plant|backupbucket|backupentry|controllerinstallation|target) <name>]")
 			}
 
 			name := ""
 			if len(args) == 2 {
 				name = args[1]
 			}
+			if exportOptions.path != "" {
+				if args[0] != "shoot" {
+					return fmt.Errorf("--export-kubeconfig is only supported for shoots, not for %s", args[0])
+				}
+				if expand {
+					return errors.New("--expand cannot be combined with --export-kubeconfig")
+				}
+			}
+
+			switch args[0] {
+			case "project", "seed", "shoot":
+				if expand {
+					return printCoreResource(targetReader.ReadTarget(pathTarget), args[0], name, expand, ioStreams.Out, outputFormat)
+				}
+			case "cloudprofile", "secretbinding", "quota", "plant", "backupbucket", "controllerinstallation":
+				return printCoreResource(targetReader.ReadTarget(pathTarget), args[0], name, expand, ioStreams.Out, outputFormat)
+			}
 
 			switch args[0] {
 			case "project":
@@ -68,13 +89,21 @@ func NewGetCmd(targetReader TargetReader, configReader ConfigReader,
 				}
 
 			case "shoot":
-				if IsTargeted(targetReader, "shoot") {
-					err = printShootKubeconfig(name, targetReader, kubeconfigWriter, ioStreams.Out, outputFormat)
-					checkError(err)
-				} else {
+				if !IsTargeted(targetReader, "shoot") {
+					return errors.New("no shoot targeted")
+				}
+				if exportOptions.path != "" {
+					return exportShootKubeconfig(name, targetReader, kubeconfigWriter, exportOptions, ioStreams.Out)
+				}
+				err = printShootKubeconfig(name, targetReader, kubeconfigWriter, ioStreams.Out, outputFormat)
+				checkError(err)
+
+			case "backupentry":
+				if name == "" && !IsTargeted(targetReader, "shoot") {
 					return errors.New("no shoot targeted")
 				}
 
+				return printShootBackupEntry(targetReader.ReadTarget(pathTarget), name, ioStreams, outputFormat)
 			case "target":
 				if !IsTargeted(targetReader) {
 					return errors.New("target stack is empty")
@@ -85,13 +114,18 @@ func NewGetCmd(targetReader TargetReader, configReader ConfigReader,
 					return err
 				}
 			default:
-				fmt.Fprint(ioStreams.Out, "command must be in the format: get [project|garden|seed|shoot|target] + <NAME>")
+				fmt.Fprint(ioStreams.Out, "command must be in the format: get [project|garden|seed|shoot|cloudprofile|secretbinding|quota|plant|backupbucket|backupentry|controllerinstallation|target] + <NAME>")
 			}
 
 			return nil
 		},
-		ValidArgs: []string{"project", "garden", "seed", "shoot", "target"},
+		ValidArgs: append([]string{"garden", "target"}, coreResourceKinds...),
 	}
+	cmd.Flags().BoolVar(&expand, "expand", false, "inline related objects, e.g. the seed, secret binding and cloud profile of a shoot; prints seeds and shoots instead of their kubeconfig")
+	cmd.Flags().StringVar(&exportOptions.path, "export-kubeconfig", "", "write a self-contained kubeconfig of the shoot to the given file, with context, cluster and user named <garden>--<project>--<shoot>")
+	cmd.Flags().StringVar(&exportOptions.namespace, "namespace", "", "default namespace of the context in the exported kubeconfig")
+	cmd.Flags().BoolVar(&exportOptions.minify, "minify", false, "only keep the current context and the cluster and user it refers to in the exported kubeconfig")
+	cmd.Flags().BoolVar(&exportOptions.validate, "validate", false, "check certificate validity and API server reachability before writing the exported kubeconfig")
 
 	return cmd
 }
@@ -173,11 +207,21 @@ func printSeedKubeconfig(name string, targetReader TargetReader, writer io.Write
 
 // printShootKubeconfig lists kubeconfig of shoot
 func printShootKubeconfig(name string, targetReader TargetReader, kubeconfigWriter KubeconfigWriter, writer io.Writer, outFormat string) error {
+	kubeconfig, _, err := getShootKubeconfig(name, targetReader, kubeconfigWriter)
+	if err != nil {
+		return err
+	}
+
+	return PrintoutObject(fmt.Sprintf("%s\n", kubeconfig), writer, outFormat)
+}
+
+// getShootKubeconfig returns the kubeconfig of the given or targeted shoot, read from the seed
+func getShootKubeconfig(name string, targetReader TargetReader, kubeconfigWriter KubeconfigWriter) ([]byte, *v1beta1.Shoot, error) {
 	target := targetReader.ReadTarget(pathTarget)
 
 	client, err := target.K8SClientToKind(TargetKindGarden)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var shoot *v1beta1.Shoot
@@ -193,11 +237,11 @@ func printShootKubeconfig(name string, targetReader TargetReader, kubeconfigWrit
 
 	seed, err := GetTargetedSeedObject(targetReader)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	kubeSecret, err := client.CoreV1().Secrets(seed.Spec.SecretRef.Namespace).Get(seed.Spec.SecretRef.Name, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	gardenName, err := GetTargetName(targetReader, "garden")
 	checkError(err)
@@ -208,15 +252,15 @@ func printShootKubeconfig(name string, targetReader TargetReader, kubeconfigWrit
 
 	seedClient, err := target.K8SClientToKind(TargetKindSeed)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	kubeSecret, err = seedClient.CoreV1().Secrets(namespace).Get("kubecfg", metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	return PrintoutObject(fmt.Sprintf("%s\n", kubeSecret.Data["kubeconfig"]), writer, outFormat)
+	return kubeSecret.Data["kubeconfig"], shoot, nil
 }
 
 // printTarget prints the target stack.
@@ -63,7 +63,7 @@ var _ = Describe("Get command", func() {
 				err := command.Execute()
 
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("command must be in the format: get [(garden|project|seed|shoot|target) <name>]"))
+				Expect(err.Error()).To(Equal("command must be in the format: get [(garden|project|seed|shoot|cloudprofile|secretbinding|quota|plant|backupbucket|backupentry|controllerinstallation|target) <name>]"))
 			})
 		})
 
@@ -0,0 +1,140 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// shootChangeFlags are the flags of commands changing one or many shoots
+type shootChangeFlags struct {
+	selector    string
+	concurrency int
+	wait        bool
+	timeout     time.Duration
+	yes         bool
+}
+
+// addFlags adds the flags to the command
+func (f *shootChangeFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.selector, "selector", "l", "", "change all shoots of the targeted project, or of all projects, matching the label selector")
+	cmd.Flags().IntVar(&f.concurrency, "concurrency", defaultShootConcurrency, "maximum number of shoots changed in parallel with --selector")
+	f.addSingleShootFlags(cmd)
+}
+
+// addSingleShootFlags adds the flags to a command which changes exactly one shoot
+func (f *shootChangeFlags) addSingleShootFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.wait, "wait", false, "wait until the operation finished and return an error if it failed")
+	cmd.Flags().DurationVar(&f.timeout, "timeout", 30*time.Minute, "maximum time to wait with --wait")
+	cmd.Flags().BoolVarP(&f.yes, "yes", "y", false, "do not ask for confirmation of shoots with access restrictions")
+}
+
+// NewHibernateCmd returns a new hibernate command.
+func NewHibernateCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	return newHibernationCmd(targetReader, configReader, ioStreams, true, "hibernate [shoot]",
+		"Hibernate the targeted or given shoot, e.g. \"gardenctl hibernate my-shoot --wait\" or \"gardenctl hibernate -l purpose=dev\"")
+}
+
+// NewWakeupCmd returns a new wakeup command.
+func NewWakeupCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	return newHibernationCmd(targetReader, configReader, ioStreams, false, "wakeup [shoot]",
+		"Wake up the targeted or given shoot, e.g. \"gardenctl wakeup my-shoot --wait\" or \"gardenctl wakeup -l purpose=dev\"")
+}
+
+func newHibernationCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams, enabled bool, use, short string) *cobra.Command {
+	var flags shootChangeFlags
+	cmd := &cobra.Command{
+		Use:          use,
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("command must be in the format: %s", use)
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, name, flags.selector)
+			if err != nil {
+				return err
+			}
+			if err := confirmShootRestrictions(target, configReader, shoots, flags.yes, ioStreams); err != nil {
+				return err
+			}
+
+			out := shootOperationWriter(ioStreams.Out, shoots)
+			return forEachShoot(shoots, flags.concurrency, ioStreams.ErrOut, func(shoot *gardencorev1beta1.Shoot) error {
+				return setShootHibernation(gardenClientset, shoot, enabled, flags, out)
+			})
+		},
+	}
+	flags.addFlags(cmd)
+
+	return cmd
+}
+
+// setShootHibernation sets the desired hibernation state of the shoot and optionally waits until it is reached
+func setShootHibernation(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, enabled bool, flags shootChangeFlags, writer io.Writer) error {
+	state := "hibernated"
+	if !enabled {
+		state = "woken up"
+	}
+
+	if isHibernationEnabled(shoot) == enabled {
+		fmt.Fprintf(writer, "Shoot %s is already %s\n", shootKey(shoot), state)
+	} else {
+		patch := fmt.Sprintf(`{"spec":{"hibernation":{"enabled":%t}}}`, enabled)
+		patched, err := gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Patch(shoot.Name, types.MergePatchType, []byte(patch))
+		if err != nil {
+			return err
+		}
+		shoot = patched
+		fmt.Fprintf(writer, "Shoot %s will be %s\n", shootKey(shoot), state)
+	}
+	if !flags.wait {
+		return nil
+	}
+
+	succeeded := lastOperationSucceeded(shoot.Generation)
+	_, err := waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+		done, err := succeeded(shoot)
+		return done && shoot.Status.IsHibernated == enabled, err
+	}, flags.timeout, writer)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Shoot %s has been %s\n", shootKey(shoot), state)
+
+	return nil
+}
+
+// isHibernationEnabled returns whether the desired state of the shoot is to be hibernated
+func isHibernationEnabled(shoot *gardencorev1beta1.Shoot) bool {
+	return shoot.Spec.Hibernation != nil && shoot.Spec.Hibernation.Enabled != nil && *shoot.Spec.Hibernation.Enabled
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Hibernate command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+	)
+
+	projectNamespace := "garden-prod"
+	project := &gardencorev1beta1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+	}
+	newShoot := func(name string, labels map[string]string, status gardencorev1beta1.ShootStatus) *gardencorev1beta1.Shoot {
+		return &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: projectNamespace, Labels: labels},
+			Status:     status,
+		}
+	}
+	hibernatedStatus := gardencorev1beta1.ShootStatus{
+		IsHibernated:  true,
+		LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{
+			GardenClusters: []cmd.GardenClusterMeta{{
+				Name:               "test-garden",
+				AccessRestrictions: []cmd.AccessRestriction{{Key: "seed.gardener.cloud/eu-access", NotifyIf: true, Msg: "Do not access this EU shoot"}},
+			}},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().DoAndReturn(func() (gardencoreclientset.Interface, error) {
+			return clientSet, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("with a single shoot", func() {
+		BeforeEach(func() {
+			clientSet = gardencorefake.NewSimpleClientset(
+				project,
+				newShoot("test-shoot", nil, hibernatedStatus),
+			)
+		})
+
+		It("should hibernate the targeted shoot", func() {
+			ioStreams, _, out, _ := cmd.NewTestIOStreams()
+			command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{})
+			err := command.Execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal("Shoot garden-prod/test-shoot will be hibernated\n"))
+			shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*shoot.Spec.Hibernation.Enabled).To(BeTrue())
+		})
+
+		It("should wait until the shoot is hibernated", func() {
+			ioStreams, _, out, _ := cmd.NewTestIOStreams()
+			command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{"test-shoot", "--wait"})
+			err := command.Execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("garden-prod/test-shoot [####################] 100% Reconcile Succeeded\n"))
+			Expect(out.String()).To(HaveSuffix("Shoot garden-prod/test-shoot has been hibernated\n"))
+		})
+
+		It("should follow the progress until the shoot is woken up", func() {
+			watcher := watch.NewFake()
+			clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+			go func() {
+				defer GinkgoRecover()
+				watcher.Modify(newShoot("test-shoot", nil, gardencorev1beta1.ShootStatus{
+					IsHibernated:  true,
+					LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateProcessing, Progress: 40, Description: "Waking up"},
+				}))
+				watcher.Modify(newShoot("test-shoot", nil, gardencorev1beta1.ShootStatus{
+					LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+				}))
+			}()
+
+			ioStreams, _, out, _ := cmd.NewTestIOStreams()
+			command := cmd.NewWakeupCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{"--wait"})
+			err := command.Execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("garden-prod/test-shoot [########............]  40% Reconcile Processing: Waking up\n"))
+			Expect(out.String()).To(HaveSuffix("Shoot garden-prod/test-shoot has been woken up\n"))
+		})
+
+		It("should watch the shoot again if its resource version expired", func() {
+			watchers := []*watch.FakeWatcher{watch.NewFake(), watch.NewFake()}
+			watches := 0
+			clientSet.PrependWatchReactor("shoots", func(action k8stesting.Action) (bool, watch.Interface, error) {
+				watcher := watchers[watches]
+				watches++
+				return true, watcher, nil
+			})
+			go func() {
+				defer GinkgoRecover()
+				watchers[0].Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+				watchers[1].Modify(newShoot("test-shoot", nil, gardencorev1beta1.ShootStatus{
+					LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+				}))
+			}()
+
+			ioStreams, _, out, _ := cmd.NewTestIOStreams()
+			command := cmd.NewWakeupCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{"--wait", "--timeout", "10s"})
+			err := command.Execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(HaveSuffix("Shoot garden-prod/test-shoot has been woken up\n"))
+			Expect(watches).To(Equal(2))
+		})
+	})
+
+	It("should return an error with the last errors if the operation failed", func() {
+		taskID := "deploy-worker"
+		clientSet = gardencorefake.NewSimpleClientset(project, newShoot("test-shoot", nil, gardencorev1beta1.ShootStatus{
+			LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateFailed, Description: "Worker deployment failed"},
+			LastErrors:    []gardencorev1beta1.LastError{{Description: "quota exceeded", TaskID: &taskID, Codes: []gardencorev1beta1.ErrorCode{gardencorev1beta1.ErrorInfraQuotaExceeded}}},
+		}))
+
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"--wait"})
+		err := command.Execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Reconcile of shoot garden-prod/test-shoot failed: Worker deployment failed\n  - [ERR_INFRA_QUOTA_EXCEEDED] deploy-worker: quota exceeded"))
+	})
+
+	It("should hibernate all shoots matching the selector", func() {
+		clientSet = gardencorefake.NewSimpleClientset(
+			project,
+			newShoot("dev-1", map[string]string{"purpose": "dev"}, hibernatedStatus),
+			newShoot("dev-2", map[string]string{"purpose": "dev"}, hibernatedStatus),
+			newShoot("live", map[string]string{"purpose": "production"}, hibernatedStatus),
+		)
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"--selector", "purpose=dev", "--concurrency", "2"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("Shoot garden-prod/dev-1 will be hibernated\n"))
+		Expect(out.String()).To(ContainSubstring("Shoot garden-prod/dev-2 will be hibernated\n"))
+		live, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("live", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(live.Spec.Hibernation).To(BeNil())
+	})
+
+	Context("with access restrictions", func() {
+		BeforeEach(func() {
+			shoot := newShoot("test-shoot", nil, hibernatedStatus)
+			shoot.Spec.SeedSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"seed.gardener.cloud/eu-access": "true"}}
+			clientSet = gardencorefake.NewSimpleClientset(project, shoot)
+		})
+
+		It("should abort without confirmation", func() {
+			ioStreams, _, _, errOut := cmd.NewTestIOStreams()
+			command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{})
+			err := command.Execute()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("aborted due to access restrictions"))
+			Expect(errOut.String()).To(ContainSubstring("Do not access this EU shoot"))
+		})
+
+		It("should hibernate after confirmation", func() {
+			ioStreams, in, out, _ := cmd.NewTestIOStreams()
+			in.WriteString("yes\n")
+			command := cmd.NewHibernateCmd(targetReader, configReader, ioStreams)
+			command.SetArgs([]string{})
+			err := command.Execute()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("Shoot garden-prod/test-shoot will be hibernated\n"))
+		})
+	})
+})
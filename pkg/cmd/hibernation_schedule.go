@@ -0,0 +1,458 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/robfig/cron"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// hibernationLookback is how far back the last start and end of hibernation schedules are searched
+	hibernationLookback = 31 * 24 * time.Hour
+	// hibernationTimeFormat is the format of the times of hibernation schedules in tables
+	hibernationTimeFormat = "Mon 2006-01-02 15:04"
+
+	// expectedHibernated marks shoots which should be hibernated according to their schedules
+	expectedHibernated = "hibernated"
+	// expectedAwake marks shoots which should be awake according to their schedules
+	expectedAwake = "awake"
+)
+
+// NewHibernationCmd returns a new hibernation command.
+func NewHibernationCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "List, add and remove the hibernation schedules of the targeted shoot",
+	}
+	scheduleCmd.AddCommand(newHibernationScheduleLsCmd(targetReader, ioStreams))
+	scheduleCmd.AddCommand(newHibernationScheduleAddCmd(targetReader, configReader, ioStreams))
+	scheduleCmd.AddCommand(newHibernationScheduleRmCmd(targetReader, configReader, ioStreams))
+
+	cmd := &cobra.Command{
+		Use:   "hibernation",
+		Short: "Manage the hibernation of the targeted shoot, e.g. \"gardenctl hibernation schedule ls\"",
+	}
+	cmd.AddCommand(scheduleCmd)
+
+	return cmd
+}
+
+func newHibernationScheduleLsCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	var timezone string
+	cmd := &cobra.Command{
+		Use:          "ls",
+		Short:        "List the hibernation schedules of the targeted shoot with their next start and end in the local timezone",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: hibernation schedule ls")
+			}
+			location, err := loadTimezone(timezone)
+			if err != nil {
+				return err
+			}
+			target := targetReader.ReadTarget(pathTarget)
+			if !CheckShootIsTargeted(target) {
+				return errors.New("no shoot targeted")
+			}
+			_, shoots, err := resolveShoots(target, "", "")
+			if err != nil {
+				return err
+			}
+
+			meta, err := toShootHibernationMeta(&shoots[0], time.Now(), location)
+			if err != nil {
+				return err
+			}
+			outFormat := outputFormatOrTable(cmd)
+			if outFormat == tableOutputFormat {
+				return renderShootHibernationSchedules(meta, location, isTerminal(ioStreams.Out), ioStreams.Out)
+			}
+			return PrintoutObject(meta, ioStreams.Out, outFormat)
+		},
+	}
+	cmd.Flags().StringVar(&timezone, "timezone", "", "timezone of the listed times, e.g. Europe/Berlin, defaults to the local timezone")
+
+	return cmd
+}
+
+func newHibernationScheduleAddCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		schedule   gardencorev1beta1.HibernationSchedule
+		start, end string
+		location   string
+		yes        bool
+	)
+	cmd := &cobra.Command{
+		Use:          "add",
+		Short:        "Add a hibernation schedule to the targeted shoot, e.g. \"gardenctl hibernation schedule add --start '00 20 * * 1-5' --end '00 07 * * 1-5' --location Europe/Berlin\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: hibernation schedule add --start <cron> --end <cron> [--location <location>]")
+			}
+			if start == "" && end == "" {
+				return errors.New("at least one of --start and --end must be given")
+			}
+			for _, spec := range []string{start, end} {
+				if _, err := cron.ParseStandard(spec); spec != "" && err != nil {
+					return fmt.Errorf("invalid cron expression %q: %v", spec, err)
+				}
+			}
+			if location != "" {
+				if _, err := time.LoadLocation(location); err != nil {
+					return fmt.Errorf("invalid location %q: %v", location, err)
+				}
+				schedule.Location = &location
+			}
+			if start != "" {
+				schedule.Start = &start
+			}
+			if end != "" {
+				schedule.End = &end
+			}
+
+			return changeHibernationSchedules(targetReader, configReader, yes, ioStreams, func(shoot *gardencorev1beta1.Shoot, schedules []gardencorev1beta1.HibernationSchedule) ([]gardencorev1beta1.HibernationSchedule, error) {
+				for _, existing := range schedules {
+					if stringValue(existing.Start) == start && stringValue(existing.End) == end && stringValue(existing.Location) == location {
+						return nil, fmt.Errorf("shoot %s already has this hibernation schedule", shootKey(shoot))
+					}
+				}
+				fmt.Fprintf(ioStreams.Out, "Adding hibernation schedule %s to shoot %s\n", formatHibernationSchedule(schedule), shootKey(shoot))
+				return append(schedules, schedule), nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&start, "start", "", "cron expression at which the shoot is hibernated")
+	cmd.Flags().StringVar(&end, "end", "", "cron expression at which the shoot is woken up")
+	cmd.Flags().StringVar(&location, "location", "", "location in which the cron expressions are evaluated, e.g. Europe/Berlin, defaults to UTC")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation of shoots with access restrictions")
+
+	return cmd
+}
+
+func newHibernationScheduleRmCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:          "rm <number>",
+		Short:        "Remove the hibernation schedule with the number shown by \"gardenctl hibernation schedule ls\" from the targeted shoot",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: hibernation schedule rm <number>")
+			}
+			number, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid schedule number %q", args[0])
+			}
+
+			return changeHibernationSchedules(targetReader, configReader, yes, ioStreams, func(shoot *gardencorev1beta1.Shoot, schedules []gardencorev1beta1.HibernationSchedule) ([]gardencorev1beta1.HibernationSchedule, error) {
+				if number < 1 || number > len(schedules) {
+					return nil, fmt.Errorf("shoot %s has no hibernation schedule %d", shootKey(shoot), number)
+				}
+				fmt.Fprintf(ioStreams.Out, "Removing hibernation schedule %s from shoot %s\n", formatHibernationSchedule(schedules[number-1]), shootKey(shoot))
+				return append(append([]gardencorev1beta1.HibernationSchedule{}, schedules[:number-1]...), schedules[number:]...), nil
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation of shoots with access restrictions")
+
+	return cmd
+}
+
+// changeHibernationSchedules replaces the hibernation schedules of the targeted shoot with the changed ones. The patch
+// contains the resource version of the shoot, so that it fails if the schedules have been changed in the meantime.
+func changeHibernationSchedules(targetReader TargetReader, configReader ConfigReader, yes bool, ioStreams IOStreams,
+	change func(shoot *gardencorev1beta1.Shoot, schedules []gardencorev1beta1.HibernationSchedule) ([]gardencorev1beta1.HibernationSchedule, error)) error {
+	target := targetReader.ReadTarget(pathTarget)
+	if !CheckShootIsTargeted(target) {
+		return errors.New("no shoot targeted")
+	}
+	gardenClientset, shoots, err := resolveShoots(target, "", "")
+	if err != nil {
+		return err
+	}
+	shoot := &shoots[0]
+	if err := confirmShootRestrictions(target, configReader, shoots, yes, ioStreams); err != nil {
+		return err
+	}
+
+	var schedules []gardencorev1beta1.HibernationSchedule
+	if shoot.Spec.Hibernation != nil {
+		schedules = shoot.Spec.Hibernation.Schedules
+	}
+	changed, err := change(shoot, schedules)
+	if err != nil {
+		return err
+	}
+	return patchHibernationSchedules(gardenClientset, shoot, changed)
+}
+
+// patchHibernationSchedules sets the hibernation schedules of the shoot if it has not been changed in the meantime
+func patchHibernationSchedules(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, schedules []gardencorev1beta1.HibernationSchedule) error {
+	if schedules == nil {
+		schedules = []gardencorev1beta1.HibernationSchedule{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": shoot.ResourceVersion},
+		"spec":     map[string]interface{}{"hibernation": map[string]interface{}{"schedules": schedules}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Patch(shoot.Name, types.MergePatchType, patch)
+	return err
+}
+
+// printHibernationSchedules lists the hibernation schedules of the shoots of the targeted project, or of all shoots
+func printHibernationSchedules(target TargetInterface, timezone string, writer io.Writer, outFormat string) error {
+	location, err := loadTimezone(timezone)
+	if err != nil {
+		return err
+	}
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+	namespace, err := (&resourceResolver{target: target, gardenClientset: gardenClientset}).namespace()
+	if err != nil {
+		return err
+	}
+	list, err := gardenClientset.CoreV1beta1().Shoots(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return shootKey(&list.Items[i]) < shootKey(&list.Items[j])
+	})
+
+	now := time.Now()
+	schedules := &HibernationSchedules{Timezone: location.String()}
+	for i := range list.Items {
+		meta, err := toShootHibernationMeta(&list.Items[i], now, location)
+		if err != nil {
+			// an invalid schedule of one shoot must not hide the schedules of the others
+			schedules.Shoots = append(schedules.Shoots, ShootHibernationMeta{
+				Shoot:      shootKey(&list.Items[i]),
+				Hibernated: list.Items[i].Status.IsHibernated,
+				Warning:    err.Error(),
+			})
+			continue
+		}
+		if len(meta.Schedules) > 0 {
+			schedules.Shoots = append(schedules.Shoots, *meta)
+		}
+	}
+
+	if outFormat == tableOutputFormat {
+		return renderHibernationSchedules(schedules, isTerminal(writer), writer)
+	}
+	return PrintoutObject(schedules, writer, outFormat)
+}
+
+// toShootHibernationMeta returns the hibernation schedules of the shoot with their next start and end in the given timezone
+func toShootHibernationMeta(shoot *gardencorev1beta1.Shoot, now time.Time, timezone *time.Location) (*ShootHibernationMeta, error) {
+	meta := &ShootHibernationMeta{
+		Shoot:      shootKey(shoot),
+		Hibernated: shoot.Status.IsHibernated,
+	}
+	if shoot.Spec.Hibernation == nil {
+		return meta, nil
+	}
+
+	var nextSleep, nextWake, lastSleep, lastWake time.Time
+	for _, schedule := range shoot.Spec.Hibernation.Schedules {
+		location := time.UTC
+		if schedule.Location != nil {
+			var err error
+			if location, err = time.LoadLocation(*schedule.Location); err != nil {
+				return nil, fmt.Errorf("invalid location %q of shoot %s: %v", *schedule.Location, shootKey(shoot), err)
+			}
+		}
+
+		scheduleMeta := HibernationScheduleMeta{
+			Start:    stringValue(schedule.Start),
+			End:      stringValue(schedule.End),
+			Location: location.String(),
+		}
+		if schedule.Start != nil {
+			next, last, err := cronTimes(*schedule.Start, now.In(location))
+			if err != nil {
+				return nil, fmt.Errorf("invalid start %q of shoot %s: %v", *schedule.Start, shootKey(shoot), err)
+			}
+			scheduleMeta.NextSleep = next.In(timezone).Format(time.RFC3339)
+			nextSleep, lastSleep = earliest(nextSleep, next), latest(lastSleep, last)
+		}
+		if schedule.End != nil {
+			next, last, err := cronTimes(*schedule.End, now.In(location))
+			if err != nil {
+				return nil, fmt.Errorf("invalid end %q of shoot %s: %v", *schedule.End, shootKey(shoot), err)
+			}
+			scheduleMeta.NextWake = next.In(timezone).Format(time.RFC3339)
+			nextWake, lastWake = earliest(nextWake, next), latest(lastWake, last)
+		}
+		meta.Schedules = append(meta.Schedules, scheduleMeta)
+	}
+	if !nextSleep.IsZero() {
+		meta.NextSleep = nextSleep.In(timezone).Format(time.RFC3339)
+	}
+	if !nextWake.IsZero() {
+		meta.NextWake = nextWake.In(timezone).Format(time.RFC3339)
+	}
+
+	switch {
+	case lastSleep.IsZero() && lastWake.IsZero():
+		return meta, nil
+	case lastSleep.After(lastWake):
+		meta.Expected = expectedHibernated
+	default:
+		meta.Expected = expectedAwake
+	}
+	if meta.Expected == expectedHibernated && !meta.Hibernated {
+		meta.Warning = "running outside its hibernation window"
+	} else if meta.Expected == expectedAwake && meta.Hibernated {
+		meta.Warning = "hibernated outside its hibernation window"
+	}
+	return meta, nil
+}
+
+// cronTimes returns the next time the cron expression fires after now, and the last time it fired before now
+// within the lookback period, which is zero if it did not fire
+func cronTimes(spec string, now time.Time) (time.Time, time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	var last time.Time
+	// cron schedules can only be iterated forwards, the number of iterations is limited for schedules firing every minute
+	for t, i := schedule.Next(now.Add(-hibernationLookback)), 0; !t.IsZero() && !t.After(now) && i < 50000; t, i = schedule.Next(t), i+1 {
+		last = t
+	}
+	return schedule.Next(now), last, nil
+}
+
+// earliest returns the earlier of the times, ignoring zero times
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// latest returns the later of the times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// loadTimezone returns the location with the given name, or the local timezone if the name is empty
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", name, err)
+	}
+	return location, nil
+}
+
+// formatHibernationSchedule returns the schedule in a human readable form
+func formatHibernationSchedule(schedule gardencorev1beta1.HibernationSchedule) string {
+	location := "UTC"
+	if schedule.Location != nil {
+		location = *schedule.Location
+	}
+	return fmt.Sprintf("start %q end %q in %s", stringValue(schedule.Start), stringValue(schedule.End), location)
+}
+
+// formatHibernationTime formats a time of the hibernation metadata for tables
+func formatHibernationTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "-"
+	}
+	return t.Format(hibernationTimeFormat)
+}
+
+// renderShootHibernationSchedules renders a table of the hibernation schedules of a shoot, followed by a warning which
+// is coloured if colored is set
+func renderShootHibernationSchedules(meta *ShootHibernationMeta, timezone *time.Location, colored bool, writer io.Writer) error {
+	state := expectedAwake
+	if meta.Hibernated {
+		state = expectedHibernated
+	}
+	if len(meta.Schedules) == 0 {
+		fmt.Fprintf(writer, "Shoot %s is %s and has no hibernation schedules\n", meta.Shoot, state)
+		return nil
+	}
+
+	fmt.Fprintf(writer, "Shoot %s is %s, times in %s:\n", meta.Shoot, state, timezone)
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "#\tSTART\tEND\tLOCATION\tNEXT SLEEP\tNEXT WAKE")
+	for i, schedule := range meta.Schedules {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, valueOrDash(schedule.Start), valueOrDash(schedule.End), schedule.Location,
+			formatHibernationTime(schedule.NextSleep), formatHibernationTime(schedule.NextWake))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if meta.Warning != "" {
+		warning := fmt.Sprintf("Warning: shoot %s is %s", meta.Shoot, meta.Warning)
+		if colored {
+			warning = fmt.Sprintf(warningColor, warning)
+		}
+		fmt.Fprintln(writer, warning)
+	}
+	return nil
+}
+
+// renderHibernationSchedules renders a table of shoots with their next hibernation and wake up, highlighting shoots whose
+// state does not match their schedules
+func renderHibernationSchedules(schedules *HibernationSchedules, colored bool, writer io.Writer) error {
+	fmt.Fprintf(writer, "Times in %s:\n", schedules.Timezone)
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SHOOT\tSTATE\tSCHEDULES\tNEXT SLEEP\tNEXT WAKE\tWARNING")
+	for _, shoot := range schedules.Shoots {
+		state := expectedAwake
+		if shoot.Hibernated {
+			state = expectedHibernated
+		}
+		// tabwriter counts the escape sequences as text, so only the last column is coloured
+		warning := valueOrDash(shoot.Warning)
+		if colored && shoot.Warning != "" {
+			warning = fmt.Sprintf(warningColor, warning)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", shoot.Shoot, state, len(shoot.Schedules),
+			formatHibernationTime(shoot.NextSleep), formatHibernationTime(shoot.NextWake), warning)
+	}
+	return w.Flush()
+}
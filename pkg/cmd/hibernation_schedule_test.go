@@ -0,0 +1,204 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Hibernation schedule command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+		shoot        *gardencorev1beta1.Shoot
+	)
+
+	projectNamespace := "garden-prod"
+	// every minute is always the latest start, so the shoot is expected to be hibernated
+	everyMinute := "* * * * *"
+	evening := "00 20 * * 1-5"
+	berlin := "Europe/Berlin"
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewHibernationCmd(targetReader, configReader, ioStreams)
+		command.SetArgs(append([]string{"schedule"}, args...))
+		err := command.Execute()
+		return out.String(), err
+	}
+	getSchedules := func() []gardencorev1beta1.HibernationSchedule {
+		shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return shoot.Spec.Hibernation.Schedules
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		shoot = &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Spec: gardencorev1beta1.ShootSpec{
+				Hibernation: &gardencorev1beta1.Hibernation{
+					Schedules: []gardencorev1beta1.HibernationSchedule{
+						{Start: &evening, Location: &berlin},
+						{Start: &everyMinute},
+					},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			shoot,
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list the schedules and warn about a shoot running outside its hibernation window", func() {
+		out, err := execute("ls", "--timezone", "UTC")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(HavePrefix("Shoot garden-prod/test-shoot is awake, times in UTC:\n#     START"))
+		Expect(out).To(MatchRegexp(`\n1 +00 20 \* \* 1-5 +- +Europe/Berlin +(Mon|Tue|Wed|Thu|Fri) \S+ \S+ +-\n`))
+		Expect(out).To(MatchRegexp(`\n2 +\* \* \* \* \* +- +UTC +\w{3} \S+ \S+ +-\n`))
+		Expect(out).To(HaveSuffix("\nWarning: shoot garden-prod/test-shoot is running outside its hibernation window\n"))
+	})
+
+	Context("when the shoot is hibernated", func() {
+		BeforeEach(func() {
+			shoot.Status.IsHibernated = true
+		})
+
+		It("should not warn", func() {
+			out, err := execute("ls", "--timezone", "UTC")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(HavePrefix("Shoot garden-prod/test-shoot is hibernated, times in UTC:\n"))
+			Expect(out).NotTo(ContainSubstring("Warning"))
+		})
+	})
+
+	It("should add a schedule", func() {
+		out, err := execute("add", "--start", "00 20 * * *", "--end", "00 07 * * *", "--location", "Asia/Tokyo")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Adding hibernation schedule start \"00 20 * * *\" end \"00 07 * * *\" in Asia/Tokyo to shoot garden-prod/test-shoot\n"))
+		schedules := getSchedules()
+		Expect(schedules).To(HaveLen(3))
+		Expect(*schedules[2].Start).To(Equal("00 20 * * *"))
+		Expect(*schedules[2].End).To(Equal("00 07 * * *"))
+		Expect(*schedules[2].Location).To(Equal("Asia/Tokyo"))
+	})
+
+	It("should reject invalid cron expressions", func() {
+		_, err := execute("add", "--start", "00 25 * * *")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(`invalid cron expression "00 25 * * *"`))
+	})
+
+	It("should reject invalid locations", func() {
+		_, err := execute("add", "--start", "00 20 * * *", "--location", "Europe/Nowhere")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(`invalid location "Europe/Nowhere"`))
+	})
+
+	It("should reject duplicate schedules", func() {
+		_, err := execute("add", "--start", evening, "--location", berlin)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("shoot garden-prod/test-shoot already has this hibernation schedule"))
+	})
+
+	It("should remove a schedule by its number", func() {
+		out, err := execute("rm", "1")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Removing hibernation schedule start \"00 20 * * 1-5\" end \"\" in Europe/Berlin from shoot garden-prod/test-shoot\n"))
+		schedules := getSchedules()
+		Expect(schedules).To(HaveLen(1))
+		Expect(*schedules[0].Start).To(Equal(everyMinute))
+	})
+
+	It("should reject unknown schedule numbers", func() {
+		_, err := execute("rm", "3")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("shoot garden-prod/test-shoot has no hibernation schedule 3"))
+	})
+
+	It("should list the shoots with hibernation schedules of the targeted project", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"hibernation-schedules", "--timezone", "UTC"})
+
+		Expect(command.Execute()).To(Succeed())
+		Expect(out.String()).To(HavePrefix("Times in UTC:\nSHOOT "))
+		Expect(out.String()).To(MatchRegexp(`\ngarden-prod/test-shoot +awake +2 +\w{3} \S+ \S+ +- +running outside its hibernation window\n`))
+	})
+
+	It("should list the other shoots if the schedule of a shoot is invalid", func() {
+		nowhere := "Europe/Nowhere"
+		_, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Create(&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken-shoot", Namespace: projectNamespace},
+			Spec: gardencorev1beta1.ShootSpec{
+				Hibernation: &gardencorev1beta1.Hibernation{
+					Schedules: []gardencorev1beta1.HibernationSchedule{{Start: &evening, Location: &nowhere}},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"hibernation-schedules", "--timezone", "UTC"})
+
+		Expect(command.Execute()).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`\ngarden-prod/broken-shoot +awake +0 +- +- +invalid location "Europe/Nowhere" of shoot garden-prod/broken-shoot: .+\n`))
+		Expect(out.String()).To(MatchRegexp(`\ngarden-prod/test-shoot +awake +2 +\w{3} \S+ \S+ +- +running outside its hibernation window\n`))
+	})
+})
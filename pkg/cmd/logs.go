@@ -44,14 +44,25 @@ const (
 //flags passed to the command
 var flags *logFlags
 
+//logsPortForwarder forwards the port of Loki for --loki
+var logsPortForwarder PortForwarder
+
+//logsIOStreams are the streams the logs are printed to
+var logsIOStreams IOStreams
+
 // NewLogsCmd returns a new logs command.
-func NewLogsCmd(targetReader TargetReader) *cobra.Command {
+func NewLogsCmd(targetReader TargetReader, portForwarder PortForwarder, ioStreams IOStreams) *cobra.Command {
 	flags = newLogsFlags()
+	logsPortForwarder = portForwarder
+	logsIOStreams = ioStreams
 	cmd := &cobra.Command{
 		Use:          "logs (gardener-apiserver|gardener-controller-manager|gardener-dashboard|api|scheduler|controller-manager|etcd-operator|etcd-main[etcd backup-restore]|etcd-main-backup|etcd-events[etcd backup-restore]|addon-manager|vpn-seed|vpn-shoot|machine-controller-manager|kubernetes-dashboard|prometheus|grafana|gardenlet|tf (infra|dns|ingress)|cluster-autoscaler)",
 		Short:        "Show and optionally follow logs of given component, e.g. \"gardenctl logs api\" show api server log, \"gardenctl logs all\" download all available logs to current dir logs folder",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.query != emptyString && len(args) > 0 && args[0] == "all" {
+				return errors.New("Logs command does not support --query with all, the component only selects the namespace the query is run in")
+			}
 			err := validateArgs(targetReader, args)
 			if err != nil {
 				return err
@@ -67,6 +78,11 @@ func NewLogsCmd(targetReader TargetReader) *cobra.Command {
 	cmd.Flags().DurationVar(&flags.sinceSeconds, "since", flags.sinceSeconds, "Only return logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs. Only one of since-time / since may be used.")
 	cmd.Flags().StringVar(&flags.sinceTime, "since-time", flags.sinceTime, "Only return logs after a specific date (RFC3339). Defaults to all logs. Only one of since-time / since may be used.")
 	cmd.Flags().BoolVar(&flags.loki, "loki", flags.loki, "If the flag is set the logs are retrieved and shown from Loki, otherwise from the kubelet.")
+	cmd.Flags().StringVar(&flags.query, "query", flags.query, "LogQL query of the logs retrieved from Loki instead of the logs of the given component, e.g. '{pod_name=~\"kube-apiserver.*\"} |= \"error\"'. The component only selects the namespace of the Loki the query is run in, all is not supported. Implies --loki.")
+	cmd.Flags().BoolVarP(&flags.follow, "follow", "f", flags.follow, "Specify if the logs should be streamed.")
+	cmd.Flags().BoolVarP(&flags.previous, "previous", "p", flags.previous, "If true, print the logs for the previous instance of the container if it exists. Not supported with --loki.")
+	cmd.Flags().BoolVar(&flags.allContainers, "all-containers", flags.allContainers, "Get all containers' logs in the pods. Not supported with --loki.")
+	cmd.Flags().BoolVar(&flags.timestamps, "timestamps", flags.timestamps, "Include timestamps on each line in the log output. Loki logs always include them.")
 
 	return cmd
 }
@@ -88,6 +104,13 @@ func validateArgs(targetReader TargetReader, args []string) error {
 }
 
 func validateFlags(flags *logFlags) {
+	if flags.query != emptyString {
+		flags.loki = true
+	}
+	if flags.loki && (flags.previous || flags.allContainers) {
+		fmt.Println("Logs command does not support --previous and --all-containers with --loki")
+		os.Exit(2)
+	}
 	if flags.sinceSeconds != 0 && flags.sinceTime != emptyString {
 		fmt.Println("Logs command can not contains --since and --since-time in the same time")
 		os.Exit(2)
@@ -277,7 +300,7 @@ func logPod(targetReader TargetReader, toMatch string, toTarget string, containe
 		}
 
 	} else {
-		showLogsFromKubectl(namespace, toMatch, container)
+		showLogsFromKubelet(namespace, toMatch, container)
 	}
 }
 
@@ -322,18 +345,9 @@ func saveLogPod(targetReader TargetReader, toMatch string, toTarget string, cont
 }
 
 func saveLogsFromLoki(namespace, toMatch, container string) {
-	args := BuildLokiCommandArgs(KUBECONFIG, namespace, toMatch, container, flags.tail, flags.sinceSeconds)
-	cmdResult := "kubectl " + strings.Join(args, " ")
-	output, err := ExecCmdReturnOutput("bash", "-c", cmdResult)
+	response, err := fetchLogsFromLoki(logsPortForwarder, namespace, lokiQueryOf(toMatch, container), flags.tail, flags.sinceSeconds)
 	checkError(err)
 
-	byteOutput := []byte(output)
-	var response logResponseLoki
-	err = json.Unmarshal(byteOutput, &response)
-	checkError(err)
-	fmt.Println("the response is")
-	fmt.Println(response)
-
 	fileName := "./logs/"
 	fileName += namespace + "_" + toMatch
 	if container != emptyString {
@@ -344,22 +358,23 @@ func saveLogsFromLoki(namespace, toMatch, container string) {
 	f, err := os.Create(fileName)
 	checkError(err)
 	defer f.Close()
-	_, err = f.WriteString(fmt.Sprintf("%v", response))
+	_, err = f.WriteString(response.String())
 	checkError(err)
 	err = f.Sync()
 	checkError(err)
 }
 
-func showLogsFromKubectl(namespace, toMatch, container string) {
-	pods, err := Client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
-	checkError(err)
-	for _, pod := range pods.Items {
-		if strings.Contains(pod.Name, toMatch) {
-			output, err := ExecCmdReturnOutput("kubectl", BuildLogCommandArgs(KUBECONFIG, namespace, pod.Name, container, flags.tail, flags.sinceSeconds)...)
-			checkError(err)
-			fmt.Println(output)
-		}
+func showLogsFromKubelet(namespace, toMatch, container string) {
+	options := LogsOptions{
+		Follow:        flags.follow,
+		Previous:      flags.previous,
+		AllContainers: flags.allContainers,
+		Timestamps:    flags.timestamps,
+		Since:         flags.sinceSeconds,
+		Tail:          flags.tail,
 	}
+	err := StreamLogs(Client, namespace, toMatch, container, options, logsIOStreams)
+	checkError(err)
 }
 
 func saveLogsFromKubectl(namespace, toMatch, container string) {
@@ -382,17 +397,16 @@ func saveLogsFromKubectl(namespace, toMatch, container string) {
 }
 
 func showLogsFromLoki(namespace, toMatch, container string) {
-	output, err := ExecCmdReturnOutput("kubectl", BuildLokiCommandArgs(KUBECONFIG, namespace, toMatch, container, flags.tail, flags.sinceSeconds)...)
-	checkError(err)
-
-	byteOutput := []byte(output)
-	var response logResponseLoki
-	err = json.Unmarshal(byteOutput, &response)
+	err := ShowLogsFromLoki(logsPortForwarder, namespace, lokiQueryOf(toMatch, container), flags.tail, flags.sinceSeconds, flags.follow, logsIOStreams)
 	checkError(err)
+}
 
-	fmt.Println(response)
-	fmt.Println("COMMAND: ", "kubectl", BuildLokiCommandArgs(KUBECONFIG, namespace, toMatch, container, flags.tail, flags.sinceSeconds))
-	fmt.Println("KRIS LENGTH: ", len(response.Data.Result))
+// lokiQueryOf returns the LogQL query given with --query or otherwise the query of the logs of the pod and container
+func lokiQueryOf(toMatch, container string) string {
+	if flags.query != emptyString {
+		return flags.query
+	}
+	return BuildLokiQuery(toMatch, container)
 }
 
 //BuildLogCommandArgs build kubectl command to get logs
@@ -419,52 +433,12 @@ func BuildLogCommandArgs(kubeconfig string, namespace, podName, container string
 	return args
 }
 
-//BuildLokiCommandArgs build kubect command to get logs from loki
-//https://github.com/gardener/gardener/blob/master/docs/usage/logging.md
-//Loki multi-tenant is enabled so it's required to pass 'X-Scope-OrgID' header
-func BuildLokiCommandArgs(kubeconfig string, namespace, podName, container string, tail int64, sinceSeconds time.Duration) []string {
-	args := []string{
-		"--kubeconfig=" + kubeconfig,
-		"exec",
-		"loki-0",
-		"-n",
-		namespace,
-		"--",
-		"wget",
-		"--header",
-		"X-Scope-OrgID: operator",
-		"http://localhost:3100/loki/api/v1/query_range",
-		"-O-",
-		"--post-data",
-	}
-
-	lokiQuery := fmt.Sprintf("{pod_name=~\"%s.*\"}", podName)
-	command := fmt.Sprintf("query=%s", lokiQuery)
-
-	if container != emptyString {
-		command += fmt.Sprintf("&&query={container_name=~\"%s.*\"", container)
-	}
-	if tail != 0 {
-		command += fmt.Sprintf("&&limit=%d", tail)
-	}
-	if sinceSeconds == 0 {
-		sinceSeconds = fourteenDaysInSeconds * time.Second
-	}
-	sinceNanoSec := sinceSeconds.Nanoseconds()
-	now := time.Now().UnixNano()
-
-	command += fmt.Sprintf("&&start=%d&&end=%d", now-sinceNanoSec, now)
-
-	args = append(args, command)
-	return args
-}
-
 // logPodGarden print logfiles for garden pods
 func logPodGarden(toMatch, namespace string) {
 	var err error
 	Client, err = clientToTarget("garden")
 	checkError(err)
-	showLogsFromKubectl(namespace, toMatch, emptyString)
+	showLogsFromKubelet(namespace, toMatch, emptyString)
 }
 
 // logPodSeed print logfiles for Seed pods
@@ -473,9 +447,9 @@ func logPodSeed(toMatch, namespace string, container string) {
 	Client, err = clientToTarget(TargetKindSeed)
 	checkError(err)
 	if container != emptyString {
-		showLogsFromKubectl(namespace, toMatch, container)
+		showLogsFromKubelet(namespace, toMatch, container)
 	} else {
-		showLogsFromKubectl(namespace, toMatch, emptyString)
+		showLogsFromKubelet(namespace, toMatch, emptyString)
 	}
 }
 
@@ -496,9 +470,9 @@ func logPodShoot(toMatch, namespace string, container string) {
 	Client, err = clientToTarget(TargetKindShoot)
 	checkError(err)
 	if container != emptyString {
-		showLogsFromKubectl(namespace, toMatch, container)
+		showLogsFromKubelet(namespace, toMatch, container)
 	} else {
-		showLogsFromKubectl(namespace, toMatch, emptyString)
+		showLogsFromKubelet(namespace, toMatch, emptyString)
 	}
 }
 
@@ -593,7 +567,7 @@ func logPodWhileControlPlaneTargeted(targetReader TargetReader, toMatch string,
 		}
 
 	} else {
-		showLogsFromKubectl(namespace, toMatch, container)
+		showLogsFromKubelet(namespace, toMatch, container)
 	}
 }
 
@@ -932,10 +906,15 @@ func logsIngress(str string) {
 }
 
 type logFlags struct {
-	sinceSeconds time.Duration
-	sinceTime    string
-	tail         int64
-	loki         bool
+	sinceSeconds  time.Duration
+	sinceTime     string
+	tail          int64
+	loki          bool
+	query         string
+	follow        bool
+	previous      bool
+	allContainers bool
+	timestamps    bool
 }
 
 func newLogsFlags() *logFlags {
@@ -946,17 +925,21 @@ func newLogsFlags() *logFlags {
 
 type logResponseLoki struct {
 	Data struct {
-		Result []struct {
-			Stream struct {
-				ContainerName string `json:"container_name"`
-				DockerID      string `json:"docker_id"`
-				PodName       string `json:"pod_name"`
-			} `json:"stream"`
-			Values [][]string `json:"values"`
-		} `json:"result"`
+		Result []lokiStream `json:"result"`
 	} `json:"data"`
 }
 
+type lokiStream struct {
+	Stream lokiStreamLabels `json:"stream"`
+	Values [][]string       `json:"values"`
+}
+
+type lokiStreamLabels struct {
+	ContainerName string `json:"container_name"`
+	DockerID      string `json:"docker_id"`
+	PodName       string `json:"pod_name"`
+}
+
 type logMessage struct {
 	Log      string `json:"log"`
 	Severity string `json:"severity"`
@@ -999,7 +982,6 @@ func (response logResponseLoki) String() string {
 	results := response.Data.Result
 	var allLogs strings.Builder
 	pairs := make(map[pair][]dockerIds)
-	valuesDelimeter := strings.Repeat("=", getTerminalWidth()) + "\n"
 
 	for resultIndex := len(results) - 1; resultIndex >= 0; resultIndex-- {
 		currContainer := results[resultIndex].Stream.ContainerName
@@ -1028,7 +1010,7 @@ func (response logResponseLoki) String() string {
 			return container[i].logs[0].time.Before(container[j].logs[0].time)
 		})
 		for _, dockerID := range container {
-			allLogs.WriteString(fmt.Sprintf("%sPod Name: %s, Container Name: %s, DockerID: %s\n%s", valuesDelimeter, pair.podName, pair.containerName, dockerID.name, valuesDelimeter))
+			allLogs.WriteString(lokiStreamHeader(lokiStreamLabels{PodName: pair.podName, ContainerName: pair.containerName, DockerID: dockerID.name}))
 			//Sort logs in the stream
 			sort.Slice(dockerID.logs, func(i, j int) bool {
 				return dockerID.logs[i].time.Before(dockerID.logs[j].time)
@@ -1042,6 +1024,12 @@ func (response logResponseLoki) String() string {
 	return allLogs.String()
 }
 
+// lokiStreamHeader returns the header printed before the logs of the stream
+func lokiStreamHeader(labels lokiStreamLabels) string {
+	valuesDelimeter := strings.Repeat("=", getTerminalWidth()) + "\n"
+	return fmt.Sprintf("%sPod Name: %s, Container Name: %s, DockerID: %s\n%s", valuesDelimeter, labels.PodName, labels.ContainerName, labels.DockerID, valuesDelimeter)
+}
+
 func parseTimeInRFC(unixTime string) time.Time {
 	intTime, err := strconv.ParseInt(unixTime, 10, 64)
 	checkError(err)
@@ -1052,8 +1040,10 @@ func parseTimeInRFC(unixTime string) time.Time {
 func parseLogMessage(logMsg string) logMessage {
 	byteOutput := []byte(logMsg)
 	var log logMessage
-	err := json.Unmarshal(byteOutput, &log)
-	checkError(err)
+	if err := json.Unmarshal(byteOutput, &log); err != nil {
+		// the line is not structured, e.g. if it has been formatted by the LogQL query
+		return logMessage{Log: logMsg}
+	}
 
 	return log
 }
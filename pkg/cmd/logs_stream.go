@@ -0,0 +1,220 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logColors are the colors the pods and containers are printed in before their log lines
+var logColors = []string{
+	"\033[32m%s\033[0m",
+	"\033[33m%s\033[0m",
+	"\033[34m%s\033[0m",
+	"\033[35m%s\033[0m",
+	"\033[36m%s\033[0m",
+	"\033[92m%s\033[0m",
+	"\033[93m%s\033[0m",
+	"\033[94m%s\033[0m",
+	"\033[95m%s\033[0m",
+	"\033[96m%s\033[0m",
+}
+
+// LogsOptions are the options of the logs streamed from the kubelet.
+type LogsOptions struct {
+	// Follow streams new logs until gardenctl is interrupted
+	Follow bool
+	// Previous streams the logs of the previous instances of the containers
+	Previous bool
+	// AllContainers streams the logs of all containers of the pods instead of the given or the first one
+	AllContainers bool
+	// Timestamps prefixes each line with its timestamp
+	Timestamps bool
+	// Since only streams logs newer than the duration if it is set
+	Since time.Duration
+	// Tail only streams this number of the most recent lines if it is not negative
+	Tail int64
+}
+
+// logStream is a container whose logs are streamed
+type logStream struct {
+	pod       string
+	container string
+}
+
+// StreamLogs streams the logs of the containers of the pods in the namespace whose names contain toMatch concurrently,
+// each line prefixed with its pod and container, until all streams end or gardenctl is interrupted.
+func StreamLogs(client kubernetes.Interface, namespace, toMatch, container string, options LogsOptions, ioStreams IOStreams) error {
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var streams []logStream
+	for _, pod := range pods.Items {
+		if !strings.Contains(pod.Name, toMatch) {
+			continue
+		}
+		for _, name := range logContainers(pod, container, options.AllContainers) {
+			streams = append(streams, logStream{pod: pod.Name, container: name})
+		}
+	}
+	if len(streams) == 0 {
+		if container != emptyString {
+			return fmt.Errorf("no pod matching %s with container %s found in namespace %s", toMatch, container, namespace)
+		}
+		return fmt.Errorf("no pod matching %s found in namespace %s", toMatch, namespace)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		failed int
+		out    = &syncWriter{writer: ioStreams.Out}
+		errOut = &syncWriter{writer: ioStreams.ErrOut}
+		color  = isTerminal(ioStreams.Out)
+	)
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream logStream) {
+			defer wg.Done()
+			err := streamContainerLogs(ctx, client, namespace, stream, options, logPrefix(stream, color), out)
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(errOut, "Warning: logs of %s/%s are unavailable: %v\n", stream.pod, stream.container, err)
+				mutex.Lock()
+				failed++
+				mutex.Unlock()
+			}
+		}(stream)
+	}
+	wg.Wait()
+
+	if failed == len(streams) {
+		return errors.New("logs could not be read from any container")
+	}
+	return nil
+}
+
+// logContainers returns the containers of the pod whose logs are streamed: the given one if the pod has it, all if
+// allContainers is set or otherwise the first one
+func logContainers(pod corev1.Pod, container string, allContainers bool) []string {
+	if container != emptyString {
+		if hasContainer(pod.Spec.InitContainers, container) || hasContainer(pod.Spec.Containers, container) {
+			return []string{container}
+		}
+		return nil
+	}
+	var containers []string
+	if allContainers {
+		for _, initContainer := range pod.Spec.InitContainers {
+			containers = append(containers, initContainer.Name)
+		}
+		for _, c := range pod.Spec.Containers {
+			containers = append(containers, c.Name)
+		}
+		return containers
+	}
+	if len(pod.Spec.Containers) > 0 {
+		containers = append(containers, pod.Spec.Containers[0].Name)
+	}
+	return containers
+}
+
+// streamContainerLogs writes the log lines of the container with the prefix to the writer until the stream ends or the
+// context is cancelled
+func streamContainerLogs(ctx context.Context, client kubernetes.Interface, namespace string, stream logStream, options LogsOptions, prefix string, writer io.Writer) error {
+	logOptions := &corev1.PodLogOptions{
+		Container:  stream.container,
+		Follow:     options.Follow,
+		Previous:   options.Previous,
+		Timestamps: options.Timestamps,
+	}
+	if options.Since > 0 {
+		sinceSeconds := int64(math.Ceil(options.Since.Seconds()))
+		logOptions.SinceSeconds = &sinceSeconds
+	}
+	if options.Tail >= 0 {
+		tail := options.Tail
+		logOptions.TailLines = &tail
+	}
+
+	reader, err := client.CoreV1().Pods(namespace).GetLogs(stream.pod, logOptions).Context(ctx).Stream()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	lines := bufio.NewReader(reader)
+	for {
+		line, err := lines.ReadString('\n')
+		if line != emptyString {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			if _, err := io.WriteString(writer, prefix+line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// logPrefix returns the prefix of the log lines of the stream, colored by the names of its pod and container if color
+// is set
+func logPrefix(stream logStream, color bool) string {
+	if !color {
+		return stream.pod + " " + stream.container + " "
+	}
+	return fmt.Sprintf(logColor(stream.pod), stream.pod) + " " + fmt.Sprintf(logColor(stream.container), stream.container) + " "
+}
+
+// logColor returns the color of the name, which is the same for each invocation of gardenctl
+func logColor(name string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	return logColors[hash.Sum32()%uint32(len(logColors))]
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("Logs streaming", func() {
+	var (
+		server   *httptest.Server
+		client   kubernetes.Interface
+		mutex    sync.Mutex
+		requests map[string]url.Values
+		options  cmd.LogsOptions
+	)
+
+	namespace := "shoot--prod--test-shoot"
+	pod := func(name string, initContainers []string, containers ...string) corev1.Pod {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		for _, container := range initContainers {
+			pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{Name: container})
+		}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+		}
+		return pod
+	}
+	lines := func(out string) []string {
+		return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	}
+
+	BeforeEach(func() {
+		requests = map[string]url.Values{}
+		options = cmd.LogsOptions{Tail: -1}
+		pods := &corev1.PodList{
+			TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+			Items: []corev1.Pod{
+				pod("kube-apiserver-1", nil, "kube-apiserver", "vpn-seed"),
+				pod("kube-apiserver-2", []string{"init"}, "kube-apiserver", "vpn-seed"),
+				pod("kube-controller-manager-1", nil, "kube-controller-manager"),
+			},
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefix := "/api/v1/namespaces/" + namespace + "/pods"
+			switch {
+			case r.URL.Path == prefix:
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(pods)).To(Succeed())
+			case strings.HasPrefix(r.URL.Path, prefix+"/") && strings.HasSuffix(r.URL.Path, "/log"):
+				name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix+"/"), "/log")
+				container := r.URL.Query().Get("container")
+				mutex.Lock()
+				requests[name+"/"+container] = r.URL.Query()
+				mutex.Unlock()
+				if container == "vpn-seed" && r.URL.Query().Get("previous") == "true" {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					Expect(json.NewEncoder(w).Encode(&metav1.Status{
+						TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+						Status:   metav1.StatusFailure,
+						Message:  fmt.Sprintf("previous terminated container %q in pod %q not found", container, name),
+						Reason:   metav1.StatusReasonBadRequest,
+						Code:     http.StatusBadRequest,
+					})).To(Succeed())
+					return
+				}
+				for i := 1; i <= 2; i++ {
+					fmt.Fprintf(w, "%s line %d\n", container, i)
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, "without newline")
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		var err error
+		client, err = kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should stream the logs of the first container of the matching pods with prefixes", func() {
+		options.Since = 90 * time.Second
+		options.Tail = 10
+		options.Timestamps = true
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-apiserver", "", options, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines(out.String())).To(ConsistOf(
+			"kube-apiserver-1 kube-apiserver kube-apiserver line 1",
+			"kube-apiserver-1 kube-apiserver kube-apiserver line 2",
+			"kube-apiserver-1 kube-apiserver without newline",
+			"kube-apiserver-2 kube-apiserver kube-apiserver line 1",
+			"kube-apiserver-2 kube-apiserver kube-apiserver line 2",
+			"kube-apiserver-2 kube-apiserver without newline",
+		))
+		Expect(out.String()).To(ContainSubstring("kube-apiserver-1 kube-apiserver kube-apiserver line 1\nkube-apiserver-1 kube-apiserver kube-apiserver line 2\n"))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests["kube-apiserver-1/kube-apiserver"].Get("sinceSeconds")).To(Equal("90"))
+		Expect(requests["kube-apiserver-1/kube-apiserver"].Get("tailLines")).To(Equal("10"))
+		Expect(requests["kube-apiserver-1/kube-apiserver"].Get("timestamps")).To(Equal("true"))
+		Expect(requests["kube-apiserver-1/kube-apiserver"].Get("follow")).To(BeEmpty())
+	})
+
+	It("should follow the logs of the given container", func() {
+		options.Follow = true
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-", "kube-controller-manager", options, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines(out.String())).To(Equal([]string{
+			"kube-controller-manager-1 kube-controller-manager kube-controller-manager line 1",
+			"kube-controller-manager-1 kube-controller-manager kube-controller-manager line 2",
+			"kube-controller-manager-1 kube-controller-manager without newline",
+		}))
+		Expect(requests).To(HaveKey("kube-controller-manager-1/kube-controller-manager"))
+		Expect(requests["kube-controller-manager-1/kube-controller-manager"].Get("follow")).To(Equal("true"))
+		Expect(requests["kube-controller-manager-1/kube-controller-manager"].Get("tailLines")).To(BeEmpty())
+	})
+
+	It("should stream the logs of all containers including the init containers", func() {
+		options.AllContainers = true
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-apiserver-2", "", options, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(HaveLen(3))
+		Expect(requests).To(HaveKey("kube-apiserver-2/init"))
+		Expect(requests).To(HaveKey("kube-apiserver-2/kube-apiserver"))
+		Expect(requests).To(HaveKey("kube-apiserver-2/vpn-seed"))
+	})
+
+	It("should warn about containers whose logs are unavailable", func() {
+		options.Previous = true
+		options.AllContainers = true
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-apiserver-1", "", options, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("kube-apiserver-1 kube-apiserver kube-apiserver line 1\n"))
+		Expect(errOut.String()).To(Equal("Warning: logs of kube-apiserver-1/vpn-seed are unavailable: previous terminated container \"vpn-seed\" in pod \"kube-apiserver-1\" not found\n"))
+		Expect(requests["kube-apiserver-1/kube-apiserver"].Get("previous")).To(Equal("true"))
+	})
+
+	It("should return an error if the logs of no container are available", func() {
+		options.Previous = true
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-apiserver", "vpn-seed", options, ioStreams)
+
+		Expect(err).To(MatchError("logs could not be read from any container"))
+	})
+
+	It("should return an error if no pod matches", func() {
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+
+		err := cmd.StreamLogs(client, namespace, "kube-apiserver", "etcd", options, ioStreams)
+
+		Expect(err).To(MatchError("no pod matching kube-apiserver with container etcd found in namespace " + namespace))
+	})
+})
@@ -20,8 +20,8 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/cobra"
 
-	"regexp"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -52,7 +52,8 @@ var _ = Describe("Logs and kubecmd command", func() {
 
 	Context("with < 1 args", func() {
 		It("should return error", func() {
-			command = cmd.NewLogsCmd(targetReader)
+			ioStreams, _, _, _ := cmd.NewTestIOStreams()
+			command = cmd.NewLogsCmd(targetReader, mockcmd.NewMockPortForwarder(ctrl), ioStreams)
 			err := execute(command, []string{})
 
 			Expect(err).To(HaveOccurred())
@@ -60,6 +61,16 @@ var _ = Describe("Logs and kubecmd command", func() {
 		})
 	})
 
+	Context("with --query", func() {
+		It("should return error for all components", func() {
+			ioStreams, _, _, _ := cmd.NewTestIOStreams()
+			command = cmd.NewLogsCmd(targetReader, mockcmd.NewMockPortForwarder(ctrl), ioStreams)
+			err := execute(command, []string{"all", "--query", `{pod_name=~"kube-apiserver.*"}`})
+
+			Expect(err).To(MatchError("Logs command does not support --query with all, the component only selects the namespace the query is run in"))
+		})
+	})
+
 	Context("kubectl commands", func() {
 
 		It("should build kubectl command", func() {
@@ -70,20 +81,15 @@ var _ = Describe("Logs and kubecmd command", func() {
 			Expect(expected).To(Equal(join))
 		})
 
-		It("should build kubectl command", func() {
+		It("should build the loki query of the pod and container", func() {
+			Expect(cmd.BuildLokiQuery("nginx-pod", "")).To(Equal(`{pod_name=~"nginx-pod.*"}`))
+			Expect(cmd.BuildLokiQuery("nginx-pod", "my.container")).To(Equal(`{pod_name=~"nginx-pod.*", container_name=~"my\\.container.*"}`))
+		})
+
+		It("should build the encoded loki query_range parameters", func() {
+			params := cmd.BuildLokiQueryRangeParams(`{pod_name=~"nginx-pod.*", container_name=~"mycontainer.*"} |= "a&b"`, time.Unix(0, 101010), time.Unix(0, 202020), 200)
 
-			//test `normalizeTimestamp` first - replace timestamp with some predefined values
-			expected := `--kubeconfig=/path/to/configfile exec loki-0 -n myns -- wget --header X-Scope-OrgID: operator http://localhost:3100/loki/api/v1/query_range -O- --post-data query={pod_name=~"nginx-pod.*"}&&query={container_name=~"mycontainer.*"&&limit=200&&start=1603184413805314000&&end=1604394013805314000`
-			expectedNorm := `--kubeconfig=/path/to/configfile exec loki-0 -n myns -- wget --header X-Scope-OrgID: operator http://localhost:3100/loki/api/v1/query_range -O- --post-data query={pod_name=~"nginx-pod.*"}&&query={container_name=~"mycontainer.*"&&limit=200&&start=101010&&end=202020`
-			norm := normalizeTimestamp(expected)
-			Expect(expectedNorm).To(Equal(norm))
-
-			//test real command builder
-			args := cmd.BuildLokiCommandArgs("/path/to/configfile", "myns", "nginx-pod", "mycontainer", 200, 0)
-			command := strings.Join(args, " ")
-			normCommand := normalizeTimestamp(command)
-			Expect(expectedNorm).To(Equal(normCommand))
-			Expect(len(args)).To(Equal(13))
+			Expect(params.Encode()).To(Equal("direction=backward&end=202020&limit=200&query=%7Bpod_name%3D~%22nginx-pod.%2A%22%2C+container_name%3D~%22mycontainer.%2A%22%7D+%7C%3D+%22a%26b%22&start=101010"))
 		})
 	})
 
@@ -99,13 +105,3 @@ var _ = Describe("Logs and kubecmd command", func() {
 		})
 	})
 })
-
-func normalizeTimestamp(command string) string {
-	var re = regexp.MustCompile(`(?m).*start=(\d+)&&end=(\d+)`)
-
-	timestamps := re.FindAllStringSubmatch(command, -1)[0]
-	command = strings.Replace(command, timestamps[1], "101010", 1)
-	command = strings.Replace(command, timestamps[2], "202020", 1)
-
-	return command
-}
@@ -0,0 +1,300 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// lokiPod is the name of the Loki pod in the control plane namespace of a shoot
+	lokiPod = "loki-0"
+	// lokiPort is the port of the HTTP API of Loki
+	lokiPort = 3100
+	// lokiOrgID is the tenant the logs of the control plane are stored for. Loki multi-tenant is enabled so it's
+	// required to pass it in the 'X-Scope-OrgID' header, see https://github.com/gardener/gardener/blob/master/docs/usage/logging.md
+	lokiOrgID = "operator"
+	// lokiPageSize is the number of log entries requested at once, Loki rejects queries of more than 5000 entries by default
+	lokiPageSize = 5000
+)
+
+// lokiClient calls the HTTP API of Loki
+type lokiClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// lokiTailResponse is a message of the tail endpoint of Loki
+type lokiTailResponse struct {
+	Streams        []lokiStream `json:"streams"`
+	DroppedEntries []struct {
+		Timestamp string `json:"timestamp"`
+	} `json:"dropped_entries"`
+}
+
+// ShowLogsFromLoki prints at most limit of the newest logs within since which match the LogQL query from the Loki in
+// the namespace of the seed. If follow is set, new logs are printed until the tail ends or gardenctl is interrupted.
+func ShowLogsFromLoki(portForwarder PortForwarder, namespace, query string, limit int64, since time.Duration, follow bool, ioStreams IOStreams) error {
+	client, stop, err := newLokiClient(portForwarder, namespace)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	end := time.Now()
+	response, err := client.queryRange(query, end.Add(-lokiSince(since)), end, lokiLimit(limit))
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(ioStreams.Out, response)
+	if !follow {
+		return nil
+	}
+	return client.tail(query, end, ioStreams)
+}
+
+// fetchLogsFromLoki returns at most limit of the newest logs within since which match the LogQL query from the Loki in
+// the namespace of the seed
+func fetchLogsFromLoki(portForwarder PortForwarder, namespace, query string, limit int64, since time.Duration) (*logResponseLoki, error) {
+	client, stop, err := newLokiClient(portForwarder, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	end := time.Now()
+	return client.queryRange(query, end.Add(-lokiSince(since)), end, lokiLimit(limit))
+}
+
+// newLokiClient forwards a local port to the Loki in the namespace of the seed and returns a client for it. The
+// returned function stops forwarding.
+func newLokiClient(portForwarder PortForwarder, namespace string) (*lokiClient, func(), error) {
+	port, stop, err := portForwarder.ForwardPort(TargetKindSeed, namespace, lokiPod, lokiPort)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &lokiClient{baseURL: fmt.Sprintf("http://127.0.0.1:%d", port), client: &http.Client{Timeout: time.Minute}}, stop, nil
+}
+
+// lokiSince returns the duration logs are queried for, the last 14 days if it is not set
+func lokiSince(since time.Duration) time.Duration {
+	if since <= 0 {
+		return fourteenDaysInSeconds * time.Second
+	}
+	return since
+}
+
+// lokiLimit returns the maximum number of log entries which are queried
+func lokiLimit(limit int64) int64 {
+	if limit <= 0 || limit > maxLokiLogs {
+		return maxLokiLogs
+	}
+	return limit
+}
+
+// BuildLokiQuery builds the LogQL query of the logs of the pods whose names start with podName and, if it is set, of
+// the containers whose names start with container
+func BuildLokiQuery(podName, container string) string {
+	selectors := []string{"pod_name=~" + strconv.Quote(regexp.QuoteMeta(podName)+".*")}
+	if container != emptyString {
+		selectors = append(selectors, "container_name=~"+strconv.Quote(regexp.QuoteMeta(container)+".*"))
+	}
+	return "{" + strings.Join(selectors, ", ") + "}"
+}
+
+// BuildLokiQueryRangeParams builds the parameters of the Loki query_range request of at most limit of the newest logs
+// which match the LogQL query between start and end
+func BuildLokiQueryRangeParams(query string, start, end time.Time, limit int64) url.Values {
+	return url.Values{
+		"query":     {query},
+		"start":     {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(end.UnixNano(), 10)},
+		"limit":     {strconv.FormatInt(limit, 10)},
+		"direction": {"backward"},
+	}
+}
+
+// get calls the path of the Loki API with the parameters and decodes the JSON response into the value
+func (c *lokiClient) get(path string, params url.Values, value interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Scope-OrgID", lokiOrgID)
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", path, response.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, value)
+}
+
+// queryRange returns at most limit of the newest log entries which match the LogQL query between start and end. The
+// entries are requested in pages of lokiPageSize, each ending at the oldest entry of the previous one.
+func (c *lokiClient) queryRange(query string, start, end time.Time, limit int64) (*logResponseLoki, error) {
+	response := &logResponseLoki{}
+	// seen are the entries of the previous page at its oldest timestamp, which is requested again
+	seen := map[string]bool{}
+	for remaining := limit; remaining > 0; {
+		pageSize := remaining + int64(len(seen))
+		if pageSize > lokiPageSize {
+			pageSize = lokiPageSize
+		}
+		var page struct {
+			Data struct {
+				ResultType string          `json:"resultType"`
+				Result     json.RawMessage `json:"result"`
+			} `json:"data"`
+		}
+		if err := c.get("/loki/api/v1/query_range", BuildLokiQueryRangeParams(query, start, end, pageSize), &page); err != nil {
+			return nil, err
+		}
+		if page.Data.ResultType != "streams" {
+			return nil, fmt.Errorf("only log queries are supported, the query returned a result of type %s", page.Data.ResultType)
+		}
+		var streams []lokiStream
+		if err := json.Unmarshal(page.Data.Result, &streams); err != nil {
+			return nil, err
+		}
+
+		var (
+			received int64
+			added    int64
+			oldest   int64
+			boundary = map[string]bool{}
+		)
+		for _, stream := range streams {
+			values := [][]string{}
+			for _, value := range stream.Values {
+				if len(value) != 2 {
+					return nil, fmt.Errorf("invalid log entry %v", value)
+				}
+				timestamp, err := strconv.ParseInt(value[0], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid timestamp of log entry: %v", err)
+				}
+				received++
+				key := fmt.Sprintf("%v\x00%s\x00%s", stream.Stream, value[0], value[1])
+				if oldest == 0 || timestamp < oldest {
+					oldest, boundary = timestamp, map[string]bool{}
+				}
+				if timestamp == oldest {
+					boundary[key] = true
+				}
+				if seen[key] || added+int64(len(values)) >= remaining {
+					continue
+				}
+				values = append(values, value)
+			}
+			if len(values) > 0 {
+				added += int64(len(values))
+				response.Data.Result = append(response.Data.Result, lokiStream{Stream: stream.Stream, Values: values})
+			}
+		}
+		if received < pageSize || added == 0 {
+			break
+		}
+		remaining -= added
+		// the end is exclusive, the entries at the oldest timestamp which did not fit into the page are requested again
+		end, seen = time.Unix(0, oldest+1), boundary
+	}
+	return response, nil
+}
+
+// tail prints the log entries which match the LogQL query from start on as they are received until the tail ends or
+// gardenctl is interrupted
+func (c *lokiClient) tail(query string, start time.Time, ioStreams IOStreams) error {
+	params := url.Values{
+		"query":     {query},
+		"start":     {strconv.FormatInt(start.UnixNano(), 10)},
+		"limit":     {strconv.Itoa(lokiPageSize)},
+		"delay_for": {"0"},
+	}
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(c.baseURL, "http")+"/loki/api/v1/tail?"+params.Encode(), c.baseURL)
+	if err != nil {
+		return err
+	}
+	config.Header.Set("X-Scope-OrgID", lokiOrgID)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return fmt.Errorf("cannot tail the logs: %v", err)
+	}
+
+	interrupted := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			close(interrupted)
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	var last lokiStreamLabels
+	for {
+		var message lokiTailResponse
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			select {
+			case <-interrupted:
+				return nil
+			default:
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("cannot tail the logs: %v", err)
+		}
+		if len(message.DroppedEntries) > 0 {
+			fmt.Fprintf(ioStreams.ErrOut, "Warning: %d log entries have been dropped by Loki\n", len(message.DroppedEntries))
+		}
+		for _, stream := range message.Streams {
+			for _, value := range stream.Values {
+				if len(value) != 2 {
+					continue
+				}
+				if stream.Stream != last {
+					fmt.Fprint(ioStreams.Out, lokiStreamHeader(stream.Stream))
+					last = stream.Stream
+				}
+				fmt.Fprint(ioStreams.Out, parseTimeInRFC(value[0]).String()+parseLogMessage(value[1]).String())
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+)
+
+var _ = Describe("Loki client", func() {
+	var (
+		ctrl          *gomock.Controller
+		portForwarder *mockcmd.MockPortForwarder
+		server        *httptest.Server
+		mutex         sync.Mutex
+		entries       []int64
+		requests      []url.Values
+		resultType    string
+		tailStart     string
+	)
+
+	query := `{pod_name=~"kube-apiserver.*"} |= "line"`
+	stream := map[string]string{"pod_name": "kube-apiserver-0", "container_name": "kube-apiserver", "docker_id": "abc"}
+	line := func(i int) string {
+		return fmt.Sprintf(`{"log":"line-%d","severity":"INFO"}`, i)
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		portForwarder = mockcmd.NewMockPortForwarder(ctrl)
+		requests = nil
+		resultType = "streams"
+		tailStart = ""
+		// two entries are logged at each timestamp, the newest ones within the last seconds
+		now := time.Now().UnixNano()
+		entries = make([]int64, 6000)
+		for i := range entries {
+			entries[i] = now - int64(len(entries)-i)/2*int64(time.Millisecond)
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/loki/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if r.Header.Get("X-Scope-OrgID") != "operator" {
+				http.Error(w, "no org id", http.StatusUnauthorized)
+				return
+			}
+			params := r.URL.Query()
+			requests = append(requests, params)
+			if params.Get("query") != query {
+				http.Error(w, "parse error", http.StatusBadRequest)
+				return
+			}
+			start, _ := strconv.ParseInt(params.Get("start"), 10, 64)
+			end, _ := strconv.ParseInt(params.Get("end"), 10, 64)
+			limit, _ := strconv.Atoi(params.Get("limit"))
+			values := [][]string{}
+			for i := len(entries) - 1; i >= 0 && len(values) < limit; i-- {
+				if entries[i] >= start && entries[i] < end {
+					values = append(values, []string{strconv.FormatInt(entries[i], 10), line(i)})
+				}
+			}
+			result := []interface{}{map[string]interface{}{"stream": stream, "values": values}}
+			if resultType != "streams" {
+				result = []interface{}{map[string]interface{}{"metric": map[string]string{}, "values": []interface{}{[]interface{}{1, "2"}}}}
+			}
+			Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "success",
+				"data":   map[string]interface{}{"resultType": resultType, "result": result},
+			})).To(Succeed())
+		})
+		mux.Handle("/loki/api/v1/tail", websocket.Handler(func(conn *websocket.Conn) {
+			mutex.Lock()
+			tailStart = conn.Request().URL.Query().Get("start")
+			mutex.Unlock()
+			Expect(websocket.JSON.Send(conn, map[string]interface{}{
+				"streams":         []interface{}{map[string]interface{}{"stream": stream, "values": [][]string{{strconv.FormatInt(time.Now().UnixNano(), 10), line(6000)}}}},
+				"dropped_entries": []interface{}{map[string]interface{}{"timestamp": "1"}},
+			})).To(Succeed())
+			Expect(websocket.JSON.Send(conn, map[string]interface{}{
+				"streams": []interface{}{map[string]interface{}{"stream": stream, "values": [][]string{{strconv.FormatInt(time.Now().UnixNano(), 10), "unstructured line"}}}},
+			})).To(Succeed())
+		}))
+		server = httptest.NewServer(mux)
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		port, err := strconv.Atoi(serverURL.Port())
+		Expect(err).NotTo(HaveOccurred())
+		portForwarder.EXPECT().ForwardPort(cmd.TargetKindSeed, "shoot--prod--test-shoot", "loki-0", 3100).Return(port, func() {}, nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		ctrl.Finish()
+	})
+
+	printedLines := func(out string) []string {
+		return regexp.MustCompile(`\tINFO\tline-\d+\n`).FindAllString(out, -1)
+	}
+
+	It("should show the newest logs of the query", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+
+		err := cmd.ShowLogsFromLoki(portForwarder, "shoot--prod--test-shoot", query, 3, time.Hour, false, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("Pod Name: kube-apiserver-0, Container Name: kube-apiserver, DockerID: abc\n"))
+		Expect(printedLines(out.String())).To(Equal([]string{"\tINFO\tline-5997\n", "\tINFO\tline-5998\n", "\tINFO\tline-5999\n"}))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Get("limit")).To(Equal("3"))
+		Expect(requests[0].Get("direction")).To(Equal("backward"))
+	})
+
+	It("should request the logs in pages if they exceed one response", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+
+		err := cmd.ShowLogsFromLoki(portForwarder, "shoot--prod--test-shoot", query, 5500, time.Hour, false, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		lines := printedLines(out.String())
+		Expect(lines).To(HaveLen(5500))
+		Expect(lines[0]).To(Equal("\tINFO\tline-500\n"))
+		Expect(lines[5499]).To(Equal("\tINFO\tline-5999\n"))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0].Get("limit")).To(Equal("5000"))
+		Expect(requests[1].Get("limit")).To(Equal("501"))
+	})
+
+	It("should return an error for metric queries", func() {
+		resultType = "matrix"
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+
+		err := cmd.ShowLogsFromLoki(portForwarder, "shoot--prod--test-shoot", query, 3, time.Hour, false, ioStreams)
+
+		Expect(err).To(MatchError("only log queries are supported, the query returned a result of type matrix"))
+	})
+
+	It("should return the error of Loki", func() {
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+
+		err := cmd.ShowLogsFromLoki(portForwarder, "shoot--prod--test-shoot", "{", 3, time.Hour, false, ioStreams)
+
+		Expect(err).To(MatchError("/loki/api/v1/query_range returned 400 Bad Request: parse error"))
+	})
+
+	It("should follow the logs after the newest ones", func() {
+		ioStreams, _, out, errOut := cmd.NewTestIOStreams()
+
+		err := cmd.ShowLogsFromLoki(portForwarder, "shoot--prod--test-shoot", query, 1, time.Hour, true, ioStreams)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(printedLines(out.String())).To(Equal([]string{"\tINFO\tline-5999\n", "\tINFO\tline-6000\n"}))
+		Expect(out.String()).To(HaveSuffix("\tunstructured line\n"))
+		Expect(errOut.String()).To(Equal("Warning: 1 log entries have been dropped by Loki\n"))
+		Expect(tailStart).To(Equal(requests[0].Get("end")))
+	})
+})
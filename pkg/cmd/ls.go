@@ -28,13 +28,21 @@ import (
 
 // NewLsCmd returns a new ls command.
 func NewLsCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		includeBackups bool
+		shootName      string
+		seedName       string
+		allProjects    bool
+		user           string
+		timezone       string
+	)
 	cmd := &cobra.Command{
-		Use:          "ls [gardens|projects|seeds|shoots|issues|namespaces]",
+		Use:          "ls [gardens|projects|seeds|shoots|issues|namespaces|backupbuckets|backupentries|extensions|members|hibernation-schedules]",
 		Short:        "List all resource instances, e.g. \"gardenctl ls shoots\" to list shoots, \"gardenctl ls issues\" to list issues",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			if len(args) < 1 || len(args) > 2 {
-				return errors.New("command must be in the format: ls [gardens|projects|seeds|shoots|issues|namespaces]")
+				return errors.New("command must be in the format: ls [gardens|projects|seeds|shoots|issues|namespaces|backupbuckets|backupentries|extensions|members|hibernation-schedules]")
 			}
 
 			target := targetReader.ReadTarget(pathTarget)
@@ -66,16 +74,33 @@ func NewLsCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IO
 					return printSeedsWithShootsForProject(ioStreams.Out, outputFormat)
 				}
 			case "issues":
-				return printIssues(target, ioStreams.Out, outputFormat)
+				return printIssues(target, includeBackups, ioStreams.Out, outputFormat)
 			case "namespaces":
 				return printNamespaces(ioStreams.Out)
+			case "backupbuckets":
+				return printBackupBuckets(target, ioStreams.Out, outputFormat)
+			case "backupentries":
+				return printBackupEntries(target, shootName, ioStreams.Out, outputFormat)
+			case "extensions":
+				return printExtensions(target, seedName, ioStreams.Out, outputFormatOrTable(cmd))
+			case "members":
+				return printProjectMembers(target, allProjects, user, ioStreams.Out, outputFormatOrTable(cmd))
+			case "hibernation-schedules":
+				return printHibernationSchedules(target, timezone, ioStreams.Out, outputFormatOrTable(cmd))
 			}
 
 			return errors.New("command must be in the format: " + cmd.Use)
 		},
-		ValidArgs: []string{"issues", "projects", "gardens", "seeds", "shoots", "namespaces"},
+		ValidArgs: []string{"issues", "projects", "gardens", "seeds", "shoots", "namespaces", "backupbuckets", "backupentries", "extensions", "members", "hibernation-schedules"},
 	}
 
+	cmd.Flags().BoolVar(&includeBackups, "include-backups", false, "include failing backup buckets and backup entries in \"ls issues\"")
+	cmd.Flags().StringVar(&shootName, "shoot", "", "only list the backup entry of the given shoot in \"ls backupentries\"")
+	cmd.Flags().StringVar(&seedName, "seed", "", "only show the extensions of the given seed in \"ls extensions\"")
+	cmd.Flags().BoolVar(&allProjects, "all-projects", false, "list the members of all projects instead of the targeted one in \"ls members\"")
+	cmd.Flags().StringVar(&user, "user", "", "only list the memberships of the given user, group or service account in \"ls members\"")
+	cmd.Flags().StringVar(&timezone, "timezone", "", "timezone of the listed times in \"ls hibernation-schedules\", defaults to the local timezone")
+
 	return cmd
 }
 
@@ -167,8 +192,8 @@ func printProjectsWithShootsForSeed(writer io.Writer, outFormat string) error {
 	return PrintoutObject(projects, writer, outFormat)
 }
 
-// printIssues lists broken shoot clusters
-func printIssues(target TargetInterface, writer io.Writer, outFormat string) error {
+// printIssues lists broken shoot clusters and optionally failing backup buckets and entries
+func printIssues(target TargetInterface, includeBackups bool, writer io.Writer, outFormat string) error {
 	gardenClientset, err := target.GardenerClient()
 	checkError(err)
 	shootList, err := gardenClientset.CoreV1beta1().Shoots("").List(metav1.ListOptions{})
@@ -222,6 +247,10 @@ func printIssues(target TargetInterface, writer io.Writer, outFormat string) err
 				}
 				statusMeta.LastOperation = lastOperationMeta
 				im.Health = state
+				im.Severity = lastOperationSeverity(item.Status.LastOperation)
+				if !healthy && im.Severity != SeverityError {
+					im.Severity = SeverityWarning
+				}
 				im.Project = getProjectForNamespace(item.Namespace)
 				im.Seed = *item.Spec.SeedName
 				im.Shoot = item.Name
@@ -236,9 +265,16 @@ func printIssues(target TargetInterface, writer io.Writer, outFormat string) err
 			im.Seed = *item.Spec.SeedName
 			im.Shoot = item.Name
 			im.Health = "None"
+			im.Severity = SeverityWarning
 			issues.Issues = append(issues.Issues, im)
 		}
 	}
+	if includeBackups {
+		issues.BackupIssues, err = getBackupIssues(gardenClientset)
+		if err != nil {
+			return err
+		}
+	}
 	return PrintoutObject(issues, writer, outFormat)
 }
 
@@ -0,0 +1,349 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	machineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// workerPoolLabel is the node label carrying the name of the worker pool
+	workerPoolLabel = "worker.gardener.cloud/pool"
+	// deprecatedWorkerPoolLabel is the node label carrying the name of the worker pool on older shoots
+	deprecatedWorkerPoolLabel = "worker.garden.sapcloud.io/group"
+)
+
+// NewMachinesCmd returns a new machines command.
+func NewMachinesCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "machines",
+		Short:        "List machine deployments, machine sets and machines of the targeted shoot, e.g. \"gardenctl machines\"",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			machines, err := getShootMachines(targetReader)
+			if err != nil {
+				return err
+			}
+
+			outFormat := outputFormatOrTable(cmd)
+			if outFormat == tableOutputFormat {
+				return renderMachines(machines, ioStreams.Out)
+			}
+			return PrintoutObject(machines, ioStreams.Out, outFormat)
+		},
+	}
+	cmd.AddCommand(newMachinesDescribeCmd(targetReader, ioStreams))
+
+	return cmd
+}
+
+func newMachinesDescribeCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:          "describe <machine|node>",
+		Short:        "Describe a machine of the targeted shoot by machine or node name, e.g. \"gardenctl machines describe shoot--prj--name-worker-z1-abcde\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: machines describe <machine|node>")
+			}
+
+			machines, err := getShootMachines(targetReader)
+			if err != nil {
+				return err
+			}
+
+			var machine *MachineMeta
+			for index, m := range machines.Machines {
+				if m.Name == args[0] || m.Node == args[0] {
+					machine = &machines.Machines[index]
+					break
+				}
+			}
+			if machine == nil {
+				return fmt.Errorf("no machine found with name %q", args[0])
+			}
+
+			outFormat := outputFormatOrTable(cmd)
+			if outFormat == tableOutputFormat {
+				return renderMachine(machine, ioStreams.Out)
+			}
+			return PrintoutObject(machine, ioStreams.Out, outFormat)
+		},
+	}
+}
+
+// getShootMachines reads the machine deployments, machine sets and machines of the targeted shoot from its seed
+func getShootMachines(targetReader TargetReader) (*Machines, error) {
+	target := targetReader.ReadTarget(pathTarget)
+	if !CheckShootIsTargeted(target) {
+		return nil, errors.New("no shoot targeted")
+	}
+
+	shoot, err := FetchShootFromTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if shoot == nil {
+		return nil, fmt.Errorf("no shoot found with name %q", target.Stack()[2].Name)
+	}
+	if shoot.Status.TechnicalID == "" {
+		return nil, fmt.Errorf("shoot %q has not been scheduled to a seed yet", shoot.Name)
+	}
+
+	machineClientset, err := target.MachineClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return getMachines(machineClientset, shoot.Status.TechnicalID)
+}
+
+// getMachines collects the machine deployments, machine sets and machines in the given seed namespace
+func getMachines(machineClientset machineclientset.Interface, namespace string) (*Machines, error) {
+	deploymentList, err := machineClientset.MachineV1alpha1().MachineDeployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	machineSetList, err := machineClientset.MachineV1alpha1().MachineSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	machineList, err := machineClientset.MachineV1alpha1().Machines(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	machines := &Machines{}
+	poolPerDeployment := make(map[string]string)
+	for _, deployment := range deploymentList.Items {
+		pool := workerPool(deployment.Spec.Template.Spec.NodeTemplateSpec.Labels)
+		poolPerDeployment[deployment.Name] = pool
+		machines.MachineDeployments = append(machines.MachineDeployments, MachineDeploymentMeta{
+			Name:                deployment.Name,
+			WorkerPool:          pool,
+			Replicas:            deployment.Spec.Replicas,
+			ReadyReplicas:       deployment.Status.ReadyReplicas,
+			UpdatedReplicas:     deployment.Status.UpdatedReplicas,
+			AvailableReplicas:   deployment.Status.AvailableReplicas,
+			UnavailableReplicas: deployment.Status.UnavailableReplicas,
+		})
+	}
+
+	deploymentPerMachineSet := make(map[string]string)
+	for _, machineSet := range machineSetList.Items {
+		deployment := ownerName(machineSet.OwnerReferences, "MachineDeployment")
+		deploymentPerMachineSet[machineSet.Name] = deployment
+		machines.MachineSets = append(machines.MachineSets, MachineSetMeta{
+			Name:              machineSet.Name,
+			MachineDeployment: deployment,
+			WorkerPool:        poolPerDeployment[deployment],
+			Replicas:          machineSet.Spec.Replicas,
+			ReadyReplicas:     machineSet.Status.ReadyReplicas,
+			AvailableReplicas: machineSet.Status.AvailableReplicas,
+		})
+	}
+
+	for _, machine := range machineList.Items {
+		meta := toMachineMeta(machine)
+		meta.MachineDeployment = deploymentPerMachineSet[meta.MachineSet]
+		if meta.WorkerPool == "" {
+			meta.WorkerPool = poolPerDeployment[meta.MachineDeployment]
+		}
+		machines.Machines = append(machines.Machines, meta)
+	}
+
+	sort.Slice(machines.MachineDeployments, func(i, j int) bool {
+		return machines.MachineDeployments[i].Name < machines.MachineDeployments[j].Name
+	})
+	sort.Slice(machines.MachineSets, func(i, j int) bool {
+		return machines.MachineSets[i].Name < machines.MachineSets[j].Name
+	})
+	sort.Slice(machines.Machines, func(i, j int) bool {
+		return machines.Machines[i].Name < machines.Machines[j].Name
+	})
+
+	return machines, nil
+}
+
+func toMachineMeta(machine v1alpha1.Machine) MachineMeta {
+	meta := MachineMeta{
+		Name:       machine.Name,
+		WorkerPool: workerPool(machine.Spec.NodeTemplateSpec.Labels),
+		MachineSet: ownerName(machine.OwnerReferences, "MachineSet"),
+		Phase:      string(machine.Status.CurrentStatus.Phase),
+		Node:       machine.Status.Node,
+		ProviderID: machine.Spec.ProviderID,
+		LastOperation: LastOperationMeta{
+			Description: machine.Status.LastOperation.Description,
+			State:       string(machine.Status.LastOperation.State),
+			Type:        string(machine.Status.LastOperation.Type),
+		},
+	}
+	if !machine.CreationTimestamp.IsZero() {
+		meta.CreationTimestamp = machine.CreationTimestamp.Format(time.RFC3339)
+	}
+	if !machine.Status.LastOperation.LastUpdateTime.IsZero() {
+		meta.LastOperation.LastUpdateTime = machine.Status.LastOperation.LastUpdateTime.Format(time.RFC3339)
+	}
+	if machine.Status.LastOperation.State == v1alpha1.MachineStateFailed {
+		meta.LastError = machine.Status.LastOperation.Description
+	}
+	for _, condition := range machine.Status.Conditions {
+		meta.Conditions = append(meta.Conditions, ConditionMeta{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Format(time.RFC3339),
+		})
+	}
+
+	return meta
+}
+
+// renderMachines renders tables of the machine deployments, machine sets and machines, highlighting machines which are stuck
+func renderMachines(machines *Machines, writer io.Writer) error {
+	fmt.Fprintln(writer, "Machine Deployments:")
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "NAME", "POOL", "DESIRED", "READY", "UPDATED", "AVAILABLE", "UNAVAILABLE")
+	for _, deployment := range machines.MachineDeployments {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", deployment.Name, deployment.WorkerPool, deployment.Replicas, deployment.ReadyReplicas, deployment.UpdatedReplicas, deployment.AvailableReplicas, deployment.UnavailableReplicas)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer, "\nMachine Sets:")
+	w = tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "NAME", "DEPLOYMENT", "DESIRED", "READY", "AVAILABLE")
+	for _, machineSet := range machines.MachineSets {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", machineSet.Name, machineSet.MachineDeployment, machineSet.Replicas, machineSet.ReadyReplicas, machineSet.AvailableReplicas)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// render into a buffer first, so that colouring whole lines does not break the column alignment
+	fmt.Fprintln(writer, "\nMachines:")
+	var buffer bytes.Buffer
+	w = tabwriter.NewWriter(&buffer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "NAME", "POOL", "PHASE", "NODE", "PROVIDER ID", "LAST OPERATION", "CREATED")
+	for _, machine := range machines.Machines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", machine.Name, machine.WorkerPool, machine.Phase, machine.Node, machine.ProviderID, strings.TrimSpace(machine.LastOperation.Type+" "+machine.LastOperation.State), machine.CreationTimestamp)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	fmt.Fprintln(writer, lines[0])
+	color := isTerminal(writer)
+	for index, machine := range machines.Machines {
+		if color && isMachineStuck(machine) {
+			fmt.Fprintf(writer, warningColor+"\n", lines[index+1])
+		} else {
+			fmt.Fprintln(writer, lines[index+1])
+		}
+	}
+
+	var failed []MachineMeta
+	for _, machine := range machines.Machines {
+		if machine.LastError != "" {
+			failed = append(failed, machine)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(writer, "\nLast Errors:")
+		for _, machine := range failed {
+			fmt.Fprintf(writer, "%s: %s\n", machine.Name, machine.LastError)
+		}
+	}
+
+	return nil
+}
+
+// renderMachine renders the details of a single machine
+func renderMachine(machine *MachineMeta, writer io.Writer) error {
+	phase := machine.Phase
+	if isMachineStuck(*machine) && isTerminal(writer) {
+		phase = fmt.Sprintf(warningColor, phase)
+	}
+
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", machine.Name)
+	fmt.Fprintf(w, "Worker Pool:\t%s\n", machine.WorkerPool)
+	fmt.Fprintf(w, "Machine Deployment:\t%s\n", machine.MachineDeployment)
+	fmt.Fprintf(w, "Machine Set:\t%s\n", machine.MachineSet)
+	fmt.Fprintf(w, "Phase:\t%s\n", phase)
+	fmt.Fprintf(w, "Node:\t%s\n", machine.Node)
+	fmt.Fprintf(w, "Provider ID:\t%s\n", machine.ProviderID)
+	fmt.Fprintf(w, "Created:\t%s\n", machine.CreationTimestamp)
+	fmt.Fprintf(w, "Last Operation:\t%s\n", strings.TrimSpace(machine.LastOperation.Type+" "+machine.LastOperation.State+" "+machine.LastOperation.LastUpdateTime))
+	fmt.Fprintf(w, "  Description:\t%s\n", machine.LastOperation.Description)
+	fmt.Fprintf(w, "Last Error:\t%s\n", machine.LastError)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(machine.Conditions) == 0 {
+		return nil
+	}
+	fmt.Fprintln(writer, "Conditions:")
+	w = tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", "TYPE", "STATUS", "REASON", "MESSAGE")
+	for _, condition := range machine.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	return w.Flush()
+}
+
+// isMachineStuck returns true if the machine is in a phase which needs attention
+func isMachineStuck(machine MachineMeta) bool {
+	switch v1alpha1.MachinePhase(machine.Phase) {
+	case v1alpha1.MachinePending, v1alpha1.MachineFailed, v1alpha1.MachineTerminating:
+		return true
+	}
+	return false
+}
+
+// workerPool returns the name of the worker pool from the given node labels
+func workerPool(labels map[string]string) string {
+	if pool, ok := labels[workerPoolLabel]; ok {
+		return pool
+	}
+	return labels[deprecatedWorkerPoolLabel]
+}
+
+// ownerName returns the name of the first owner with the given kind
+func ownerName(ownerReferences []metav1.OwnerReference, kind string) string {
+	for _, owner := range ownerReferences {
+		if owner.Kind == kind {
+			return owner.Name
+		}
+	}
+	return ""
+}
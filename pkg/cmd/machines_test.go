@@ -0,0 +1,161 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	machinefake "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Machines", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		target       *mockcmd.MockTargetInterface
+	)
+
+	namespace := "shoot--prod--test-shoot"
+	projectNamespace := "garden-prod"
+	targetMeta := []cmd.TargetMeta{
+		{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+		{Kind: cmd.TargetKindProject, Name: "prod"},
+		{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+	}
+
+	gardenClientSet := gardencorefake.NewSimpleClientset(
+		&gardencorev1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+		},
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Status:     gardencorev1beta1.ShootStatus{TechnicalID: namespace},
+		},
+	)
+
+	machineClientSet := machinefake.NewSimpleClientset(
+		&v1alpha1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "shoot--prod--test-shoot-worker-z1", Namespace: namespace},
+			Spec: v1alpha1.MachineDeploymentSpec{
+				Replicas: 2,
+				Template: v1alpha1.MachineTemplateSpec{
+					Spec: v1alpha1.MachineSpec{
+						NodeTemplateSpec: v1alpha1.NodeTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"worker.gardener.cloud/pool": "worker"}},
+						},
+					},
+				},
+			},
+			Status: v1alpha1.MachineDeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 2, AvailableReplicas: 1, UnavailableReplicas: 1},
+		},
+		&v1alpha1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "shoot--prod--test-shoot-worker-z1-abc",
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "MachineDeployment", Name: "shoot--prod--test-shoot-worker-z1"}},
+			},
+			Spec: v1alpha1.MachineSetSpec{Replicas: 2},
+		},
+		&v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "shoot--prod--test-shoot-worker-z1-abc-running",
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "MachineSet", Name: "shoot--prod--test-shoot-worker-z1-abc"}},
+			},
+			Spec: v1alpha1.MachineSpec{ProviderID: "aws:///eu-west-1/i-running"},
+			Status: v1alpha1.MachineStatus{
+				Node:          "ip-10-250-0-1.ec2.internal",
+				CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning},
+				LastOperation: v1alpha1.LastOperation{Type: v1alpha1.MachineOperationCreate, State: v1alpha1.MachineStateSuccessful},
+			},
+		},
+		&v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "shoot--prod--test-shoot-worker-z1-abc-failed",
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "MachineSet", Name: "shoot--prod--test-shoot-worker-z1-abc"}},
+			},
+			Status: v1alpha1.MachineStatus{
+				CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineFailed},
+				LastOperation: v1alpha1.LastOperation{
+					Type:        v1alpha1.MachineOperationCreate,
+					State:       v1alpha1.MachineStateFailed,
+					Description: "InsufficientInstanceCapacity",
+				},
+			},
+		},
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return(targetMeta).AnyTimes()
+		target.EXPECT().GardenerClient().Return(gardenClientSet, nil).AnyTimes()
+		target.EXPECT().MachineClient().Return(machineClientSet, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list machine deployments, machine sets and machines", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewMachinesCmd(targetReader, ioStreams)
+		command.SetArgs([]string{})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(MatchRegexp(`shoot--prod--test-shoot-worker-z1\s+worker\s+2\s+1\s+2\s+1\s+1`))
+		Expect(out.String()).To(MatchRegexp(`shoot--prod--test-shoot-worker-z1-abc\s+shoot--prod--test-shoot-worker-z1\s+2`))
+		Expect(out.String()).To(MatchRegexp(`shoot--prod--test-shoot-worker-z1-abc-running\s+worker\s+Running\s+ip-10-250-0-1.ec2.internal\s+aws:///eu-west-1/i-running\s+Create Successful`))
+		Expect(out.String()).To(MatchRegexp(`\nshoot--prod--test-shoot-worker-z1-abc-failed\s+worker\s+Failed`))
+		Expect(out.String()).NotTo(ContainSubstring("\033["))
+		Expect(out.String()).To(ContainSubstring("shoot--prod--test-shoot-worker-z1-abc-failed: InsufficientInstanceCapacity"))
+	})
+
+	It("should describe a machine by its node name", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewMachinesCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"describe", "ip-10-250-0-1.ec2.internal"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(MatchRegexp(`Name:\s+shoot--prod--test-shoot-worker-z1-abc-running`))
+		Expect(out.String()).To(MatchRegexp(`Machine Deployment:\s+shoot--prod--test-shoot-worker-z1\n`))
+		Expect(out.String()).To(MatchRegexp(`Worker Pool:\s+worker`))
+	})
+
+	It("should return error for unknown machine", func() {
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewMachinesCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"describe", "unknown"})
+		err := command.Execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("no machine found with name \"unknown\""))
+	})
+})
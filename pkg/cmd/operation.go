@@ -0,0 +1,109 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// shootOperationAnnotation is the annotation which makes Gardener perform an operation on a shoot
+const shootOperationAnnotation = "gardener.cloud/operation"
+
+// shootOperations maps the operations of the operation command to the values of the operation annotation
+var shootOperations = map[string]string{
+	"reconcile":         "reconcile",
+	"retry":             "retry",
+	"maintain":          "maintain",
+	"rotate-kubeconfig": "rotate-kubeconfig-credentials",
+}
+
+// NewOperationCmd returns a new operation command.
+func NewOperationCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var flags shootChangeFlags
+	cmd := &cobra.Command{
+		Use:          "operation <reconcile|retry|maintain|rotate-kubeconfig> [shoot]",
+		Short:        "Trigger an operation on the targeted or given shoot, e.g. \"gardenctl operation reconcile --wait\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 || len(args) > 2 {
+				return errors.New("command must be in the format: operation <reconcile|retry|maintain|rotate-kubeconfig> [shoot]")
+			}
+			operation, ok := shootOperations[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown operation %q, must be one of reconcile, retry, maintain or rotate-kubeconfig", args[0])
+			}
+			name := ""
+			if len(args) == 2 {
+				name = args[1]
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, name, flags.selector)
+			if err != nil {
+				return err
+			}
+			if err := confirmShootRestrictions(target, configReader, shoots, flags.yes, ioStreams); err != nil {
+				return err
+			}
+
+			out := shootOperationWriter(ioStreams.Out, shoots)
+			return forEachShoot(shoots, flags.concurrency, ioStreams.ErrOut, func(shoot *gardencorev1beta1.Shoot) error {
+				return triggerShootOperation(gardenClientset, shoot, operation, flags, out)
+			})
+		},
+		ValidArgs: []string{"reconcile", "retry", "maintain", "rotate-kubeconfig"},
+	}
+	flags.addFlags(cmd)
+
+	return cmd
+}
+
+// triggerShootOperation annotates the shoot with the operation and optionally waits until the operation finished
+func triggerShootOperation(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, operation string, flags shootChangeFlags, writer io.Writer) error {
+	if operation == "retry" && (shoot.Status.LastOperation == nil || shoot.Status.LastOperation.State != gardencorev1beta1.LastOperationStateFailed) {
+		return fmt.Errorf("the last operation of shoot %s did not fail and cannot be retried", shootKey(shoot))
+	}
+
+	before := shoot.Status
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, shootOperationAnnotation, operation)
+	if _, err := gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Patch(shoot.Name, types.MergePatchType, []byte(patch)); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Operation %s requested for shoot %s\n", operation, shootKey(shoot))
+	if !flags.wait {
+		return nil
+	}
+
+	shoot, err := waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, shootOperationSucceeded(operation, before), flags.timeout, writer)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "%s of shoot %s succeeded\n", shoot.Status.LastOperation.Type, shootKey(shoot))
+	if len(shoot.Status.LastErrors) > 0 {
+		fmt.Fprintf(writer, "Last errors:%s\n", formatLastErrors(shoot.Status.LastErrors))
+	}
+
+	return nil
+}
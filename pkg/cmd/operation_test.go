@@ -0,0 +1,188 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Operation command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+	)
+
+	projectNamespace := "garden-prod"
+	project := &gardencorev1beta1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+	}
+	newShoot := func(state gardencorev1beta1.LastOperationState, progress int32, description string) *gardencorev1beta1.Shoot {
+		return &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Status: gardencorev1beta1.ShootStatus{
+				LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: state, Progress: progress, Description: description},
+			},
+		}
+	}
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewOperationCmd(targetReader, configReader, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("with a succeeded shoot", func() {
+		BeforeEach(func() {
+			clientSet = gardencorefake.NewSimpleClientset(
+				project,
+				newShoot(gardencorev1beta1.LastOperationStateSucceeded, 100, "Shoot cluster state has been successfully reconciled."),
+			)
+			target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		})
+
+		It("should annotate the shoot with the operation", func() {
+			out, err := execute("rotate-kubeconfig", "test-shoot")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("Operation rotate-kubeconfig-credentials requested for shoot garden-prod/test-shoot\n"))
+			shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shoot.Annotations).To(HaveKeyWithValue("gardener.cloud/operation", "rotate-kubeconfig-credentials"))
+		})
+
+		It("should show the progress of the operation until it succeeded", func() {
+			watcher := watch.NewFake()
+			clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+			go func() {
+				defer GinkgoRecover()
+				watcher.Modify(newShoot(gardencorev1beta1.LastOperationStateProcessing, 55, "Waiting until the Kubernetes API server is ready"))
+				watcher.Modify(newShoot(gardencorev1beta1.LastOperationStateSucceeded, 100, "Shoot cluster state has been successfully reconciled."))
+			}()
+
+			out, err := execute("reconcile", "--wait")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal(`Operation reconcile requested for shoot garden-prod/test-shoot
+garden-prod/test-shoot [####################] 100% Reconcile Succeeded: Shoot cluster state has been successfully reconciled.
+garden-prod/test-shoot [###########.........]  55% Reconcile Processing: Waiting until the Kubernetes API server is ready
+garden-prod/test-shoot [####################] 100% Reconcile Succeeded: Shoot cluster state has been successfully reconciled.
+Reconcile of shoot garden-prod/test-shoot succeeded
+`))
+		})
+
+		It("should detect that the operation has been picked up regardless of the local clock", func() {
+			watcher := watch.NewFake()
+			clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+			go func() {
+				defer GinkgoRecover()
+				// the clock of the server is behind, the operation finished before it was requested according to the local clock
+				succeeded := newShoot(gardencorev1beta1.LastOperationStateSucceeded, 100, "Shoot cluster state has been successfully reconciled.")
+				succeeded.Status.LastOperation.LastUpdateTime = metav1.NewTime(time.Now().Add(-time.Hour))
+				watcher.Modify(succeeded)
+			}()
+
+			out, err := execute("reconcile", "--wait", "--timeout", "10s")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(HaveSuffix("Reconcile of shoot garden-prod/test-shoot succeeded\n"))
+		})
+
+		It("should not consider the unchanged last operation as the result of the operation", func() {
+			watcher := watch.NewFake()
+			clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+			go func() {
+				defer GinkgoRecover()
+				watcher.Modify(newShoot(gardencorev1beta1.LastOperationStateSucceeded, 100, "Shoot cluster state has been successfully reconciled."))
+			}()
+
+			_, err := execute("reconcile", "--wait", "--timeout", "100ms")
+
+			Expect(err).To(MatchError("timed out waiting for shoot garden-prod/test-shoot"))
+		})
+
+		It("should refuse to retry a shoot whose last operation did not fail", func() {
+			_, err := execute("retry")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("the last operation of shoot garden-prod/test-shoot did not fail and cannot be retried"))
+		})
+	})
+
+	It("should print the last errors with their codes if the operation failed again", func() {
+		clientSet = gardencorefake.NewSimpleClientset(project, newShoot(gardencorev1beta1.LastOperationStateFailed, 80, "Infrastructure reconciliation failed"))
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		watcher := watch.NewFake()
+		clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+		go func() {
+			defer GinkgoRecover()
+			watcher.Modify(newShoot(gardencorev1beta1.LastOperationStateProcessing, 10, "Retrying"))
+			failed := newShoot(gardencorev1beta1.LastOperationStateFailed, 20, "Infrastructure reconciliation failed")
+			failed.Status.LastErrors = []gardencorev1beta1.LastError{{
+				Description: "not authorized to perform ec2:CreateVpc",
+				Codes:       []gardencorev1beta1.ErrorCode{gardencorev1beta1.ErrorInfraUnauthorized, gardencorev1beta1.ErrorConfigurationProblem},
+			}}
+			watcher.Modify(failed)
+		}()
+
+		out, err := execute("retry", "test-shoot", "--wait")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Reconcile of shoot garden-prod/test-shoot failed: Infrastructure reconciliation failed\n  - [ERR_INFRA_UNAUTHORIZED, ERR_CONFIGURATION_PROBLEM] not authorized to perform ec2:CreateVpc"))
+		Expect(out).To(ContainSubstring("garden-prod/test-shoot [##..................]  10% Reconcile Processing: Retrying\n"))
+	})
+
+	It("should return an error for an unknown operation", func() {
+		_, err := execute("restart")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("unknown operation \"restart\", must be one of reconcile, retry, maintain or rotate-kubeconfig"))
+	})
+})
@@ -0,0 +1,396 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// projectMemberUserAccessManager is a const for a role that allows to manage the members of a project
+	projectMemberUserAccessManager = "uam"
+	// serviceAccountPrefix is the prefix of the user name of a service account
+	serviceAccountPrefix = "system:serviceaccount:"
+)
+
+// projectMemberFlags are the flags of the commands changing project members
+type projectMemberFlags struct {
+	kind      string
+	namespace string
+	roles     []string
+	dryRun    bool
+}
+
+// NewProjectCmd returns a new project command.
+func NewProjectCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage the targeted project, e.g. \"gardenctl project members ls\"",
+	}
+	cmd.AddCommand(newProjectMembersCmd(targetReader, ioStreams))
+
+	return cmd
+}
+
+func newProjectMembersCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "members",
+		Short: "Manage the members of the targeted project, e.g. \"gardenctl project members add john.doe@example.com --role viewer\"",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "ls",
+		Short:        "List the members of the targeted project, e.g. \"gardenctl project members ls\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := targetReader.ReadTarget(pathTarget)
+			return printProjectMembers(target, false, "", ioStreams.Out, outputFormatOrTable(cmd))
+		},
+	})
+	cmd.AddCommand(newProjectMembersChangeCmd(targetReader, ioStreams, "add", "Add a member to the targeted project, e.g. \"gardenctl project members add john.doe@example.com --role admin\"",
+		func(members []gardencorev1beta1.ProjectMember, subject rbacv1.Subject, roles []string) ([]gardencorev1beta1.ProjectMember, error) {
+			if findProjectMember(members, subject) >= 0 {
+				return nil, fmt.Errorf("%s is already a member of the project", subjectString(subject))
+			}
+			if len(roles) == 0 {
+				roles = []string{gardencorev1beta1.ProjectMemberViewer}
+			}
+			member := gardencorev1beta1.ProjectMember{Subject: subject}
+			setProjectMemberRoles(&member, roles)
+			return append(members, member), nil
+		}))
+	cmd.AddCommand(newProjectMembersChangeCmd(targetReader, ioStreams, "remove", "Remove a member from the targeted project, e.g. \"gardenctl project members remove john.doe@example.com\"",
+		func(members []gardencorev1beta1.ProjectMember, subject rbacv1.Subject, roles []string) ([]gardencorev1beta1.ProjectMember, error) {
+			index := findProjectMember(members, subject)
+			if index < 0 {
+				return nil, fmt.Errorf("%s is not a member of the project", subjectString(subject))
+			}
+			return append(members[:index], members[index+1:]...), nil
+		}))
+	cmd.AddCommand(newProjectMembersChangeCmd(targetReader, ioStreams, "set-role", "Replace the roles of a member of the targeted project, e.g. \"gardenctl project members set-role john.doe@example.com --role admin --role uam\"",
+		func(members []gardencorev1beta1.ProjectMember, subject rbacv1.Subject, roles []string) ([]gardencorev1beta1.ProjectMember, error) {
+			index := findProjectMember(members, subject)
+			if index < 0 {
+				return nil, fmt.Errorf("%s is not a member of the project", subjectString(subject))
+			}
+			if len(roles) == 0 {
+				return nil, errors.New("at least one role must be given with --role")
+			}
+			// the owner role can only be changed via the project owner, so it's kept
+			if containsString(projectMemberRoles(members[index]), gardencorev1beta1.ProjectMemberOwner) {
+				roles = append([]string{gardencorev1beta1.ProjectMemberOwner}, roles...)
+			}
+			setProjectMemberRoles(&members[index], roles)
+			return members, nil
+		}))
+
+	return cmd
+}
+
+// newProjectMembersChangeCmd returns a command which changes the members of the targeted project with the given function
+func newProjectMembersChangeCmd(targetReader TargetReader, ioStreams IOStreams, use, short string, change func([]gardencorev1beta1.ProjectMember, rbacv1.Subject, []string) ([]gardencorev1beta1.ProjectMember, error)) *cobra.Command {
+	flags := &projectMemberFlags{}
+	cmd := &cobra.Command{
+		Use:          use + " <name>",
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: project members " + use + " <name>")
+			}
+			if err := validateProjectMemberRoles(flags.roles); err != nil {
+				return err
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			gardenClientset, project, err := getTargetedProject(target)
+			if err != nil {
+				return err
+			}
+			subject, err := parseSubject(flags.kind, args[0], flags.namespace, stringValue(project.Spec.Namespace))
+			if err != nil {
+				return err
+			}
+
+			return updateProjectMembers(gardenClientset, project, flags.dryRun, ioStreams.Out, func(members []gardencorev1beta1.ProjectMember) ([]gardencorev1beta1.ProjectMember, error) {
+				return change(members, subject, flags.roles)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.kind, "kind", rbacv1.UserKind, "kind of the member, one of user, group or serviceaccount")
+	cmd.Flags().StringVar(&flags.namespace, "namespace", "", "namespace of the service account, defaults to the project namespace")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "only show the changes without updating the project")
+	if use != "remove" {
+		cmd.Flags().StringSliceVar(&flags.roles, "role", nil, "role of the member, one of admin, viewer, uam or extension:<name>, can be repeated")
+	}
+
+	return cmd
+}
+
+// printProjectMembers prints the members of the targeted project or of all projects, optionally only the ones matching the given user
+func printProjectMembers(target TargetInterface, allProjects bool, user string, writer io.Writer, outFormat string) error {
+	var projects []gardencorev1beta1.Project
+	if allProjects {
+		gardenClientset, err := target.GardenerClient()
+		if err != nil {
+			return err
+		}
+		projectList, err := gardenClientset.CoreV1beta1().Projects().List(metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		projects = projectList.Items
+	} else {
+		_, project, err := getTargetedProject(target)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, *project)
+	}
+
+	var members ProjectMembers
+	for _, project := range projects {
+		for _, member := range projectMembersWithOwner(project) {
+			if user != "" && !subjectMatches(member.Subject, user) {
+				continue
+			}
+			members.Members = append(members.Members, ProjectMemberMeta{
+				Project: project.Name,
+				Kind:    member.Kind,
+				Name:    subjectName(member.Subject),
+				Roles:   projectMemberRoles(member),
+			})
+		}
+	}
+	sort.SliceStable(members.Members, func(i, j int) bool {
+		if members.Members[i].Project != members.Members[j].Project {
+			return members.Members[i].Project < members.Members[j].Project
+		}
+		return members.Members[i].Name < members.Members[j].Name
+	})
+
+	if outFormat != tableOutputFormat {
+		return PrintoutObject(members, writer, outFormat)
+	}
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "PROJECT", "KIND", "NAME", "ROLES")
+	for _, member := range members.Members {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", member.Project, member.Kind, member.Name, strings.Join(member.Roles, ","))
+	}
+	return w.Flush()
+}
+
+// updateProjectMembers applies the given change to the project members, prints the difference and updates the project
+// unless it is a dry run. The update fails if the project has been modified in the meantime.
+func updateProjectMembers(gardenClientset gardencoreclientset.Interface, project *gardencorev1beta1.Project, dryRun bool, writer io.Writer, change func([]gardencorev1beta1.ProjectMember) ([]gardencorev1beta1.ProjectMember, error)) error {
+	updated := project.DeepCopy()
+	members, err := change(updated.Spec.Members)
+	if err != nil {
+		return err
+	}
+	updated.Spec.Members = members
+
+	diff := diffProjectMembers(project.Spec.Members, updated.Spec.Members)
+	if len(diff) == 0 {
+		fmt.Fprintf(writer, "No changes for project %s\n", project.Name)
+		return nil
+	}
+	fmt.Fprintf(writer, "Changes for project %s:\n", project.Name)
+	for _, line := range diff {
+		fmt.Fprintln(writer, line)
+	}
+	if dryRun {
+		fmt.Fprintln(writer, "Dry run, project has not been updated")
+		return nil
+	}
+
+	if _, err := gardenClientset.CoreV1beta1().Projects().Update(updated); err != nil {
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("project %q has been modified in the meantime, please check the members and try again", project.Name)
+		}
+		return err
+	}
+	fmt.Fprintf(writer, "Project %s updated\n", project.Name)
+
+	return nil
+}
+
+// diffProjectMembers returns a line per added (+), removed (-) or changed (~) member
+func diffProjectMembers(oldMembers, newMembers []gardencorev1beta1.ProjectMember) []string {
+	var diff []string
+	for _, oldMember := range oldMembers {
+		index := findProjectMember(newMembers, oldMember.Subject)
+		if index < 0 {
+			diff = append(diff, fmt.Sprintf("- %s (%s)", subjectString(oldMember.Subject), strings.Join(projectMemberRoles(oldMember), ", ")))
+			continue
+		}
+		oldRoles, newRoles := strings.Join(projectMemberRoles(oldMember), ", "), strings.Join(projectMemberRoles(newMembers[index]), ", ")
+		if oldRoles != newRoles {
+			diff = append(diff, fmt.Sprintf("~ %s (%s -> %s)", subjectString(oldMember.Subject), oldRoles, newRoles))
+		}
+	}
+	for _, newMember := range newMembers {
+		if findProjectMember(oldMembers, newMember.Subject) < 0 {
+			diff = append(diff, fmt.Sprintf("+ %s (%s)", subjectString(newMember.Subject), strings.Join(projectMemberRoles(newMember), ", ")))
+		}
+	}
+	return diff
+}
+
+// getTargetedProject returns the project of the current target
+func getTargetedProject(target TargetInterface) (gardencoreclientset.Interface, *gardencorev1beta1.Project, error) {
+	if len(target.Stack()) < 2 || target.Stack()[1].Kind != TargetKindProject {
+		return nil, nil, errors.New("no project targeted")
+	}
+
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := gardenClientset.CoreV1beta1().Projects().Get(target.Stack()[1].Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gardenClientset, project, nil
+}
+
+// projectMembersWithOwner returns the project members including the project owner
+func projectMembersWithOwner(project gardencorev1beta1.Project) []gardencorev1beta1.ProjectMember {
+	members := append([]gardencorev1beta1.ProjectMember{}, project.Spec.Members...)
+	if project.Spec.Owner == nil {
+		return members
+	}
+
+	if index := findProjectMember(members, *project.Spec.Owner); index >= 0 {
+		roles := projectMemberRoles(members[index])
+		if !containsString(roles, gardencorev1beta1.ProjectMemberOwner) {
+			members[index] = *members[index].DeepCopy()
+			setProjectMemberRoles(&members[index], append([]string{gardencorev1beta1.ProjectMemberOwner}, roles...))
+		}
+		return members
+	}
+	return append(members, gardencorev1beta1.ProjectMember{Subject: *project.Spec.Owner, Role: gardencorev1beta1.ProjectMemberOwner})
+}
+
+// projectMemberRoles returns all roles of a member
+func projectMemberRoles(member gardencorev1beta1.ProjectMember) []string {
+	var roles []string
+	if member.Role != "" {
+		roles = append(roles, member.Role)
+	}
+	for _, role := range member.Roles {
+		if !containsString(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// setProjectMemberRoles sets the roles of a member, the first one is kept in the deprecated role field
+func setProjectMemberRoles(member *gardencorev1beta1.ProjectMember, roles []string) {
+	member.Role = roles[0]
+	member.Roles = nil
+	if len(roles) > 1 {
+		member.Roles = append([]string{}, roles[1:]...)
+	}
+}
+
+// validateProjectMemberRoles checks that only roles are given which can be assigned to project members
+func validateProjectMemberRoles(roles []string) error {
+	for _, role := range roles {
+		switch {
+		case role == gardencorev1beta1.ProjectMemberAdmin, role == gardencorev1beta1.ProjectMemberViewer, role == projectMemberUserAccessManager:
+		case strings.HasPrefix(role, gardencorev1beta1.ProjectMemberExtensionPrefix) && len(role) > len(gardencorev1beta1.ProjectMemberExtensionPrefix):
+		case role == gardencorev1beta1.ProjectMemberOwner:
+			return errors.New("the owner role can only be changed via the project owner")
+		default:
+			return fmt.Errorf("invalid role %q, must be one of admin, viewer, uam or extension:<name>", role)
+		}
+	}
+	return nil
+}
+
+// parseSubject returns the subject for the given member kind and name
+func parseSubject(kind, name, namespace, projectNamespace string) (rbacv1.Subject, error) {
+	switch strings.ToLower(kind) {
+	case "user":
+		return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: name}, nil
+	case "group":
+		return rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: name}, nil
+	case "serviceaccount":
+		if strings.HasPrefix(name, serviceAccountPrefix) {
+			parts := strings.Split(strings.TrimPrefix(name, serviceAccountPrefix), ":")
+			if len(parts) != 2 {
+				return rbacv1.Subject{}, fmt.Errorf("invalid service account name %q", name)
+			}
+			namespace, name = parts[0], parts[1]
+		}
+		if namespace == "" {
+			namespace = projectNamespace
+		}
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}, nil
+	}
+	return rbacv1.Subject{}, fmt.Errorf("invalid member kind %q, must be one of user, group or serviceaccount", kind)
+}
+
+// findProjectMember returns the index of the member with the given subject or -1
+func findProjectMember(members []gardencorev1beta1.ProjectMember, subject rbacv1.Subject) int {
+	for index, member := range members {
+		if member.Kind == subject.Kind && subjectName(member.Subject) == subjectName(subject) {
+			return index
+		}
+	}
+	return -1
+}
+
+// subjectMatches returns true if the subject has the given name, ignoring case
+func subjectMatches(subject rbacv1.Subject, name string) bool {
+	return strings.EqualFold(subject.Name, name) || strings.EqualFold(subjectName(subject), name)
+}
+
+// subjectName returns the name of a subject as seen by the API server
+func subjectName(subject rbacv1.Subject) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return serviceAccountPrefix + subject.Namespace + ":" + subject.Name
+	}
+	return subject.Name
+}
+
+func subjectString(subject rbacv1.Subject) string {
+	return subject.Kind + " " + subjectName(subject)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
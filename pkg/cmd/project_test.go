@@ -0,0 +1,186 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Project", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+	)
+
+	prodNamespace := "garden-prod"
+	devNamespace := "garden-dev"
+	targetMeta := []cmd.TargetMeta{
+		{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+		{Kind: cmd.TargetKindProject, Name: "prod"},
+	}
+	user := func(name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: name}
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		owner := user("owner@example.com")
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec: gardencorev1beta1.ProjectSpec{
+					Namespace: &prodNamespace,
+					Owner:     &owner,
+					Members: []gardencorev1beta1.ProjectMember{
+						{Subject: owner, Role: gardencorev1beta1.ProjectMemberAdmin},
+						{Subject: user("John.Doe@example.com"), Role: gardencorev1beta1.ProjectMemberViewer},
+					},
+				},
+			},
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev"},
+				Spec: gardencorev1beta1.ProjectSpec{
+					Namespace: &devNamespace,
+					Members: []gardencorev1beta1.ProjectMember{
+						{Subject: user("john.doe@example.com"), Role: gardencorev1beta1.ProjectMemberAdmin, Roles: []string{"uam"}},
+					},
+				},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return(targetMeta).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list the memberships of a user in all projects", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewLsCmd(targetReader, configReader, ioStreams)
+		command.SetArgs([]string{"members", "--all-projects", "--user", "john.doe@example.com"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(MatchRegexp(`dev\s+User\s+john.doe@example.com\s+admin,uam`))
+		Expect(out.String()).To(MatchRegexp(`prod\s+User\s+John.Doe@example.com\s+viewer`))
+		Expect(out.String()).NotTo(ContainSubstring("owner@example.com"))
+	})
+
+	It("should list the project owner with the owner role", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "ls"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(MatchRegexp(`prod\s+User\s+owner@example.com\s+owner,admin`))
+		Expect(out.String()).NotTo(ContainSubstring("dev"))
+	})
+
+	It("should only show the changes in dry run mode", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "add", "robot", "--kind", "serviceaccount", "--role", "admin", "--dry-run"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("+ ServiceAccount system:serviceaccount:garden-prod:robot (admin)"))
+		Expect(out.String()).To(ContainSubstring("Dry run, project has not been updated"))
+
+		project, err := clientSet.CoreV1beta1().Projects().Get("prod", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(project.Spec.Members).To(HaveLen(2))
+	})
+
+	It("should replace the roles of a member", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "set-role", "John.Doe@example.com", "--role", "admin", "--role", "extension:monitoring"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("~ User John.Doe@example.com (viewer -> admin, extension:monitoring)"))
+
+		project, err := clientSet.CoreV1beta1().Projects().Get("prod", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(project.Spec.Members[1].Role).To(Equal("admin"))
+		Expect(project.Spec.Members[1].Roles).To(Equal([]string{"extension:monitoring"}))
+	})
+
+	It("should keep the owner role when replacing the roles of the project owner", func() {
+		project, err := clientSet.CoreV1beta1().Projects().Get("prod", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		project.Spec.Members[0].Role = gardencorev1beta1.ProjectMemberOwner
+		project.Spec.Members[0].Roles = []string{gardencorev1beta1.ProjectMemberAdmin}
+		_, err = clientSet.CoreV1beta1().Projects().Update(project)
+		Expect(err).NotTo(HaveOccurred())
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "set-role", "owner@example.com", "--role", "viewer"})
+		err = command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("~ User owner@example.com (owner, admin -> owner, viewer)"))
+
+		project, err = clientSet.CoreV1beta1().Projects().Get("prod", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(project.Spec.Members[0].Role).To(Equal("owner"))
+		Expect(project.Spec.Members[0].Roles).To(Equal([]string{"viewer"}))
+	})
+
+	It("should remove a member", func() {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "remove", "John.Doe@example.com"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("- User John.Doe@example.com (viewer)"))
+
+		project, err := clientSet.CoreV1beta1().Projects().Get("prod", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(project.Spec.Members).To(HaveLen(1))
+	})
+
+	It("should reject invalid roles", func() {
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewProjectCmd(targetReader, ioStreams)
+		command.SetArgs([]string{"members", "add", "jane@example.com", "--role", "superuser"})
+		err := command.Execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("invalid role \"superuser\", must be one of admin, viewer, uam or extension:<name>"))
+	})
+})
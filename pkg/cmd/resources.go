@@ -0,0 +1,544 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// coreResourceKinds are the core.gardener.cloud kinds which can be printed with "get <kind> [name]"
+var coreResourceKinds = []string{"shoot", "seed", "project", "cloudprofile", "secretbinding", "quota", "plant", "backupbucket", "backupentry", "controllerinstallation"}
+
+// resourceResolver resolves core.gardener.cloud resources by name, defaulting the name from the current target
+type resourceResolver struct {
+	target          TargetInterface
+	gardenClientset gardencoreclientset.Interface
+	shoot           *gardencorev1beta1.Shoot
+}
+
+// printCoreResource prints the core.gardener.cloud resource of the given kind, optionally together with its related objects
+func printCoreResource(target TargetInterface, kind, name string, expand bool, writer io.Writer, outFormat string) error {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return err
+	}
+	r := &resourceResolver{target: target, gardenClientset: gardenClientset}
+
+	var object interface{}
+	switch kind {
+	case "shoot":
+		var shoot *gardencorev1beta1.Shoot
+		if shoot, err = r.getShoot(name); err == nil {
+			object = shoot
+			if expand {
+				object, err = r.expandShoot(shoot)
+			}
+		}
+	case "seed":
+		object, err = r.getSeed(name)
+	case "project":
+		object, err = r.getProject(name)
+	case "cloudprofile":
+		object, err = r.getCloudProfile(name)
+	case "secretbinding":
+		var secretBinding *gardencorev1beta1.SecretBinding
+		if secretBinding, err = r.getSecretBinding(name); err == nil {
+			object = secretBinding
+			if expand {
+				object, err = r.expandSecretBinding(secretBinding)
+			}
+		}
+	case "quota":
+		object, err = r.getQuota(name)
+	case "plant":
+		object, err = r.getPlant(name)
+	case "backupbucket":
+		object, err = r.getBackupBucket(name)
+	case "controllerinstallation":
+		var installation *gardencorev1beta1.ControllerInstallation
+		if installation, err = r.getControllerInstallation(name); err == nil {
+			object = installation
+			if expand {
+				object, err = r.expandControllerInstallation(installation)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	return printoutResource(object, writer, outFormat)
+}
+
+// printoutResource prints objects containing Kubernetes resources, honouring their json field names also for yaml
+func printoutResource(objectToPrint interface{}, writer io.Writer, outputFormat string) error {
+	switch outputFormat {
+	case "yaml":
+		out, err := sigsyaml.Marshal(objectToPrint)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(writer, string(out))
+	case "json":
+		out, err := json.MarshalIndent(objectToPrint, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(writer, string(out))
+	default:
+		return errors.New("output format not supported: '" + outputFormat + "'")
+	}
+	return nil
+}
+
+// targetedShoot returns the targeted shoot or nil if no shoot is targeted
+func (r *resourceResolver) targetedShoot() (*gardencorev1beta1.Shoot, error) {
+	if r.shoot != nil || !CheckShootIsTargeted(r.target) {
+		return r.shoot, nil
+	}
+	shoot, err := FetchShootFromTarget(r.target)
+	if err != nil {
+		return nil, err
+	}
+	if shoot == nil {
+		stack := r.target.Stack()
+		return nil, notFoundError("shoot", stack[len(stack)-1].Name)
+	}
+	r.shoot = shoot
+	return shoot, nil
+}
+
+// namespace returns the namespace of the targeted project or shoot, or an empty string if none is targeted
+func (r *resourceResolver) namespace() (string, error) {
+	stack := r.target.Stack()
+	if len(stack) > 1 && stack[1].Kind == TargetKindProject {
+		project, err := r.gardenClientset.CoreV1beta1().Projects().Get(stack[1].Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return stringValue(project.Spec.Namespace), nil
+	}
+	shoot, err := r.targetedShoot()
+	if err != nil || shoot == nil {
+		return "", err
+	}
+	return shoot.Namespace, nil
+}
+
+func (r *resourceResolver) getShoot(name string) (*gardencorev1beta1.Shoot, error) {
+	if name == "" {
+		shoot, err := r.targetedShoot()
+		if err != nil {
+			return nil, err
+		}
+		if shoot == nil {
+			return nil, missingNameError("shoot")
+		}
+		return setKind(shoot, "Shoot").(*gardencorev1beta1.Shoot), nil
+	}
+
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, err
+	}
+	if namespace != "" {
+		shoot, err := r.gardenClientset.CoreV1beta1().Shoots(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return setKind(shoot, "Shoot").(*gardencorev1beta1.Shoot), nil
+	}
+	list, err := r.gardenClientset.CoreV1beta1().Shoots(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		metas = append(metas, item.ObjectMeta)
+	}
+	index, err := pickByName("shoot", name, metas)
+	if err != nil {
+		return nil, err
+	}
+	return setKind(&list.Items[index], "Shoot").(*gardencorev1beta1.Shoot), nil
+}
+
+func (r *resourceResolver) getSeed(name string) (*gardencorev1beta1.Seed, error) {
+	if name == "" {
+		if stack := r.target.Stack(); len(stack) > 1 && stack[1].Kind == TargetKindSeed {
+			name = stack[1].Name
+		} else if shoot, err := r.targetedShoot(); err != nil {
+			return nil, err
+		} else if shoot != nil && shoot.Spec.SeedName != nil {
+			name = *shoot.Spec.SeedName
+		} else {
+			return nil, missingNameError("seed")
+		}
+	}
+	seed, err := r.gardenClientset.CoreV1beta1().Seeds().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return setKind(seed, "Seed").(*gardencorev1beta1.Seed), nil
+}
+
+func (r *resourceResolver) getProject(name string) (*gardencorev1beta1.Project, error) {
+	if name == "" {
+		stack := r.target.Stack()
+		if len(stack) < 2 || stack[1].Kind != TargetKindProject {
+			return nil, missingNameError("project")
+		}
+		name = stack[1].Name
+	}
+	project, err := r.gardenClientset.CoreV1beta1().Projects().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return setKind(project, "Project").(*gardencorev1beta1.Project), nil
+}
+
+func (r *resourceResolver) getCloudProfile(name string) (*gardencorev1beta1.CloudProfile, error) {
+	if name == "" {
+		shoot, err := r.targetedShoot()
+		if err != nil {
+			return nil, err
+		}
+		if shoot == nil {
+			return nil, missingNameError("cloudprofile")
+		}
+		name = shoot.Spec.CloudProfileName
+	}
+	cloudProfile, err := r.gardenClientset.CoreV1beta1().CloudProfiles().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return setKind(cloudProfile, "CloudProfile").(*gardencorev1beta1.CloudProfile), nil
+}
+
+func (r *resourceResolver) getSecretBinding(name string) (*gardencorev1beta1.SecretBinding, error) {
+	if name == "" {
+		shoot, err := r.targetedShoot()
+		if err != nil {
+			return nil, err
+		}
+		if shoot == nil {
+			return nil, missingNameError("secretbinding")
+		}
+		name = shoot.Spec.SecretBindingName
+	}
+
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.gardenClientset.CoreV1beta1().SecretBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		metas = append(metas, item.ObjectMeta)
+	}
+	index, err := pickByName("secretbinding", name, metas)
+	if err != nil {
+		return nil, err
+	}
+	return setKind(&list.Items[index], "SecretBinding").(*gardencorev1beta1.SecretBinding), nil
+}
+
+func (r *resourceResolver) getQuota(name string) (*gardencorev1beta1.Quota, error) {
+	namespace := ""
+	if name == "" {
+		secretBinding, err := r.getSecretBinding("")
+		if err != nil {
+			return nil, err
+		}
+		if len(secretBinding.Quotas) == 0 {
+			return nil, fmt.Errorf("secret binding %q does not reference any quota", secretBinding.Name)
+		}
+		name, namespace = secretBinding.Quotas[0].Name, secretBinding.Quotas[0].Namespace
+	} else {
+		var err error
+		if namespace, err = r.namespace(); err != nil {
+			return nil, err
+		}
+	}
+
+	list, err := r.gardenClientset.CoreV1beta1().Quotas(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		metas = append(metas, item.ObjectMeta)
+	}
+	index, err := pickByName("quota", name, metas)
+	if err != nil {
+		return nil, err
+	}
+	return setKind(&list.Items[index], "Quota").(*gardencorev1beta1.Quota), nil
+}
+
+func (r *resourceResolver) getPlant(name string) (*gardencorev1beta1.Plant, error) {
+	if name == "" {
+		return nil, missingNameError("plant")
+	}
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.gardenClientset.CoreV1beta1().Plants(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		metas = append(metas, item.ObjectMeta)
+	}
+	index, err := pickByName("plant", name, metas)
+	if err != nil {
+		return nil, err
+	}
+	return setKind(&list.Items[index], "Plant").(*gardencorev1beta1.Plant), nil
+}
+
+func (r *resourceResolver) getBackupBucket(name string) (*gardencorev1beta1.BackupBucket, error) {
+	if name == "" {
+		backupEntry, err := r.getBackupEntry("")
+		if err != nil {
+			return nil, err
+		}
+		name = backupEntry.Spec.BucketName
+	}
+	backupBucket, err := r.gardenClientset.CoreV1beta1().BackupBuckets().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return setKind(backupBucket, "BackupBucket").(*gardencorev1beta1.BackupBucket), nil
+}
+
+func (r *resourceResolver) getBackupEntry(name string) (*gardencorev1beta1.BackupEntry, error) {
+	if name == "" {
+		shoot, err := r.targetedShoot()
+		if err != nil {
+			return nil, err
+		}
+		if shoot == nil {
+			return nil, missingNameError("backupentry")
+		}
+		backupEntry, err := getBackupEntryForShoot(r.gardenClientset, shoot)
+		if err != nil {
+			return nil, err
+		}
+		return setKind(backupEntry, "BackupEntry").(*gardencorev1beta1.BackupEntry), nil
+	}
+
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.gardenClientset.CoreV1beta1().BackupEntries(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]metav1.ObjectMeta, 0, len(list.Items))
+	for _, item := range list.Items {
+		metas = append(metas, item.ObjectMeta)
+	}
+	index, err := pickByName("backupentry", name, metas)
+	if err != nil {
+		return nil, err
+	}
+	return setKind(&list.Items[index], "BackupEntry").(*gardencorev1beta1.BackupEntry), nil
+}
+
+func (r *resourceResolver) getControllerInstallation(name string) (*gardencorev1beta1.ControllerInstallation, error) {
+	if name == "" {
+		return nil, missingNameError("controllerinstallation")
+	}
+	installation, err := r.gardenClientset.CoreV1beta1().ControllerInstallations().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return setKind(installation, "ControllerInstallation").(*gardencorev1beta1.ControllerInstallation), nil
+}
+
+// expandShoot adds the project, seed, secret binding and cloud profile of the shoot
+func (r *resourceResolver) expandShoot(shoot *gardencorev1beta1.Shoot) (*ExpandedShoot, error) {
+	expanded := &ExpandedShoot{Shoot: shoot}
+
+	projects, err := projectNamesByNamespace(r.gardenClientset)
+	if err != nil {
+		return nil, err
+	}
+	expanded.Project = &ProjectSummary{Name: projects[shoot.Namespace], Namespace: shoot.Namespace}
+
+	if shoot.Spec.SeedName != nil {
+		seed, err := r.gardenClientset.CoreV1beta1().Seeds().Get(*shoot.Spec.SeedName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		expanded.Seed = toSeedSummary(seed)
+	}
+
+	secretBinding, err := r.gardenClientset.CoreV1beta1().SecretBindings(shoot.Namespace).Get(shoot.Spec.SecretBindingName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	expanded.SecretBinding = setKind(secretBinding, "SecretBinding").(*gardencorev1beta1.SecretBinding)
+
+	cloudProfile, err := r.gardenClientset.CoreV1beta1().CloudProfiles().Get(shoot.Spec.CloudProfileName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	expanded.CloudProfile = toCloudProfileSummary(cloudProfile, shoot)
+
+	return expanded, nil
+}
+
+// expandSecretBinding adds the quotas referenced by the secret binding
+func (r *resourceResolver) expandSecretBinding(secretBinding *gardencorev1beta1.SecretBinding) (*ExpandedSecretBinding, error) {
+	expanded := &ExpandedSecretBinding{SecretBinding: secretBinding}
+	for _, reference := range secretBinding.Quotas {
+		quota, err := r.gardenClientset.CoreV1beta1().Quotas(reference.Namespace).Get(reference.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		expanded.Quotas = append(expanded.Quotas, *setKind(quota, "Quota").(*gardencorev1beta1.Quota))
+	}
+	return expanded, nil
+}
+
+// expandControllerInstallation adds the controller registration and the seed of the controller installation
+func (r *resourceResolver) expandControllerInstallation(installation *gardencorev1beta1.ControllerInstallation) (*ExpandedControllerInstallation, error) {
+	registration, err := r.gardenClientset.CoreV1beta1().ControllerRegistrations().Get(installation.Spec.RegistrationRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	seed, err := r.gardenClientset.CoreV1beta1().Seeds().Get(installation.Spec.SeedRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &ExpandedControllerInstallation{
+		ControllerInstallation: installation,
+		ControllerRegistration: setKind(registration, "ControllerRegistration").(*gardencorev1beta1.ControllerRegistration),
+		Seed:                   toSeedSummary(seed),
+	}, nil
+}
+
+func toSeedSummary(seed *gardencorev1beta1.Seed) *SeedSummary {
+	summary := &SeedSummary{
+		Name:          seed.Name,
+		Provider:      seed.Spec.Provider.Type,
+		Region:        seed.Spec.Provider.Region,
+		IngressDomain: seed.Spec.DNS.IngressDomain,
+	}
+	for _, taint := range seed.Spec.Taints {
+		summary.Taints = append(summary.Taints, taint.Key)
+	}
+	for _, condition := range seed.Status.Conditions {
+		summary.Conditions = append(summary.Conditions, toConditionMeta(condition))
+	}
+	return summary
+}
+
+// toCloudProfileSummary returns the kubernetes versions of the cloud profile and the machine images and region used by the shoot
+func toCloudProfileSummary(cloudProfile *gardencorev1beta1.CloudProfile, shoot *gardencorev1beta1.Shoot) *CloudProfileSummary {
+	summary := &CloudProfileSummary{
+		Name:               cloudProfile.Name,
+		Type:               cloudProfile.Spec.Type,
+		KubernetesVersions: cloudProfile.Spec.Kubernetes.Versions,
+	}
+
+	usedImages := make(map[string]bool)
+	for _, worker := range shoot.Spec.Provider.Workers {
+		if worker.Machine.Image != nil {
+			usedImages[worker.Machine.Image.Name] = true
+		}
+	}
+	for _, image := range cloudProfile.Spec.MachineImages {
+		if usedImages[image.Name] {
+			summary.MachineImages = append(summary.MachineImages, image)
+		}
+	}
+	for index, region := range cloudProfile.Spec.Regions {
+		if region.Name == shoot.Spec.Region {
+			summary.Region = &cloudProfile.Spec.Regions[index]
+		}
+	}
+
+	return summary
+}
+
+func toConditionMeta(condition gardencorev1beta1.Condition) ConditionMeta {
+	return ConditionMeta{
+		Type:               string(condition.Type),
+		Status:             string(condition.Status),
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+		LastTransitionTime: condition.LastTransitionTime.String(),
+	}
+}
+
+// pickByName returns the index of the only object with the given name
+func pickByName(kind, name string, metas []metav1.ObjectMeta) (int, error) {
+	found := -1
+	for index, meta := range metas {
+		if meta.Name != name {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("found multiple %ss with name %q in namespaces %s and %s, please target a project", kind, name, metas[found].Namespace, meta.Namespace)
+		}
+		found = index
+	}
+	if found < 0 {
+		return -1, notFoundError(kind, name)
+	}
+	return found, nil
+}
+
+// setKind sets the type meta of objects read with the typed clientset, which leaves it empty
+func setKind(object runtime.Object, kind string) runtime.Object {
+	object.GetObjectKind().SetGroupVersionKind(gardencorev1beta1.SchemeGroupVersion.WithKind(kind))
+	return object
+}
+
+// notFoundError returns a NotFound status error, so that callers can tell a missing resource from other failures
+func notFoundError(kind, name string) error {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusNotFound,
+		Reason:  metav1.StatusReasonNotFound,
+		Message: fmt.Sprintf("no %s found with name %q", kind, name),
+	}}
+}
+
+func missingNameError(kind string) error {
+	return fmt.Errorf("no %s name given and no %s can be derived from the current target", kind, kind)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Get resources", func() {
+	var (
+		ctrl             *gomock.Controller
+		targetReader     *mockcmd.MockTargetReader
+		configReader     *mockcmd.MockConfigReader
+		kubeconfigReader *mockcmd.MockKubeconfigReader
+		kubeconfigWriter *mockcmd.MockKubeconfigWriter
+		target           *mockcmd.MockTargetInterface
+	)
+
+	seedName := "test-seed"
+	projectNamespace := "garden-prod"
+	imageVersion := "2512.3.0"
+	shootTarget := []cmd.TargetMeta{
+		{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+		{Kind: cmd.TargetKindProject, Name: "prod"},
+		{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+	}
+
+	clientSet := gardencorefake.NewSimpleClientset(
+		&gardencorev1beta1.Project{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+		},
+		&gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+			Spec: gardencorev1beta1.ShootSpec{
+				CloudProfileName:  "aws",
+				Region:            "eu-west-1",
+				SecretBindingName: "my-secret",
+				SeedName:          &seedName,
+				Provider: gardencorev1beta1.Provider{
+					Workers: []gardencorev1beta1.Worker{
+						{Name: "worker", Machine: gardencorev1beta1.Machine{Type: "m5.large", Image: &gardencorev1beta1.ShootMachineImage{Name: "coreos", Version: &imageVersion}}},
+					},
+				},
+			},
+		},
+		&gardencorev1beta1.Seed{
+			ObjectMeta: metav1.ObjectMeta{Name: seedName},
+			Spec: gardencorev1beta1.SeedSpec{
+				Provider: gardencorev1beta1.SeedProvider{Type: "aws", Region: "eu-west-1"},
+				DNS:      gardencorev1beta1.SeedDNS{IngressDomain: "ingress.test-seed.example.com"},
+			},
+		},
+		&gardencorev1beta1.SecretBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: projectNamespace},
+			SecretRef:  corev1.SecretReference{Name: "my-secret", Namespace: projectNamespace},
+			Quotas:     []corev1.ObjectReference{{Name: "trial", Namespace: "garden-trial"}},
+		},
+		&gardencorev1beta1.Quota{
+			ObjectMeta: metav1.ObjectMeta{Name: "trial", Namespace: "garden-trial"},
+		},
+		&gardencorev1beta1.CloudProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "aws"},
+			Spec: gardencorev1beta1.CloudProfileSpec{
+				Type: "aws",
+				Kubernetes: gardencorev1beta1.KubernetesSettings{
+					Versions: []gardencorev1beta1.ExpirableVersion{{Version: "1.18.2"}},
+				},
+				MachineImages: []gardencorev1beta1.MachineImage{
+					{Name: "coreos", Versions: []gardencorev1beta1.ExpirableVersion{{Version: imageVersion}}},
+					{Name: "ubuntu", Versions: []gardencorev1beta1.ExpirableVersion{{Version: "18.4.20200228"}}},
+				},
+				Regions: []gardencorev1beta1.Region{
+					{Name: "eu-west-1", Zones: []gardencorev1beta1.AvailabilityZone{{Name: "eu-west-1a"}}},
+					{Name: "us-east-1"},
+				},
+			},
+		},
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		kubeconfigReader = mockcmd.NewMockKubeconfigReader(ctrl)
+		kubeconfigWriter = mockcmd.NewMockKubeconfigWriter(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should default the cloud profile from the targeted shoot", func() {
+		target.EXPECT().Stack().Return(shootTarget).AnyTimes()
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"cloudprofile"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("kind: CloudProfile"))
+		Expect(out.String()).To(ContainSubstring("name: aws"))
+	})
+
+	It("should expand the shoot with its related objects", func() {
+		target.EXPECT().Stack().Return(shootTarget).AnyTimes()
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"shoot", "--expand"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("kind: Shoot"))
+		Expect(out.String()).To(ContainSubstring("ingressDomain: ingress.test-seed.example.com"))
+		Expect(out.String()).To(ContainSubstring("secretBinding:"))
+		Expect(out.String()).To(ContainSubstring("namespace: garden-prod"))
+		Expect(out.String()).To(ContainSubstring("- name: eu-west-1a"))
+		Expect(out.String()).NotTo(ContainSubstring("ubuntu"))
+		Expect(out.String()).NotTo(ContainSubstring("us-east-1"))
+	})
+
+	It("should expand the secret binding with its quotas", func() {
+		target.EXPECT().Stack().Return(shootTarget).AnyTimes()
+
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"secretbinding", "my-secret", "--expand"})
+		err := command.Execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("kind: SecretBinding"))
+		Expect(out.String()).To(ContainSubstring("kind: Quota"))
+		Expect(out.String()).To(ContainSubstring("name: trial"))
+	})
+
+	It("should return error if no name can be derived from the target", func() {
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{{Kind: cmd.TargetKindGarden, Name: "test-garden"}}).AnyTimes()
+
+		ioStreams, _, _, _ := cmd.NewTestIOStreams()
+		command := cmd.NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams)
+		command.SetArgs([]string{"cloudprofile"})
+		err := command.Execute()
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("no cloudprofile name given and no cloudprofile can be derived from the current target"))
+	})
+})
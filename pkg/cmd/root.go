@@ -94,6 +94,7 @@ func Execute() {
 		pathGardenHome = strings.Replace(pathGardenHome, "~", HomeDir(), 1)
 	}
 	pathGardenConfig = filepath.Join(pathGardenHome, "config")
+	pathAudit = filepath.Join(pathGardenHome, "audit")
 	CreateDir(pathGardenHome, 0751)
 	sessionID = os.Getenv("GARDEN_SESSION_ID")
 	if sessionID == "" {
@@ -117,7 +118,7 @@ func Execute() {
 	}
 	GetGardenClusterKubeConfigFromConfig(pathGardenConfig, pathTarget)
 	if err := RootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(ExitCode(err))
 	}
 }
 
@@ -129,6 +130,10 @@ func init() {
 		kubeconfigReader = &GardenctlKubeconfigReader{}
 		kubeconfigWriter = &GardenctlKubeconfigWriter{}
 		historyWriter    = &GardenctlHistoryWriter{}
+		prompter         = &GardenctlPrompter{}
+		editor           = &GardenctlEditor{}
+		portForwarder    = &GardenctlPortForwarder{}
+		podExecutor      = &GardenctlPodExecutor{}
 		ioStreams        = IOStreams{
 			In:     os.Stdin,
 			Out:    os.Stdout,
@@ -149,11 +154,18 @@ func init() {
 		NewTargetCmd(targetReader, targetWriter, configReader, ioStreams, kubeconfigReader, historyWriter),
 		NewDropCmd(targetReader, targetWriter, ioStreams),
 		NewGetCmd(targetReader, configReader, kubeconfigReader, kubeconfigWriter, ioStreams))
-	RootCmd.AddCommand(NewDownloadCmd(targetReader), NewShowCmd(targetReader), NewLogsCmd(targetReader))
+	RootCmd.AddCommand(NewDownloadCmd(targetReader), NewShowCmd(targetReader), NewLogsCmd(targetReader, portForwarder, ioStreams))
 	RootCmd.AddCommand(NewRegisterCmd(), NewUnregisterCmd())
 	RootCmd.AddCommand(NewCompletionCmd())
 	RootCmd.AddCommand(NewShellCmd(targetReader, ioStreams))
 	RootCmd.AddCommand(NewSSHCmd(targetReader, ioStreams))
+	RootCmd.AddCommand(NewMachinesCmd(targetReader, ioStreams))
+	RootCmd.AddCommand(NewProjectCmd(targetReader, ioStreams))
+	RootCmd.AddCommand(NewHibernateCmd(targetReader, configReader, ioStreams), NewWakeupCmd(targetReader, configReader, ioStreams))
+	RootCmd.AddCommand(NewOperationCmd(targetReader, configReader, ioStreams), NewUpgradeCmd(targetReader, configReader, ioStreams))
+	RootCmd.AddCommand(NewCreateCmd(targetReader, prompter, ioStreams), NewDeleteCmd(targetReader, configReader, ioStreams))
+	RootCmd.AddCommand(NewWaitCmd(targetReader, ioStreams), NewWorkerCmd(targetReader, configReader, ioStreams))
+	RootCmd.AddCommand(NewEditCmd(targetReader, configReader, editor, ioStreams), NewHibernationCmd(targetReader, configReader, ioStreams))
 	RootCmd.AddCommand(NewKubectlCmd(), NewKaCmd(), NewKsCmd(), NewKgCmd(), NewKnCmd())
 	RootCmd.AddCommand(NewKubectxCmd())
 	RootCmd.AddCommand(NewTerraformCmd(targetReader))
@@ -161,7 +173,8 @@ func init() {
 	RootCmd.AddCommand(NewAliyunCmd(targetReader), NewAwsCmd(targetReader), NewAzCmd(targetReader), NewGcloudCmd(targetReader), NewOpenstackCmd(targetReader))
 	RootCmd.AddCommand(NewInfoCmd(targetReader, ioStreams))
 	RootCmd.AddCommand(NewVersionCmd(), NewUpdateCheckCmd())
-	RootCmd.AddCommand(NewDiagCmd(targetReader, ioStreams))
+	RootCmd.AddCommand(NewDiagCmd(targetReader, ioStreams), NewEventsCmd(targetReader, ioStreams), NewCertsCmd(targetReader, kubeconfigReader, ioStreams))
+	RootCmd.AddCommand(NewEtcdCmd(targetReader, portForwarder, ioStreams), NewEtcdctlCmd(targetReader, podExecutor, historyWriter, ioStreams))
 	RootCmd.AddCommand(NewHistoryCmd(targetWriter, historyWriter))
 
 	RootCmd.SuggestionsMinimumDistance = suggestionsMinimumDistance
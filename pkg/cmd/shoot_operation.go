@@ -0,0 +1,396 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// defaultShootConcurrency is the default number of shoots changed in parallel when selecting shoots by label
+	defaultShootConcurrency = 5
+	// shootWatchInitialBackoff is the time waited before a shoot is watched again after its watch ended, it is
+	// doubled for each further watch up to shootWatchMaxBackoff
+	shootWatchInitialBackoff = time.Second
+	shootWatchMaxBackoff     = 16 * time.Second
+)
+
+var (
+	// errShootDeleted is returned when waiting for a shoot which has been deleted
+	errShootDeleted = errors.New("has been deleted")
+	// errWaitTimeout is returned when the awaited state of a shoot is not reached in time
+	errWaitTimeout = errors.New("timed out waiting")
+)
+
+// shootWaitCondition reports whether the awaited state of a shoot is reached, or returns an error if it
+// cannot be reached anymore
+type shootWaitCondition func(shoot *gardencorev1beta1.Shoot) (bool, error)
+
+// resolveShoots returns the given or targeted shoot, or all shoots matching the label selector
+func resolveShoots(target TargetInterface, name, selector string) (gardencoreclientset.Interface, []gardencorev1beta1.Shoot, error) {
+	gardenClientset, err := target.GardenerClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &resourceResolver{target: target, gardenClientset: gardenClientset}
+
+	if selector == "" {
+		shoot, err := r.getShoot(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gardenClientset, []gardencorev1beta1.Shoot{*shoot}, nil
+	}
+	if name != "" {
+		return nil, nil, errors.New("a shoot name and --selector cannot be used together")
+	}
+
+	namespace, err := r.namespace()
+	if err != nil {
+		return nil, nil, err
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	list, err := gardenClientset.CoreV1beta1().Shoots(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil, fmt.Errorf("no shoots found matching selector %q", selector)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return shootKey(&list.Items[i]) < shootKey(&list.Items[j])
+	})
+	return gardenClientset, list.Items, nil
+}
+
+// confirmShootRestrictions prints the access restriction warnings of the shoots and asks for confirmation
+// unless the user already confirmed with --yes
+func confirmShootRestrictions(target TargetInterface, configReader ConfigReader, shoots []gardencorev1beta1.Shoot, yes bool, ioStreams IOStreams) error {
+	gardenName := target.Stack()[0].Name
+	restricted := false
+	for _, shoot := range shoots {
+		if warning := checkShootsRestriction(shoot, configReader, gardenName); warning != "" {
+			fmt.Fprintf(ioStreams.ErrOut, "Shoot %s:\n%s", shootKey(&shoot), warning)
+			restricted = true
+		}
+	}
+	if !restricted || yes {
+		return nil
+	}
+
+	confirmed, err := confirm("Do you want to continue? Type 'yes' to confirm: ", ioStreams)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errors.New("aborted due to access restrictions")
+	}
+	return nil
+}
+
+// forEachShoot calls the function for all shoots with at most the given number of calls in parallel
+// and returns an error naming the shoots for which it failed
+func forEachShoot(shoots []gardencorev1beta1.Shoot, concurrency int, errOut io.Writer, fn func(shoot *gardencorev1beta1.Shoot) error) error {
+	if len(shoots) == 1 {
+		return fn(&shoots[0])
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		failed    []string
+		semaphore = make(chan struct{}, concurrency)
+	)
+	for i := range shoots {
+		wg.Add(1)
+		go func(shoot *gardencorev1beta1.Shoot) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := fn(shoot); err != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				fmt.Fprintf(errOut, "%s: %v\n", shootKey(shoot), err)
+				failed = append(failed, shootKey(shoot))
+			}
+		}(&shoots[i])
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%d of %d shoots failed: %s", len(failed), len(shoots), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// waitForShoot watches the shoot until the condition is met, the condition fails, the shoot is deleted or the timeout
+// expires. Changes of the last operation and the conditions of the shoot are reported to the writer. If the watch ends
+// or its resource version expired, the shoot is read again after a backoff and watched from its current version.
+func waitForShoot(gardenClientset gardencoreclientset.Interface, namespace, name string, condition shootWaitCondition, timeout time.Duration, writer io.Writer) (*gardencorev1beta1.Shoot, error) {
+	shoots := gardenClientset.CoreV1beta1().Shoots(namespace)
+	progress := newShootProgressPrinter(writer)
+	defer progress.done()
+	deadline := time.After(timeout)
+	backoff := shootWatchInitialBackoff
+
+	for {
+		shoot, err := shoots.Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("shoot %s/%s %w", namespace, name, errShootDeleted)
+		}
+		if err != nil {
+			return nil, err
+		}
+		progress.print(shoot)
+		if done, err := condition(shoot); err != nil || done {
+			return shoot, err
+		}
+
+		watcher, err := shoots.Watch(metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: shoot.ResourceVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		shoot, done, err := nextShootEvent(watcher, name, shoot, condition, progress, deadline)
+		watcher.Stop()
+		if err != nil || done {
+			return shoot, err
+		}
+		if shoot == nil {
+			return nil, fmt.Errorf("shoot %s/%s %w", namespace, name, errShootDeleted)
+		}
+
+		select {
+		case <-deadline:
+			return shoot, fmt.Errorf("%w for shoot %s", errWaitTimeout, shootKey(shoot))
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > shootWatchMaxBackoff {
+			backoff = shootWatchMaxBackoff
+		}
+	}
+}
+
+// nextShootEvent consumes watch events until the condition is met or fails, the shoot is deleted or the watch ends
+// or fails, e.g. because the resource version expired. It returns the latest known state of the shoot, or nil if the
+// shoot has been deleted, and whether the condition is met.
+func nextShootEvent(watcher watch.Interface, name string, shoot *gardencorev1beta1.Shoot, condition shootWaitCondition, progress *shootProgressPrinter, deadline <-chan time.Time) (*gardencorev1beta1.Shoot, bool, error) {
+	for {
+		select {
+		case <-deadline:
+			return shoot, false, fmt.Errorf("%w for shoot %s", errWaitTimeout, shootKey(shoot))
+		case event, ok := <-watcher.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return shoot, false, nil
+			}
+			updated, isShoot := event.Object.(*gardencorev1beta1.Shoot)
+			if !isShoot || updated.Name != name {
+				continue
+			}
+			if event.Type == watch.Deleted {
+				return nil, false, nil
+			}
+			shoot = updated
+			progress.print(shoot)
+			if done, err := condition(shoot); err != nil || done {
+				return shoot, done, err
+			}
+		}
+	}
+}
+
+// lastOperationSucceeded returns a condition which is met once the shoot observed the given generation and its last
+// operation succeeded, and which fails once the last operation failed or was aborted
+func lastOperationSucceeded(generation int64) shootWaitCondition {
+	return func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+		if shoot.Status.ObservedGeneration < generation {
+			return false, nil
+		}
+		return lastOperationResult(shoot)
+	}
+}
+
+// shootOperationSucceeded returns a condition which is met once the operation requested with the operation annotation
+// has been picked up and succeeded, and which fails once it failed or was aborted. The operation is picked up once the
+// annotation is removed and the last operation or the observed generation differ from the given status, which was
+// read before the annotation was set. Only timestamps of the server are compared, the local clock may be skewed.
+func shootOperationSucceeded(operation string, before gardencorev1beta1.ShootStatus) shootWaitCondition {
+	pickedUp := false
+	return func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+		lastOperation := shoot.Status.LastOperation
+		if shoot.Annotations[shootOperationAnnotation] == operation || lastOperation == nil {
+			return false, nil
+		}
+		// the annotation is removed when the operation starts, but the last operation might not be updated yet
+		pickedUp = pickedUp || !isLastOperationFinal(lastOperation.State) || shoot.Status.ObservedGeneration != before.ObservedGeneration ||
+			before.LastOperation == nil || !lastOperation.LastUpdateTime.Equal(&before.LastOperation.LastUpdateTime) || lastOperation.Type != before.LastOperation.Type
+		if !pickedUp {
+			return false, nil
+		}
+		return lastOperationResult(shoot)
+	}
+}
+
+// lastOperationResult returns whether the last operation of the shoot succeeded, or an error with the last errors
+// of the shoot if it failed or was aborted
+func lastOperationResult(shoot *gardencorev1beta1.Shoot) (bool, error) {
+	lastOperation := shoot.Status.LastOperation
+	if lastOperation == nil {
+		return false, nil
+	}
+	switch lastOperation.State {
+	case gardencorev1beta1.LastOperationStateSucceeded:
+		return true, nil
+	case gardencorev1beta1.LastOperationStateFailed, gardencorev1beta1.LastOperationStateAborted:
+		return false, fmt.Errorf("%s of shoot %s %s: %s%s", lastOperation.Type, shootKey(shoot),
+			strings.ToLower(string(lastOperation.State)), lastOperation.Description, formatLastErrors(shoot.Status.LastErrors))
+	}
+	return false, nil
+}
+
+// isLastOperationFinal returns whether an operation in the given state will not make any further progress
+func isLastOperationFinal(state gardencorev1beta1.LastOperationState) bool {
+	return state == gardencorev1beta1.LastOperationStateSucceeded ||
+		state == gardencorev1beta1.LastOperationStateFailed ||
+		state == gardencorev1beta1.LastOperationStateAborted
+}
+
+// shootProgressPrinter prints the last operation and the unhealthy conditions of a shoot whenever they change.
+// On a terminal the progress bar of the last operation is updated in place.
+type shootProgressPrinter struct {
+	writer     io.Writer
+	live       bool
+	operation  string
+	conditions map[gardencorev1beta1.ConditionType]string
+}
+
+func newShootProgressPrinter(writer io.Writer) *shootProgressPrinter {
+	return &shootProgressPrinter{
+		writer:     writer,
+		live:       isTerminal(writer),
+		conditions: map[gardencorev1beta1.ConditionType]string{},
+	}
+}
+
+func (p *shootProgressPrinter) print(shoot *gardencorev1beta1.Shoot) {
+	for _, condition := range shoot.Status.Conditions {
+		state := string(condition.Status)
+		if condition.Status != gardencorev1beta1.ConditionTrue {
+			state += ": " + condition.Message
+		}
+		previous, seen := p.conditions[condition.Type]
+		p.conditions[condition.Type] = state
+		if state == previous || (!seen && condition.Status == gardencorev1beta1.ConditionTrue) {
+			continue
+		}
+		p.done()
+		fmt.Fprintf(p.writer, "%s: condition %s is %s\n", shootKey(shoot), condition.Type, state)
+	}
+
+	op := shoot.Status.LastOperation
+	if op == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %s %3d%% %s %s", shootKey(shoot), progressBar(op.Progress), op.Progress, op.Type, op.State)
+	if op.Description != "" {
+		line += ": " + op.Description
+	}
+	if line == p.operation {
+		return
+	}
+	if p.live {
+		// clear the rest of the previous line
+		fmt.Fprintf(p.writer, "\r%s\033[K", line)
+	} else {
+		fmt.Fprintln(p.writer, line)
+	}
+	p.operation = line
+}
+
+// done terminates the line of the progress bar on a terminal
+func (p *shootProgressPrinter) done() {
+	if p.live && p.operation != "" {
+		fmt.Fprintln(p.writer)
+		p.operation = ""
+	}
+}
+
+// progressBar returns a bar of fixed width visualizing the given percentage
+func progressBar(progress int32) string {
+	const width = 20
+	if progress < 0 {
+		progress = 0
+	} else if progress > 100 {
+		progress = 100
+	}
+	filled := int(progress) * width / 100
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// formatLastErrors returns the last errors of a shoot together with their error codes, one per line
+func formatLastErrors(lastErrors []gardencorev1beta1.LastError) string {
+	var b strings.Builder
+	for _, lastError := range lastErrors {
+		b.WriteString("\n  - ")
+		if len(lastError.Codes) > 0 {
+			codes := make([]string, 0, len(lastError.Codes))
+			for _, code := range lastError.Codes {
+				codes = append(codes, string(code))
+			}
+			fmt.Fprintf(&b, "[%s] ", strings.Join(codes, ", "))
+		}
+		if lastError.TaskID != nil {
+			fmt.Fprintf(&b, "%s: ", *lastError.TaskID)
+		}
+		b.WriteString(strings.TrimSpace(lastError.Description))
+	}
+	return b.String()
+}
+
+// shootKey returns the namespace and name of the shoot
+func shootKey(shoot *gardencorev1beta1.Shoot) string {
+	return shoot.Namespace + "/" + shoot.Name
+}
+
+// shootOperationWriter returns the writer the progress of the operations on the shoots is reported to. The progress of a
+// single shoot is updated in place on a terminal, the writes of concurrent operations are serialized line by line.
+func shootOperationWriter(writer io.Writer, shoots []gardencorev1beta1.Shoot) io.Writer {
+	if len(shoots) == 1 {
+		return writer
+	}
+	return &syncWriter{writer: writer}
+}
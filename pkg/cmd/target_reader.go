@@ -18,7 +18,10 @@ import (
 	"errors"
 
 	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	machineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // ReadTarget returns the current target.
@@ -76,3 +79,23 @@ func (t *Target) K8SClientToKind(kind TargetKind) (kubernetes.Interface, error)
 func (t *Target) GardenerClient() (gardencoreclientset.Interface, error) {
 	return gardencoreclientset.NewForConfig(NewConfigFromBytes(getKubeConfigOfClusterType(TargetKindGarden)))
 }
+
+// MachineClient returns a machine-controller-manager client configured against the seed of the current target
+func (t *Target) MachineClient() (machineclientset.Interface, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", getKubeConfigOfClusterType(TargetKindSeed))
+	if err != nil {
+		return nil, err
+	}
+
+	return machineclientset.NewForConfig(config)
+}
+
+// MetricsClient returns a metrics client configured against the shoot of the current target
+func (t *Target) MetricsClient() (metricsclientset.Interface, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", getKubeConfigOfClusterType(TargetKindShoot))
+	if err != nil {
+		return nil, err
+	}
+
+	return metricsclientset.NewForConfig(config)
+}
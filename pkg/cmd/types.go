@@ -15,8 +15,13 @@
 package cmd
 
 import (
+	"io"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	machineclientset "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // TargetReader reads the current target.
@@ -44,6 +49,27 @@ type HistoryWriter interface {
 	WriteStringln(path string, history interface{}) error
 }
 
+// Prompter asks the user for input.
+type Prompter interface {
+	Select(label string, items []string) (int, error)
+	Input(label, defaultValue string) (string, error)
+}
+
+// Editor lets the user edit a file.
+type Editor interface {
+	Edit(path string) error
+}
+
+// PortForwarder forwards local ports to pods of the clusters of the target.
+type PortForwarder interface {
+	ForwardPort(kind TargetKind, namespace, pod string, port int) (localPort int, stop func(), err error)
+}
+
+// PodExecutor runs commands in containers of pods of the clusters of the target.
+type PodExecutor interface {
+	Exec(kind TargetKind, namespace, pod, container string, command []string, stdout, stderr io.Writer) error
+}
+
 // GardenctlTargetReader implements TargetReader.
 type GardenctlTargetReader struct{}
 
@@ -59,6 +85,18 @@ type GardenctlKubeconfigWriter struct{}
 // GardenctlHistoryWriter implements HistoryWriter.
 type GardenctlHistoryWriter struct{}
 
+// GardenctlPrompter implements Prompter.
+type GardenctlPrompter struct{}
+
+// GardenctlEditor implements Editor.
+type GardenctlEditor struct{}
+
+// GardenctlPortForwarder implements PortForwarder.
+type GardenctlPortForwarder struct{}
+
+// GardenctlPodExecutor implements PodExecutor.
+type GardenctlPodExecutor struct{}
+
 // TargetInterface defines target operations.
 type TargetInterface interface {
 	Stack() []TargetMeta
@@ -67,6 +105,8 @@ type TargetInterface interface {
 	K8SClient() (kubernetes.Interface, error)
 	K8SClientToKind(TargetKind) (kubernetes.Interface, error)
 	GardenerClient() (gardencoreclientset.Interface, error)
+	MachineClient() (machineclientset.Interface, error)
+	MetricsClient() (metricsclientset.Interface, error)
 }
 
 // Target contains the current target.
@@ -162,18 +202,81 @@ type AccessRestriction struct {
 	Options  []AccessRestrictionsOption `yaml:"options,omitempty" json:"options,omitempty"`
 }
 
+// Severity classifies how urgent an issue is.
+type Severity string
+
+// These are valid severities.
+const (
+	// SeverityInfo marks an issue that needs no action, e.g. an operation in progress.
+	SeverityInfo Severity = "info"
+	// SeverityWarning marks an issue that will be retried or may resolve itself.
+	SeverityWarning Severity = "warning"
+	// SeverityError marks an issue that requires manual intervention.
+	SeverityError Severity = "error"
+)
+
 // Issues contains all projects with issues
 type Issues struct {
-	Issues []IssuesMeta `yaml:"issues,omitempty" json:"issues,omitempty"`
+	Issues       []IssuesMeta      `yaml:"issues,omitempty" json:"issues,omitempty"`
+	BackupIssues []BackupIssueMeta `yaml:"backupIssues,omitempty" json:"backupIssues,omitempty"`
 }
 
 // IssuesMeta contains project related informations
 type IssuesMeta struct {
-	Project string     `yaml:"project,omitempty" json:"project,omitempty"`
-	Seed    string     `yaml:"seed,omitempty" json:"seed,omitempty"`
-	Shoot   string     `yaml:"shoot,omitempty" json:"shoot,omitempty"`
-	Health  string     `yaml:"health,omitempty" json:"health,omitempty"`
-	Status  StatusMeta `yaml:"status,omitempty" json:"status,omitempty"`
+	Project  string     `yaml:"project,omitempty" json:"project,omitempty"`
+	Seed     string     `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Shoot    string     `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	Health   string     `yaml:"health,omitempty" json:"health,omitempty"`
+	Severity Severity   `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Status   StatusMeta `yaml:"status,omitempty" json:"status,omitempty"`
+}
+
+// BackupIssueMeta contains information about a failing backup bucket or backup entry
+type BackupIssueMeta struct {
+	Kind      string     `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Name      string     `yaml:"name,omitempty" json:"name,omitempty"`
+	Namespace string     `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Seed      string     `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Severity  Severity   `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Status    StatusMeta `yaml:"status,omitempty" json:"status,omitempty"`
+}
+
+// BackupBuckets contains list of backup buckets
+type BackupBuckets struct {
+	BackupBuckets []BackupBucketMeta `yaml:"backupBuckets,omitempty" json:"backupBuckets,omitempty"`
+}
+
+// BackupBucketMeta contains the operator relevant information of a backup bucket
+type BackupBucketMeta struct {
+	Name          string            `yaml:"name,omitempty" json:"name,omitempty"`
+	Seed          string            `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Provider      string            `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Region        string            `yaml:"region,omitempty" json:"region,omitempty"`
+	LastOperation LastOperationMeta `yaml:"lastOperation,omitempty" json:"lastOperation,omitempty"`
+	LastError     string            `yaml:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// BackupEntries contains list of backup entries
+type BackupEntries struct {
+	BackupEntries []BackupEntryMeta `yaml:"backupEntries,omitempty" json:"backupEntries,omitempty"`
+}
+
+// BackupEntryMeta contains the operator relevant information of a backup entry
+type BackupEntryMeta struct {
+	Name          string            `yaml:"name,omitempty" json:"name,omitempty"`
+	Project       string            `yaml:"project,omitempty" json:"project,omitempty"`
+	Bucket        string            `yaml:"bucket,omitempty" json:"bucket,omitempty"`
+	Seed          string            `yaml:"seed,omitempty" json:"seed,omitempty"`
+	LastOperation LastOperationMeta `yaml:"lastOperation,omitempty" json:"lastOperation,omitempty"`
+	LastError     string            `yaml:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// ShootBackup contains the backup entry of a shoot together with its bucket
+type ShootBackup struct {
+	Shoot  string            `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	Entry  BackupEntryMeta   `yaml:"backupEntry,omitempty" json:"backupEntry,omitempty"`
+	Bucket BackupBucketMeta  `yaml:"backupBucket,omitempty" json:"backupBucket,omitempty"`
+	Issues []BackupIssueMeta `yaml:"issues,omitempty" json:"issues,omitempty"`
 }
 
 // StatusMeta contains status for a project
@@ -190,3 +293,469 @@ type LastOperationMeta struct {
 	State          string `yaml:"state,omitempty" json:"state,omitempty"`
 	Type           string `yaml:"type,omitempty" json:"type,omitempty"`
 }
+
+// Extensions contains the installation state of all registered extensions per seed
+type Extensions struct {
+	Seeds      []string        `yaml:"seeds,omitempty" json:"seeds,omitempty"`
+	Extensions []ExtensionMeta `yaml:"extensions,omitempty" json:"extensions,omitempty"`
+}
+
+// ExtensionMeta contains a registered extension resource and its installations
+type ExtensionMeta struct {
+	Registration  string                      `yaml:"registration,omitempty" json:"registration,omitempty"`
+	Kind          string                      `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Type          string                      `yaml:"type,omitempty" json:"type,omitempty"`
+	Installations []ExtensionInstallationMeta `yaml:"installations,omitempty" json:"installations,omitempty"`
+}
+
+// ExtensionInstallationMeta contains the conditions of an extension installation on a seed
+type ExtensionInstallationMeta struct {
+	Seed      string `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Installed string `yaml:"installed,omitempty" json:"installed,omitempty"`
+	Healthy   string `yaml:"healthy,omitempty" json:"healthy,omitempty"`
+	Message   string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// ConditionMeta contains the relevant information of a condition
+type ConditionMeta struct {
+	Type               string `yaml:"type,omitempty" json:"type,omitempty"`
+	Status             string `yaml:"status,omitempty" json:"status,omitempty"`
+	Reason             string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message            string `yaml:"message,omitempty" json:"message,omitempty"`
+	LastTransitionTime string `yaml:"lastTransitionTime,omitempty" json:"lastTransitionTime,omitempty"`
+}
+
+// Machines contains the machine deployments, machine sets and machines of a shoot
+type Machines struct {
+	MachineDeployments []MachineDeploymentMeta `yaml:"machineDeployments,omitempty" json:"machineDeployments,omitempty"`
+	MachineSets        []MachineSetMeta        `yaml:"machineSets,omitempty" json:"machineSets,omitempty"`
+	Machines           []MachineMeta           `yaml:"machines,omitempty" json:"machines,omitempty"`
+}
+
+// MachineDeploymentMeta contains the replica counts of a machine deployment
+type MachineDeploymentMeta struct {
+	Name                string `yaml:"name,omitempty" json:"name,omitempty"`
+	WorkerPool          string `yaml:"workerPool,omitempty" json:"workerPool,omitempty"`
+	Replicas            int32  `yaml:"replicas" json:"replicas"`
+	ReadyReplicas       int32  `yaml:"readyReplicas" json:"readyReplicas"`
+	UpdatedReplicas     int32  `yaml:"updatedReplicas" json:"updatedReplicas"`
+	AvailableReplicas   int32  `yaml:"availableReplicas" json:"availableReplicas"`
+	UnavailableReplicas int32  `yaml:"unavailableReplicas" json:"unavailableReplicas"`
+}
+
+// MachineSetMeta contains the replica counts of a machine set
+type MachineSetMeta struct {
+	Name              string `yaml:"name,omitempty" json:"name,omitempty"`
+	MachineDeployment string `yaml:"machineDeployment,omitempty" json:"machineDeployment,omitempty"`
+	WorkerPool        string `yaml:"workerPool,omitempty" json:"workerPool,omitempty"`
+	Replicas          int32  `yaml:"replicas" json:"replicas"`
+	ReadyReplicas     int32  `yaml:"readyReplicas" json:"readyReplicas"`
+	AvailableReplicas int32  `yaml:"availableReplicas" json:"availableReplicas"`
+}
+
+// MachineMeta contains the operator relevant information of a machine
+type MachineMeta struct {
+	Name              string            `yaml:"name,omitempty" json:"name,omitempty"`
+	WorkerPool        string            `yaml:"workerPool,omitempty" json:"workerPool,omitempty"`
+	MachineSet        string            `yaml:"machineSet,omitempty" json:"machineSet,omitempty"`
+	MachineDeployment string            `yaml:"machineDeployment,omitempty" json:"machineDeployment,omitempty"`
+	Phase             string            `yaml:"phase,omitempty" json:"phase,omitempty"`
+	Node              string            `yaml:"node,omitempty" json:"node,omitempty"`
+	ProviderID        string            `yaml:"providerID,omitempty" json:"providerID,omitempty"`
+	CreationTimestamp string            `yaml:"creationTimestamp,omitempty" json:"creationTimestamp,omitempty"`
+	LastOperation     LastOperationMeta `yaml:"lastOperation,omitempty" json:"lastOperation,omitempty"`
+	LastError         string            `yaml:"lastError,omitempty" json:"lastError,omitempty"`
+	Conditions        []ConditionMeta   `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+}
+
+// ProjectMembers contains list of project members
+type ProjectMembers struct {
+	Members []ProjectMemberMeta `yaml:"members,omitempty" json:"members,omitempty"`
+}
+
+// ProjectMemberMeta contains a project member and its roles
+type ProjectMemberMeta struct {
+	Project string   `yaml:"project,omitempty" json:"project,omitempty"`
+	Kind    string   `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Name    string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Roles   []string `yaml:"roles,omitempty" json:"roles,omitempty"`
+}
+
+// ExpandedShoot contains a shoot together with its related objects
+type ExpandedShoot struct {
+	Shoot         *gardencorev1beta1.Shoot         `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	Project       *ProjectSummary                  `yaml:"project,omitempty" json:"project,omitempty"`
+	Seed          *SeedSummary                     `yaml:"seed,omitempty" json:"seed,omitempty"`
+	SecretBinding *gardencorev1beta1.SecretBinding `yaml:"secretBinding,omitempty" json:"secretBinding,omitempty"`
+	CloudProfile  *CloudProfileSummary             `yaml:"cloudProfile,omitempty" json:"cloudProfile,omitempty"`
+}
+
+// ProjectSummary contains the name and namespace of a project
+type ProjectSummary struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+// SeedSummary contains the operator relevant information of a seed
+type SeedSummary struct {
+	Name          string          `yaml:"name,omitempty" json:"name,omitempty"`
+	Provider      string          `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Region        string          `yaml:"region,omitempty" json:"region,omitempty"`
+	IngressDomain string          `yaml:"ingressDomain,omitempty" json:"ingressDomain,omitempty"`
+	Taints        []string        `yaml:"taints,omitempty" json:"taints,omitempty"`
+	Conditions    []ConditionMeta `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+}
+
+// CloudProfileSummary contains the parts of a cloud profile which are relevant for a shoot
+type CloudProfileSummary struct {
+	Name               string                               `yaml:"name,omitempty" json:"name,omitempty"`
+	Type               string                               `yaml:"type,omitempty" json:"type,omitempty"`
+	KubernetesVersions []gardencorev1beta1.ExpirableVersion `yaml:"kubernetesVersions,omitempty" json:"kubernetesVersions,omitempty"`
+	MachineImages      []gardencorev1beta1.MachineImage     `yaml:"machineImages,omitempty" json:"machineImages,omitempty"`
+	Region             *gardencorev1beta1.Region            `yaml:"region,omitempty" json:"region,omitempty"`
+}
+
+// ExpandedSecretBinding contains a secret binding together with its quotas
+type ExpandedSecretBinding struct {
+	SecretBinding *gardencorev1beta1.SecretBinding `yaml:"secretBinding,omitempty" json:"secretBinding,omitempty"`
+	Quotas        []gardencorev1beta1.Quota        `yaml:"quotas,omitempty" json:"quotas,omitempty"`
+}
+
+// ExpandedControllerInstallation contains a controller installation together with its registration and seed
+type ExpandedControllerInstallation struct {
+	ControllerInstallation *gardencorev1beta1.ControllerInstallation `yaml:"controllerInstallation,omitempty" json:"controllerInstallation,omitempty"`
+	ControllerRegistration *gardencorev1beta1.ControllerRegistration `yaml:"controllerRegistration,omitempty" json:"controllerRegistration,omitempty"`
+	Seed                   *SeedSummary                              `yaml:"seed,omitempty" json:"seed,omitempty"`
+}
+
+// KubernetesVersions contains the Kubernetes versions a shoot can be upgraded to
+type KubernetesVersions struct {
+	Shoot        string                  `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	CloudProfile string                  `yaml:"cloudProfile,omitempty" json:"cloudProfile,omitempty"`
+	Current      string                  `yaml:"current,omitempty" json:"current,omitempty"`
+	Versions     []KubernetesVersionMeta `yaml:"versions,omitempty" json:"versions,omitempty"`
+}
+
+// KubernetesVersionMeta contains a Kubernetes version of a cloud profile and whether a shoot can be upgraded to it
+type KubernetesVersionMeta struct {
+	Version        string `yaml:"version,omitempty" json:"version,omitempty"`
+	Classification string `yaml:"classification,omitempty" json:"classification,omitempty"`
+	ExpirationDate string `yaml:"expirationDate,omitempty" json:"expirationDate,omitempty"`
+	Upgrade        string `yaml:"upgrade,omitempty" json:"upgrade,omitempty"`
+}
+
+// WorkerPools contains the worker pools of a shoot
+type WorkerPools struct {
+	Shoot string           `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	Pools []WorkerPoolMeta `yaml:"pools,omitempty" json:"pools,omitempty"`
+}
+
+// WorkerPoolMeta contains the scaling and machine settings of a worker pool
+type WorkerPoolMeta struct {
+	Name           string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Minimum        int32    `yaml:"minimum" json:"minimum"`
+	Maximum        int32    `yaml:"maximum" json:"maximum"`
+	MaxSurge       string   `yaml:"maxSurge,omitempty" json:"maxSurge,omitempty"`
+	MaxUnavailable string   `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+	MachineType    string   `yaml:"machineType,omitempty" json:"machineType,omitempty"`
+	Image          string   `yaml:"image,omitempty" json:"image,omitempty"`
+	ImageVersion   string   `yaml:"imageVersion,omitempty" json:"imageVersion,omitempty"`
+	Zones          []string `yaml:"zones,omitempty" json:"zones,omitempty"`
+	VolumeName     string   `yaml:"volumeName,omitempty" json:"volumeName,omitempty"`
+	VolumeType     string   `yaml:"volumeType,omitempty" json:"volumeType,omitempty"`
+	VolumeSize     string   `yaml:"volumeSize,omitempty" json:"volumeSize,omitempty"`
+}
+
+// HibernationSchedules contains the hibernation schedules of shoots
+type HibernationSchedules struct {
+	Timezone string                 `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	Shoots   []ShootHibernationMeta `yaml:"shoots,omitempty" json:"shoots,omitempty"`
+}
+
+// ShootHibernationMeta contains the hibernation schedules of a shoot, when it is hibernated and woken up next,
+// and whether its state matches the schedules
+type ShootHibernationMeta struct {
+	Shoot      string                    `yaml:"shoot,omitempty" json:"shoot,omitempty"`
+	Hibernated bool                      `yaml:"hibernated" json:"hibernated"`
+	Expected   string                    `yaml:"expected,omitempty" json:"expected,omitempty"`
+	Warning    string                    `yaml:"warning,omitempty" json:"warning,omitempty"`
+	NextSleep  string                    `yaml:"nextSleep,omitempty" json:"nextSleep,omitempty"`
+	NextWake   string                    `yaml:"nextWake,omitempty" json:"nextWake,omitempty"`
+	Schedules  []HibernationScheduleMeta `yaml:"schedules,omitempty" json:"schedules,omitempty"`
+}
+
+// HibernationScheduleMeta contains a hibernation schedule and its next start and end
+type HibernationScheduleMeta struct {
+	Start     string `yaml:"start,omitempty" json:"start,omitempty"`
+	End       string `yaml:"end,omitempty" json:"end,omitempty"`
+	Location  string `yaml:"location,omitempty" json:"location,omitempty"`
+	NextSleep string `yaml:"nextSleep,omitempty" json:"nextSleep,omitempty"`
+	NextWake  string `yaml:"nextWake,omitempty" json:"nextWake,omitempty"`
+}
+
+// DiagReport contains the diagnostic information of a shoot, sections which could not be collected are listed as unavailable
+type DiagReport struct {
+	Shoot                DiagShootSummary   `yaml:"shoot" json:"shoot"`
+	LastOperation        *DiagLastOperation `yaml:"lastOperation,omitempty" json:"lastOperation,omitempty"`
+	Conditions           []DiagCondition    `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	Workers              []WorkerPoolMeta   `yaml:"workers,omitempty" json:"workers,omitempty"`
+	Nodes                []DiagNode         `yaml:"nodes,omitempty" json:"nodes,omitempty"`
+	NodeMetrics          []DiagNodeMetrics  `yaml:"nodeMetrics,omitempty" json:"nodeMetrics,omitempty"`
+	SystemPods           []DiagPod          `yaml:"systemPods,omitempty" json:"systemPods,omitempty"`
+	DaemonSets           []DiagDaemonSet    `yaml:"daemonSets,omitempty" json:"daemonSets,omitempty"`
+	PodDisruptionBudgets []DiagPDB          `yaml:"podDisruptionBudgets,omitempty" json:"podDisruptionBudgets,omitempty"`
+	Webhooks             []DiagWebhook      `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	ControlPlanePods     []DiagPod          `yaml:"controlPlanePods,omitempty" json:"controlPlanePods,omitempty"`
+	Findings             []DiagFinding      `yaml:"findings,omitempty" json:"findings,omitempty"`
+	Unavailable          []DiagUnavailable  `yaml:"unavailable,omitempty" json:"unavailable,omitempty"`
+}
+
+// DiagShootSummary contains the general information of a shoot
+type DiagShootSummary struct {
+	Name              string `yaml:"name" json:"name"`
+	Namespace         string `yaml:"namespace" json:"namespace"`
+	KubernetesVersion string `yaml:"kubernetesVersion,omitempty" json:"kubernetesVersion,omitempty"`
+	CreatedAt         string `yaml:"createdAt,omitempty" json:"createdAt,omitempty"`
+	CreatedBy         string `yaml:"createdBy,omitempty" json:"createdBy,omitempty"`
+	CloudProfile      string `yaml:"cloudProfile,omitempty" json:"cloudProfile,omitempty"`
+	Region            string `yaml:"region,omitempty" json:"region,omitempty"`
+	Purpose           string `yaml:"purpose,omitempty" json:"purpose,omitempty"`
+	Seed              string `yaml:"seed,omitempty" json:"seed,omitempty"`
+	TechnicalID       string `yaml:"technicalID,omitempty" json:"technicalID,omitempty"`
+	Hibernated        bool   `yaml:"hibernated" json:"hibernated"`
+}
+
+// DiagLastOperation contains the last operation of a shoot
+type DiagLastOperation struct {
+	Type        string `yaml:"type" json:"type"`
+	State       string `yaml:"state" json:"state"`
+	Progress    int32  `yaml:"progress" json:"progress"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	LastUpdate  string `yaml:"lastUpdate,omitempty" json:"lastUpdate,omitempty"`
+}
+
+// DiagCondition contains a condition of a shoot
+type DiagCondition struct {
+	Type               string   `yaml:"type" json:"type"`
+	Status             string   `yaml:"status" json:"status"`
+	Reason             string   `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message            string   `yaml:"message,omitempty" json:"message,omitempty"`
+	LastTransitionTime string   `yaml:"lastTransitionTime,omitempty" json:"lastTransitionTime,omitempty"`
+	Codes              []string `yaml:"codes,omitempty" json:"codes,omitempty"`
+}
+
+// DiagNode contains the capacity and readiness of a node
+type DiagNode struct {
+	Name       string   `yaml:"name" json:"name"`
+	ProviderID string   `yaml:"providerID,omitempty" json:"providerID,omitempty"`
+	InternalIP string   `yaml:"internalIP,omitempty" json:"internalIP,omitempty"`
+	Ready      string   `yaml:"ready" json:"ready"`
+	CPU        string   `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	Memory     string   `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Pressure   []string `yaml:"pressure,omitempty" json:"pressure,omitempty"`
+}
+
+// DiagNodeMetrics contains the resource usage of a node
+type DiagNodeMetrics struct {
+	Name   string `yaml:"name" json:"name"`
+	CPU    string `yaml:"cpu" json:"cpu"`
+	Memory string `yaml:"memory" json:"memory"`
+}
+
+// DiagPod contains the status of a pod
+type DiagPod struct {
+	Name            string `yaml:"name" json:"name"`
+	Phase           string `yaml:"phase" json:"phase"`
+	Reason          string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Ready           bool   `yaml:"ready" json:"ready"`
+	ReadyContainers int    `yaml:"readyContainers" json:"readyContainers"`
+	Containers      int    `yaml:"containers" json:"containers"`
+	Restarts        int32  `yaml:"restarts" json:"restarts"`
+	Node            string `yaml:"node,omitempty" json:"node,omitempty"`
+	CreatedAt       string `yaml:"createdAt,omitempty" json:"createdAt,omitempty"`
+}
+
+// DiagDaemonSet contains the scheduling status of a daemon set
+type DiagDaemonSet struct {
+	Name      string `yaml:"name" json:"name"`
+	Desired   int32  `yaml:"desired" json:"desired"`
+	Current   int32  `yaml:"current" json:"current"`
+	Ready     int32  `yaml:"ready" json:"ready"`
+	Available int32  `yaml:"available" json:"available"`
+}
+
+// DiagPDB contains the settings and status of a pod disruption budget
+type DiagPDB struct {
+	Namespace          string `yaml:"namespace" json:"namespace"`
+	Name               string `yaml:"name" json:"name"`
+	Selector           string `yaml:"selector,omitempty" json:"selector,omitempty"`
+	MinAvailable       string `yaml:"minAvailable,omitempty" json:"minAvailable,omitempty"`
+	MaxUnavailable     string `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+	CurrentHealthy     int32  `yaml:"currentHealthy" json:"currentHealthy"`
+	DesiredHealthy     int32  `yaml:"desiredHealthy" json:"desiredHealthy"`
+	DisruptionsAllowed int32  `yaml:"disruptionsAllowed" json:"disruptionsAllowed"`
+}
+
+// DiagWebhook contains an admission webhook of a shoot
+type DiagWebhook struct {
+	Kind              string `yaml:"kind" json:"kind"`
+	Configuration     string `yaml:"configuration" json:"configuration"`
+	Name              string `yaml:"name" json:"name"`
+	FailurePolicy     string `yaml:"failurePolicy,omitempty" json:"failurePolicy,omitempty"`
+	NamespaceSelector string `yaml:"namespaceSelector,omitempty" json:"namespaceSelector,omitempty"`
+	ObjectSelector    string `yaml:"objectSelector,omitempty" json:"objectSelector,omitempty"`
+	MatchesKubeSystem bool   `yaml:"matchesKubeSystem" json:"matchesKubeSystem"`
+}
+
+// DiagUnavailable names a section of the diagnostic report which could not be collected and why
+type DiagUnavailable struct {
+	Section string `yaml:"section" json:"section"`
+	Reason  string `yaml:"reason" json:"reason"`
+}
+
+// DiagFinding is a problem found by a rule in the diagnostic report
+type DiagFinding struct {
+	Rule        string   `yaml:"rule" json:"rule"`
+	Severity    Severity `yaml:"severity" json:"severity"`
+	Object      string   `yaml:"object" json:"object"`
+	Message     string   `yaml:"message" json:"message"`
+	Remediation string   `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+}
+
+// DiagRule checks the diagnostic report of a shoot for a problem.
+type DiagRule interface {
+	// Name returns the unique name of the rule
+	Name() string
+	// Evaluate returns the findings of the rule in the report
+	Evaluate(report *DiagReport) []DiagFinding
+}
+
+// EventMeta contains an event and the cluster it was read from
+type EventMeta struct {
+	Source    string `yaml:"source" json:"source"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Object    string `yaml:"object" json:"object"`
+	Type      string `yaml:"type" json:"type"`
+	Reason    string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message   string `yaml:"message,omitempty" json:"message,omitempty"`
+	Count     int32  `yaml:"count" json:"count"`
+	FirstSeen string `yaml:"firstSeen,omitempty" json:"firstSeen,omitempty"`
+	LastSeen  string `yaml:"lastSeen,omitempty" json:"lastSeen,omitempty"`
+}
+
+// DiagBundleIndex lists what was collected into a diagnostic bundle and what could not be collected
+type DiagBundleIndex struct {
+	Shoot     string           `yaml:"shoot" json:"shoot"`
+	CreatedAt string           `yaml:"createdAt" json:"createdAt"`
+	Items     []DiagBundleItem `yaml:"items" json:"items"`
+}
+
+// DiagBundleItem is an item of a diagnostic bundle
+type DiagBundleItem struct {
+	Path      string `yaml:"path" json:"path"`
+	Collected bool   `yaml:"collected" json:"collected"`
+	Error     string `yaml:"error,omitempty" json:"error,omitempty"`
+	Bytes     int    `yaml:"bytes" json:"bytes"`
+	Duration  string `yaml:"duration,omitempty" json:"duration,omitempty"`
+}
+
+// TerraformStateMeta contains the metadata of a terraform state without its resources and outputs
+type TerraformStateMeta struct {
+	Name             string   `yaml:"name" json:"name"`
+	ResourceVersion  string   `yaml:"resourceVersion,omitempty" json:"resourceVersion,omitempty"`
+	Bytes            int      `yaml:"bytes" json:"bytes"`
+	Version          int      `yaml:"version,omitempty" json:"version,omitempty"`
+	TerraformVersion string   `yaml:"terraformVersion,omitempty" json:"terraformVersion,omitempty"`
+	Serial           int      `yaml:"serial,omitempty" json:"serial,omitempty"`
+	Lineage          string   `yaml:"lineage,omitempty" json:"lineage,omitempty"`
+	Resources        int      `yaml:"resources" json:"resources"`
+	Outputs          []string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	Error            string   `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// ExtensionResourceMeta contains the status of an extension resource in the control plane namespace of a shoot
+type ExtensionResourceMeta struct {
+	Resource           string                 `yaml:"resource" json:"resource"`
+	Name               string                 `yaml:"name" json:"name"`
+	Type               string                 `yaml:"type,omitempty" json:"type,omitempty"`
+	ObservedGeneration int64                  `yaml:"observedGeneration,omitempty" json:"observedGeneration,omitempty"`
+	LastOperation      map[string]interface{} `yaml:"lastOperation,omitempty" json:"lastOperation,omitempty"`
+	LastError          map[string]interface{} `yaml:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// SeedDiagReport contains the diagnostic information of a seed, sections which could not be collected are listed as unavailable
+type SeedDiagReport struct {
+	Seed                    DiagSeedSummary              `yaml:"seed" json:"seed"`
+	Conditions              []DiagCondition              `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	GardenletPods           []DiagPod                    `yaml:"gardenletPods,omitempty" json:"gardenletPods,omitempty"`
+	ControllerInstallations []DiagControllerInstallation `yaml:"controllerInstallations,omitempty" json:"controllerInstallations,omitempty"`
+	Nodes                   []DiagNode                   `yaml:"nodes,omitempty" json:"nodes,omitempty"`
+	FailingNamespaces       []DiagFailingNamespace       `yaml:"failingNamespaces,omitempty" json:"failingNamespaces,omitempty"`
+	FailedShoots            []DiagFailedShoot            `yaml:"failedShoots,omitempty" json:"failedShoots,omitempty"`
+	Unavailable             []DiagUnavailable            `yaml:"unavailable,omitempty" json:"unavailable,omitempty"`
+}
+
+// DiagSeedSummary contains the general information of a seed
+type DiagSeedSummary struct {
+	Name              string   `yaml:"name" json:"name"`
+	Provider          string   `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Region            string   `yaml:"region,omitempty" json:"region,omitempty"`
+	KubernetesVersion string   `yaml:"kubernetesVersion,omitempty" json:"kubernetesVersion,omitempty"`
+	GardenerVersion   string   `yaml:"gardenerVersion,omitempty" json:"gardenerVersion,omitempty"`
+	Taints            []string `yaml:"taints,omitempty" json:"taints,omitempty"`
+	Shoots            int      `yaml:"shoots" json:"shoots"`
+}
+
+// DiagControllerInstallation contains the health of an extension controller on a seed
+type DiagControllerInstallation struct {
+	Name         string `yaml:"name" json:"name"`
+	Registration string `yaml:"registration" json:"registration"`
+	Installed    string `yaml:"installed" json:"installed"`
+	Healthy      string `yaml:"healthy" json:"healthy"`
+	Message      string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// DiagFailingNamespace contains the pods of a control plane namespace which are not ready
+type DiagFailingNamespace struct {
+	Namespace string    `yaml:"namespace" json:"namespace"`
+	Pods      []DiagPod `yaml:"pods" json:"pods"`
+}
+
+// DiagFailedShoot contains the failed last operation of a shoot
+type DiagFailedShoot struct {
+	Shoot       string `yaml:"shoot" json:"shoot"`
+	Type        string `yaml:"type" json:"type"`
+	State       string `yaml:"state" json:"state"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	LastUpdate  string `yaml:"lastUpdate,omitempty" json:"lastUpdate,omitempty"`
+}
+
+// CertificateMeta contains the expiry of a certificate of a shoot and where it was found
+type CertificateMeta struct {
+	Shoot       string `yaml:"shoot" json:"shoot"`
+	Source      string `yaml:"source" json:"source"`
+	Certificate string `yaml:"certificate" json:"certificate"`
+	Subject     string `yaml:"subject" json:"subject"`
+	NotAfter    string `yaml:"notAfter" json:"notAfter"`
+	Status      string `yaml:"status" json:"status"`
+}
+
+// EtcdStatus contains the health and the backup status of an etcd of a shoot
+type EtcdStatus struct {
+	Name          string            `yaml:"name" json:"name"`
+	Pod           *DiagPod          `yaml:"pod,omitempty" json:"pod,omitempty"`
+	Member        string            `yaml:"member,omitempty" json:"member,omitempty"`
+	DBSizeBytes   int64             `yaml:"dbSizeBytes,omitempty" json:"dbSizeBytes,omitempty"`
+	FullSnapshot  *EtcdSnapshot     `yaml:"fullSnapshot,omitempty" json:"fullSnapshot,omitempty"`
+	DeltaSnapshot *EtcdSnapshot     `yaml:"deltaSnapshot,omitempty" json:"deltaSnapshot,omitempty"`
+	Unavailable   []DiagUnavailable `yaml:"unavailable,omitempty" json:"unavailable,omitempty"`
+}
+
+// EtcdSnapshot is a snapshot taken by the backup-restore sidecar of an etcd
+type EtcdSnapshot struct {
+	Name          string `yaml:"name" json:"name"`
+	Kind          string `yaml:"kind" json:"kind"`
+	StartRevision int64  `yaml:"startRevision" json:"startRevision"`
+	LastRevision  int64  `yaml:"lastRevision" json:"lastRevision"`
+	CreatedOn     string `yaml:"createdOn" json:"createdOn"`
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Masterminds/semver"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// upgradeAllowed marks a version the shoot can be upgraded to
+	upgradeAllowed = "allowed"
+	// upgradeCurrent marks the current version of the shoot
+	upgradeCurrent = "current"
+)
+
+// kubernetesUpgradeFlags are the flags of the upgrade kubernetes command
+type kubernetesUpgradeFlags struct {
+	shootChangeFlags
+	to        string
+	nextPatch bool
+	nextMinor bool
+	dryRun    bool
+}
+
+// NewUpgradeCmd returns a new upgrade command.
+func NewUpgradeCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the targeted shoot, e.g. \"gardenctl upgrade kubernetes --next-patch\"",
+	}
+	cmd.AddCommand(newUpgradeKubernetesCmd(targetReader, configReader, ioStreams))
+
+	return cmd
+}
+
+func newUpgradeKubernetesCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var flags kubernetesUpgradeFlags
+	cmd := &cobra.Command{
+		Use:          "kubernetes [shoot]",
+		Short:        "Upgrade the Kubernetes version of the targeted or given shoot, or list the versions offered by its cloud profile, e.g. \"gardenctl upgrade kubernetes --to 1.18.2 --dry-run\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("command must be in the format: upgrade kubernetes [shoot] [--to <version>|--next-patch|--next-minor]")
+			}
+			selected := 0
+			for _, set := range []bool{flags.to != "", flags.nextPatch, flags.nextMinor} {
+				if set {
+					selected++
+				}
+			}
+			if selected > 1 {
+				return errors.New("only one of --to, --next-patch and --next-minor can be given")
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, name, "")
+			if err != nil {
+				return err
+			}
+			shoot := &shoots[0]
+			cloudProfile, err := gardenClientset.CoreV1beta1().CloudProfiles().Get(shoot.Spec.CloudProfileName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			versions, err := getKubernetesVersions(shoot, cloudProfile, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if selected == 0 {
+				outFormat := outputFormatOrTable(cmd)
+				if outFormat == tableOutputFormat {
+					return renderKubernetesVersions(versions, ioStreams.Out)
+				}
+				return PrintoutObject(versions, ioStreams.Out, outFormat)
+			}
+
+			version, err := selectKubernetesVersion(versions, flags)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(ioStreams.Out, "Shoot %s:\n  spec:\n    kubernetes:\n-     version: %s\n+     version: %s\n", shootKey(shoot), versions.Current, version)
+			if flags.dryRun {
+				return nil
+			}
+			if err := confirmShootRestrictions(target, configReader, shoots, flags.yes, ioStreams); err != nil {
+				return err
+			}
+
+			return upgradeKubernetesVersion(gardenClientset, shoot, version, flags.shootChangeFlags, ioStreams.Out)
+		},
+	}
+	cmd.Flags().StringVar(&flags.to, "to", "", "upgrade to the given Kubernetes version")
+	cmd.Flags().BoolVar(&flags.nextPatch, "next-patch", false, "upgrade to the latest patch version of the current minor version which is not in preview")
+	cmd.Flags().BoolVar(&flags.nextMinor, "next-minor", false, "upgrade to the latest patch version of the next minor version which is not in preview")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "only show the change to the shoot")
+	flags.addSingleShootFlags(cmd)
+
+	return cmd
+}
+
+// getKubernetesVersions returns the Kubernetes versions of the cloud profile, newest first, and whether the shoot can be upgraded to them
+func getKubernetesVersions(shoot *gardencorev1beta1.Shoot, cloudProfile *gardencorev1beta1.CloudProfile, now time.Time) (*KubernetesVersions, error) {
+	current, err := semver.NewVersion(shoot.Spec.Kubernetes.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubernetes version %q of shoot %s: %v", shoot.Spec.Kubernetes.Version, shootKey(shoot), err)
+	}
+
+	versions := &KubernetesVersions{
+		Shoot:        shootKey(shoot),
+		CloudProfile: cloudProfile.Name,
+		Current:      current.String(),
+	}
+	for _, expirable := range cloudProfile.Spec.Kubernetes.Versions {
+		version, err := semver.NewVersion(expirable.Version)
+		if err != nil {
+			continue
+		}
+		meta := KubernetesVersionMeta{
+			Version:        version.String(),
+			Classification: string(gardencorev1beta1.ClassificationSupported),
+			Upgrade:        kubernetesUpgradeState(current, version, expirable.ExpirationDate, now),
+		}
+		if expirable.Classification != nil {
+			meta.Classification = string(*expirable.Classification)
+		}
+		if expirable.ExpirationDate != nil {
+			meta.ExpirationDate = expirable.ExpirationDate.Format("2006-01-02")
+		}
+		versions.Versions = append(versions.Versions, meta)
+	}
+	sort.SliceStable(versions.Versions, func(i, j int) bool {
+		return semver.MustParse(versions.Versions[i].Version).GreaterThan(semver.MustParse(versions.Versions[j].Version))
+	})
+
+	return versions, nil
+}
+
+// kubernetesUpgradeState returns whether a shoot can be upgraded from the current to the given version, or why not
+func kubernetesUpgradeState(current, version *semver.Version, expirationDate *metav1.Time, now time.Time) string {
+	switch {
+	case version.Equal(current):
+		return upgradeCurrent
+	case version.LessThan(current):
+		return "downgrade"
+	case version.Major() != current.Major():
+		return "major upgrade"
+	case version.Minor() > current.Minor()+1:
+		return "skips minor version"
+	case expirationDate != nil && expirationDate.Time.Before(now):
+		return "expired"
+	}
+	return upgradeAllowed
+}
+
+// selectKubernetesVersion returns the version requested by the flags if the shoot can be upgraded to it
+func selectKubernetesVersion(versions *KubernetesVersions, flags kubernetesUpgradeFlags) (string, error) {
+	if flags.to != "" {
+		to := flags.to
+		if parsed, err := semver.NewVersion(to); err == nil {
+			to = parsed.String()
+		}
+		for _, version := range versions.Versions {
+			if version.Version != to {
+				continue
+			}
+			if version.Upgrade != upgradeAllowed {
+				return "", fmt.Errorf("cannot upgrade shoot %s from %s to %s: %s", versions.Shoot, versions.Current, version.Version, version.Upgrade)
+			}
+			return version.Version, nil
+		}
+		return "", fmt.Errorf("version %s is not offered by cloud profile %s", flags.to, versions.CloudProfile)
+	}
+
+	current := semver.MustParse(versions.Current)
+	minor, kind := current.Minor(), "patch"
+	if flags.nextMinor {
+		minor, kind = current.Minor()+1, "minor"
+	}
+	// versions are sorted newest first
+	for _, version := range versions.Versions {
+		v := semver.MustParse(version.Version)
+		if v.Minor() == minor && version.Upgrade == upgradeAllowed && version.Classification != string(gardencorev1beta1.ClassificationPreview) {
+			return version.Version, nil
+		}
+	}
+	return "", fmt.Errorf("cloud profile %s offers no newer %s version for Kubernetes %s", versions.CloudProfile, kind, versions.Current)
+}
+
+// upgradeKubernetesVersion sets the Kubernetes version of the shoot and optionally waits for the reconciliation
+func upgradeKubernetesVersion(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, version string, flags shootChangeFlags, writer io.Writer) error {
+	patch := fmt.Sprintf(`{"spec":{"kubernetes":{"version":%q}}}`, version)
+	patched, err := gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Patch(shoot.Name, types.MergePatchType, []byte(patch))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Shoot %s will be upgraded to Kubernetes %s\n", shootKey(shoot), version)
+	if !flags.wait {
+		return nil
+	}
+
+	if _, err := waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, lastOperationSucceeded(patched.Generation), flags.timeout, writer); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Shoot %s has been upgraded to Kubernetes %s\n", shootKey(shoot), version)
+
+	return nil
+}
+
+// renderKubernetesVersions renders a table of the Kubernetes versions with their classification, expiration date and upgrade state
+func renderKubernetesVersions(versions *KubernetesVersions, writer io.Writer) error {
+	fmt.Fprintf(writer, "Shoot %s runs Kubernetes %s, versions offered by cloud profile %s:\n", versions.Shoot, versions.Current, versions.CloudProfile)
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCLASSIFICATION\tEXPIRATION\tUPGRADE")
+	for _, version := range versions.Versions {
+		expirationDate := version.ExpirationDate
+		if expirationDate == "" {
+			expirationDate = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", version.Version, version.Classification, expirationDate, version.Upgrade)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"time"
+
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Upgrade kubernetes command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+	)
+
+	projectNamespace := "garden-prod"
+	preview := gardencorev1beta1.ClassificationPreview
+	deprecated := gardencorev1beta1.ClassificationDeprecated
+	expired := metav1.NewTime(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	expiring := metav1.NewTime(time.Date(2099, 6, 30, 0, 0, 0, 0, time.UTC))
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewUpgradeCmd(targetReader, configReader, ioStreams)
+		command.SetArgs(append([]string{"kubernetes"}, args...))
+		err := command.Execute()
+		return out.String(), err
+	}
+	shootVersion := func() string {
+		shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return shoot.Spec.Kubernetes.Version
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			&gardencorev1beta1.Shoot{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+				Spec: gardencorev1beta1.ShootSpec{
+					CloudProfileName: "aws",
+					Kubernetes:       gardencorev1beta1.Kubernetes{Version: "1.17.5"},
+				},
+				Status: gardencorev1beta1.ShootStatus{
+					LastOperation: &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+				},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws"},
+				Spec: gardencorev1beta1.CloudProfileSpec{
+					Kubernetes: gardencorev1beta1.KubernetesSettings{
+						Versions: []gardencorev1beta1.ExpirableVersion{
+							{Version: "1.16.9"},
+							{Version: "1.17.5", ExpirationDate: &expiring, Classification: &deprecated},
+							{Version: "1.17.7", ExpirationDate: &expired, Classification: &deprecated},
+							{Version: "1.17.9"},
+							{Version: "1.17.10", Classification: &preview},
+							{Version: "1.18.2"},
+							{Version: "1.18.5", Classification: &preview},
+							{Version: "1.19.1"},
+						},
+					},
+				},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list the offered versions with their upgrade state", func() {
+		out, err := execute()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`Shoot garden-prod/test-shoot runs Kubernetes 1.17.5, versions offered by cloud profile aws:
+VERSION   CLASSIFICATION   EXPIRATION   UPGRADE
+1.19.1    supported        -            skips minor version
+1.18.5    preview          -            allowed
+1.18.2    supported        -            allowed
+1.17.10   preview          -            allowed
+1.17.9    supported        -            allowed
+1.17.7    deprecated       2000-01-01   expired
+1.17.5    deprecated       2099-06-30   current
+1.16.9    supported        -            downgrade
+`))
+	})
+
+	It("should show the diff to the next patch version without applying it", func() {
+		out, err := execute("--next-patch", "--dry-run")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot garden-prod/test-shoot:\n  spec:\n    kubernetes:\n-     version: 1.17.5\n+     version: 1.17.9\n"))
+		Expect(shootVersion()).To(Equal("1.17.5"))
+	})
+
+	It("should upgrade to the next minor version and wait for the reconciliation", func() {
+		out, err := execute("--next-minor", "--wait")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("+     version: 1.18.2\n"))
+		Expect(out).To(HaveSuffix("Shoot garden-prod/test-shoot has been upgraded to Kubernetes 1.18.2\n"))
+		Expect(shootVersion()).To(Equal("1.18.2"))
+	})
+
+	It("should reject skipping a minor version", func() {
+		_, err := execute("--to", "1.19.1")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("cannot upgrade shoot garden-prod/test-shoot from 1.17.5 to 1.19.1: skips minor version"))
+	})
+
+	It("should reject expired versions", func() {
+		_, err := execute("--to", "v1.17.7")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("cannot upgrade shoot garden-prod/test-shoot from 1.17.5 to 1.17.7: expired"))
+	})
+
+	It("should reject versions not offered by the cloud profile", func() {
+		_, err := execute("--to", "1.18.3")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("version 1.18.3 is not offered by cloud profile aws"))
+	})
+})
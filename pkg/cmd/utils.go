@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,10 +28,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	gardenerlogger "github.com/gardener/gardener/pkg/logger"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	restclient "k8s.io/client-go/rest"
@@ -38,6 +42,15 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// tableOutputFormat is used by commands which render tables unless an output format is given explicitly
+const tableOutputFormat = "table"
+
+var (
+	answerReadersLock sync.Mutex
+	// answerReaders holds one buffered reader per input stream, so that no input is lost between questions
+	answerReaders = map[io.Reader]*bufio.Reader{}
+)
+
 // checkError checks if an error during execution occurred
 func checkError(err error) {
 	if err != nil {
@@ -333,6 +346,32 @@ func CheckToolInstalled(cliName string) bool {
 	return true
 }
 
+//outputFormatOrTable returns the output format given via --output, or "table" if the flag was not set explicitly
+func outputFormatOrTable(cmd *cobra.Command) string {
+	if flag := cmd.Flag("output"); flag != nil && flag.Changed {
+		return outputFormat
+	}
+	return tableOutputFormat
+}
+
+// isTerminal returns whether the writer is a terminal
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	return ok && isatty.IsTerminal(file.Fd())
+}
+
+// syncWriter serializes writes of concurrent shoot operations
+type syncWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writer.Write(p)
+}
+
 //PrintoutObject print object in yaml or json format. Pass os.Stdout if desired
 func PrintoutObject(objectToPrint interface{}, writer io.Writer, outputFormat string) error {
 	if outputFormat == "yaml" {
@@ -370,3 +409,30 @@ func CheckIPPortReachable(ip string, port string) error {
 	}
 	return fmt.Errorf("IP %s port %s is not reachable", ip, port)
 }
+
+// askUser prints the question and returns the line the user answered with
+func askUser(question string, ioStreams IOStreams) (string, error) {
+	answerReadersLock.Lock()
+	reader, ok := answerReaders[ioStreams.In]
+	if !ok {
+		reader = bufio.NewReader(ioStreams.In)
+		answerReaders[ioStreams.In] = reader
+	}
+	answerReadersLock.Unlock()
+
+	fmt.Fprint(ioStreams.Out, question)
+	answer, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// confirm asks the user the question and returns whether the answer is yes
+func confirm(question string, ioStreams IOStreams) (bool, error) {
+	answer, err := askUser(question, ioStreams)
+	if err != nil {
+		return false, err
+	}
+	return answer == "yes", nil
+}
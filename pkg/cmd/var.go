@@ -45,6 +45,7 @@ var (
 	pathGardenConfig   string
 	pathTarget         string
 	pathHistory        string
+	pathAudit          string
 	pathDefault        = filepath.Join(HomeDir(), ".garden")
 	pathDefaultSession = filepath.Join(HomeDir(), ".garden", "sessions")
 )
@@ -0,0 +1,211 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// exitCodeFailed is the exit code if the awaited state of a shoot cannot be reached anymore
+	exitCodeFailed = 3
+	// exitCodeTimeout is the exit code if the awaited state of a shoot is not reached in time
+	exitCodeTimeout = 4
+)
+
+// exitError is an error which makes gardenctl exit with the given code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code of gardenctl for the error returned by a command
+func ExitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
+
+// NewWaitCmd returns a new wait command.
+func NewWaitCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Wait until a resource reaches a state, e.g. \"gardenctl wait shoot my-shoot --for=operation=Succeeded\"",
+	}
+	cmd.AddCommand(newWaitShootCmd(targetReader, ioStreams))
+
+	return cmd
+}
+
+func newWaitShootCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		waitFor string
+		timeout time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "shoot [name]",
+		Short: "Wait until the targeted or given shoot reaches a state, e.g. \"gardenctl wait shoot my-shoot --for=condition=APIServerAvailable --timeout=10m\"",
+		Long: `Wait until the targeted or given shoot reaches a state. The shoot is watched on the garden cluster and its progress is printed to stderr.
+
+Supported states:
+  --for=condition=<type>[=<status>]  the condition of the shoot has the status, True by default
+  --for=operation=<state>            the last operation of the shoot is in the state, for Succeeded also the latest spec must be reconciled
+  --for=hibernated=<true|false>      the shoot is hibernated or woken up
+  --for=delete                       the shoot is deleted
+
+Exit codes:
+  0  the state has been reached
+  1  the state could not be awaited, e.g. because the shoot does not exist
+  3  the state cannot be reached anymore, e.g. because the last operation failed
+  4  the state has not been reached within --timeout`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("command must be in the format: wait shoot [name] --for=<state>")
+			}
+			condition, deletion, err := parseShootWaitCondition(waitFor)
+			if err != nil {
+				return err
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			target := targetReader.ReadTarget(pathTarget)
+			if len(target.Stack()) < 1 {
+				return errors.New("no garden cluster targeted")
+			}
+			gardenClientset, shoots, err := resolveShoots(target, name, "")
+			if deletion && apierrors.IsNotFound(err) {
+				if name == "" {
+					name = target.Stack()[len(target.Stack())-1].Name
+				}
+				fmt.Fprintf(ioStreams.Out, "Shoot %s has been deleted\n", name)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			shoot := &shoots[0]
+
+			// the generation is only known once the shoot has been read
+			if condition == nil {
+				condition = failedWaitCondition(lastOperationSucceeded(shoot.Generation))
+			}
+			_, err = waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, condition, timeout, ioStreams.ErrOut)
+			switch {
+			case deletion && errors.Is(err, errShootDeleted):
+				fmt.Fprintf(ioStreams.Out, "Shoot %s has been deleted\n", shootKey(shoot))
+				return nil
+			case errors.Is(err, errWaitTimeout):
+				return &exitError{code: exitCodeTimeout, err: err}
+			case errors.Is(err, errShootDeleted):
+				return &exitError{code: exitCodeFailed, err: err}
+			case err != nil:
+				return err
+			}
+			fmt.Fprintf(ioStreams.Out, "Shoot %s reached %s\n", shootKey(shoot), waitFor)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&waitFor, "for", "", "the state to wait for: condition=<type>[=<status>], operation=<state>, hibernated=<true|false> or delete")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute, "maximum time to wait")
+
+	return cmd
+}
+
+// parseShootWaitCondition returns the condition for the value of the --for flag and whether it waits for the deletion.
+// The condition is nil for operation=Succeeded because it depends on the generation of the shoot.
+func parseShootWaitCondition(waitFor string) (shootWaitCondition, bool, error) {
+	if waitFor == "delete" {
+		return failedWaitCondition(shootDeletionFailed), true, nil
+	}
+
+	parts := strings.SplitN(waitFor, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, false, fmt.Errorf("invalid --for %q, must be one of condition=<type>[=<status>], operation=<state>, hibernated=<true|false> or delete", waitFor)
+	}
+	switch key, value := parts[0], parts[1]; key {
+	case "condition":
+		conditionType, status := value, gardencorev1beta1.ConditionTrue
+		if i := strings.Index(value, "="); i >= 0 {
+			conditionType, status = value[:i], gardencorev1beta1.ConditionStatus(value[i+1:])
+		}
+		return func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+			condition := getCondition(shoot.Status.Conditions, gardencorev1beta1.ConditionType(conditionType))
+			if condition == nil {
+				condition = getCondition(shoot.Status.Constraints, gardencorev1beta1.ConditionType(conditionType))
+			}
+			return condition != nil && strings.EqualFold(string(condition.Status), string(status)), nil
+		}, false, nil
+
+	case "operation":
+		if strings.EqualFold(value, string(gardencorev1beta1.LastOperationStateSucceeded)) {
+			return nil, false, nil
+		}
+		return func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+			lastOperation := shoot.Status.LastOperation
+			return lastOperation != nil && strings.EqualFold(string(lastOperation.State), value), nil
+		}, false, nil
+
+	case "hibernated":
+		hibernated, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid --for %q, hibernated must be true or false", waitFor)
+		}
+		return failedWaitCondition(func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+			if shoot.Status.IsHibernated == hibernated {
+				return true, nil
+			}
+			// a failed hibernation or wake up is not retried without a change of the shoot
+			if isHibernationEnabled(shoot) == hibernated && shoot.Status.ObservedGeneration >= shoot.Generation {
+				_, err := lastOperationResult(shoot)
+				return false, err
+			}
+			return false, nil
+		}), false, nil
+	}
+	return nil, false, fmt.Errorf("invalid --for %q, must be one of condition=<type>[=<status>], operation=<state>, hibernated=<true|false> or delete", waitFor)
+}
+
+// failedWaitCondition returns the errors of the condition, which mean that it cannot be met anymore, with exitCodeFailed
+func failedWaitCondition(condition shootWaitCondition) shootWaitCondition {
+	return func(shoot *gardencorev1beta1.Shoot) (bool, error) {
+		done, err := condition(shoot)
+		if err != nil {
+			return done, &exitError{code: exitCodeFailed, err: err}
+		}
+		return done, nil
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Wait shoot command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+		watcher      *watch.FakeWatcher
+		stack        []cmd.TargetMeta
+	)
+
+	projectNamespace := "garden-prod"
+	newShoot := func(status gardencorev1beta1.ShootStatus) *gardencorev1beta1.Shoot {
+		return &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace, Generation: 2},
+			Status:     status,
+		}
+	}
+	processing := gardencorev1beta1.ShootStatus{
+		ObservedGeneration: 2,
+		LastOperation:      &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateProcessing, Progress: 50},
+	}
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewWaitCmd(targetReader, ioStreams)
+		command.SetArgs(append([]string{"shoot"}, args...))
+		err := command.Execute()
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			newShoot(processing),
+		)
+		watcher = watch.NewFake()
+		clientSet.PrependWatchReactor("shoots", k8stesting.DefaultWatchReactor(watcher, nil))
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+		}
+		target.EXPECT().Stack().DoAndReturn(func() []cmd.TargetMeta { return stack }).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should wait until the condition is true", func() {
+		go func() {
+			defer GinkgoRecover()
+			status := processing
+			status.Conditions = []gardencorev1beta1.Condition{{Type: gardencorev1beta1.ShootAPIServerAvailable, Status: gardencorev1beta1.ConditionFalse}}
+			watcher.Modify(newShoot(status))
+			status.Conditions = []gardencorev1beta1.Condition{{Type: gardencorev1beta1.ShootAPIServerAvailable, Status: gardencorev1beta1.ConditionTrue}}
+			watcher.Modify(newShoot(status))
+		}()
+
+		out, err := execute("test-shoot", "--for=condition=APIServerAvailable")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot garden-prod/test-shoot reached condition=APIServerAvailable\n"))
+	})
+
+	It("should wait until the last operation succeeded", func() {
+		go func() {
+			defer GinkgoRecover()
+			watcher.Modify(newShoot(gardencorev1beta1.ShootStatus{
+				ObservedGeneration: 2,
+				LastOperation:      &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+			}))
+		}()
+
+		out, err := execute("test-shoot", "--for=operation=Succeeded")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot garden-prod/test-shoot reached operation=Succeeded\n"))
+	})
+
+	It("should read the shoot again if the resource version of the watch expired", func() {
+		go func() {
+			defer GinkgoRecover()
+			_, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Update(newShoot(gardencorev1beta1.ShootStatus{
+				ObservedGeneration: 2,
+				LastOperation:      &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateSucceeded, Progress: 100},
+			}))
+			Expect(err).NotTo(HaveOccurred())
+			watcher.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+		}()
+
+		out, err := execute("test-shoot", "--for=operation=Succeeded", "--timeout=10s")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot garden-prod/test-shoot reached operation=Succeeded\n"))
+	})
+
+	It("should exit with the failure code if the last operation failed", func() {
+		go func() {
+			defer GinkgoRecover()
+			watcher.Modify(newShoot(gardencorev1beta1.ShootStatus{
+				ObservedGeneration: 2,
+				LastOperation:      &gardencorev1beta1.LastOperation{Type: gardencorev1beta1.LastOperationTypeReconcile, State: gardencorev1beta1.LastOperationStateFailed, Description: "quota exceeded"},
+			}))
+		}()
+
+		_, err := execute("test-shoot", "--for=operation=Succeeded")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Reconcile of shoot garden-prod/test-shoot failed: quota exceeded"))
+		Expect(cmd.ExitCode(err)).To(Equal(3))
+	})
+
+	It("should exit with the timeout code if the state is not reached in time", func() {
+		_, err := execute("test-shoot", "--for=hibernated=true", "--timeout=10ms")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("timed out waiting for shoot garden-prod/test-shoot"))
+		Expect(cmd.ExitCode(err)).To(Equal(4))
+	})
+
+	It("should wait until the shoot is deleted", func() {
+		go func() {
+			defer GinkgoRecover()
+			watcher.Delete(newShoot(processing))
+		}()
+
+		out, err := execute("test-shoot", "--for=delete")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot garden-prod/test-shoot has been deleted\n"))
+	})
+
+	It("should succeed if the given shoot is already deleted and no project is targeted", func() {
+		stack = []cmd.TargetMeta{{Kind: cmd.TargetKindGarden, Name: "test-garden"}}
+
+		out, err := execute("gone", "--for=delete")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot gone has been deleted\n"))
+	})
+
+	It("should succeed if the shoot targeted via its seed is already deleted", func() {
+		stack = []cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindSeed, Name: "test-seed"},
+			{Kind: cmd.TargetKindShoot, Name: "gone"},
+		}
+
+		out, err := execute("--for=delete")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("Shoot gone has been deleted\n"))
+	})
+
+	It("should exit with the failure code if the shoot is deleted while waiting for another state", func() {
+		go func() {
+			defer GinkgoRecover()
+			watcher.Delete(newShoot(processing))
+		}()
+
+		_, err := execute("test-shoot", "--for=hibernated=true")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("shoot garden-prod/test-shoot has been deleted"))
+		Expect(cmd.ExitCode(err)).To(Equal(3))
+	})
+
+	It("should reject unknown states", func() {
+		_, err := execute("test-shoot", "--for=ready")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(`invalid --for "ready"`))
+		Expect(cmd.ExitCode(err)).To(Equal(1))
+	})
+})
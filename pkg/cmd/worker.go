@@ -0,0 +1,402 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencoreclientset "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// workerChange is the change of a field of a worker pool, the path is relative to the worker pool
+type workerChange struct {
+	path     string
+	old, new interface{}
+}
+
+// workerChangeFlags are the flags of the worker commands changing a worker pool
+type workerChangeFlags struct {
+	shootChangeFlags
+	dryRun bool
+}
+
+// NewWorkerCmd returns a new worker command.
+func NewWorkerCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "List and change the worker pools of the targeted shoot, e.g. \"gardenctl worker scale my-pool --min 3 --max 10\"",
+	}
+	cmd.AddCommand(newWorkerLsCmd(targetReader, ioStreams))
+	cmd.AddCommand(newWorkerScaleCmd(targetReader, configReader, ioStreams))
+	cmd.AddCommand(newWorkerSetCmd(targetReader, configReader, ioStreams))
+
+	return cmd
+}
+
+func newWorkerLsCmd(targetReader TargetReader, ioStreams IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:          "ls",
+		Short:        "List the worker pools of the targeted shoot",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("command must be in the format: worker ls")
+			}
+			target := targetReader.ReadTarget(pathTarget)
+			if !CheckShootIsTargeted(target) {
+				return errors.New("no shoot targeted")
+			}
+			_, shoots, err := resolveShoots(target, "", "")
+			if err != nil {
+				return err
+			}
+
+			pools := toWorkerPools(&shoots[0])
+			outFormat := outputFormatOrTable(cmd)
+			if outFormat == tableOutputFormat {
+				return renderWorkerPools(pools, ioStreams.Out)
+			}
+			return PrintoutObject(pools, ioStreams.Out, outFormat)
+		},
+	}
+}
+
+func newWorkerScaleCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		flags    workerChangeFlags
+		min, max int32
+	)
+	cmd := &cobra.Command{
+		Use:          "scale <pool>",
+		Short:        "Change the autoscaler bounds of a worker pool of the targeted shoot, e.g. \"gardenctl worker scale my-pool --min 3 --max 10 --dry-run\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: worker scale <pool> [--min <minimum>] [--max <maximum>]")
+			}
+			if !cmd.Flags().Changed("min") && !cmd.Flags().Changed("max") {
+				return errors.New("at least one of --min and --max must be given")
+			}
+
+			return changeWorkerPool(targetReader, configReader, args[0], flags, ioStreams, func(worker *gardencorev1beta1.Worker, _ *gardencorev1beta1.Shoot, _ *gardencorev1beta1.CloudProfile) ([]workerChange, error) {
+				minimum, maximum := worker.Minimum, worker.Maximum
+				if cmd.Flags().Changed("min") {
+					minimum = min
+				}
+				if cmd.Flags().Changed("max") {
+					maximum = max
+				}
+				if err := validateWorkerScaling(minimum, maximum, len(worker.Zones)); err != nil {
+					return nil, fmt.Errorf("cannot scale worker pool %s: %v", worker.Name, err)
+				}
+
+				var changes []workerChange
+				if minimum != worker.Minimum {
+					changes = append(changes, workerChange{path: "minimum", old: worker.Minimum, new: minimum})
+				}
+				if maximum != worker.Maximum {
+					changes = append(changes, workerChange{path: "maximum", old: worker.Maximum, new: maximum})
+				}
+				return changes, nil
+			})
+		},
+	}
+	cmd.Flags().Int32Var(&min, "min", 0, "minimum number of nodes of the worker pool")
+	cmd.Flags().Int32Var(&max, "max", 0, "maximum number of nodes of the worker pool")
+	flags.addFlags(cmd)
+
+	return cmd
+}
+
+func newWorkerSetCmd(targetReader TargetReader, configReader ConfigReader, ioStreams IOStreams) *cobra.Command {
+	var (
+		flags                     workerChangeFlags
+		machineType, imageVersion string
+	)
+	cmd := &cobra.Command{
+		Use:          "set <pool>",
+		Short:        "Change the machine type or image version of a worker pool of the targeted shoot, e.g. \"gardenctl worker set my-pool --machine-type m5.xlarge\"",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("command must be in the format: worker set <pool> [--machine-type <type>] [--image-version <version>]")
+			}
+			if machineType == "" && imageVersion == "" {
+				return errors.New("at least one of --machine-type and --image-version must be given")
+			}
+
+			return changeWorkerPool(targetReader, configReader, args[0], flags, ioStreams, func(worker *gardencorev1beta1.Worker, shoot *gardencorev1beta1.Shoot, cloudProfile *gardencorev1beta1.CloudProfile) ([]workerChange, error) {
+				var changes []workerChange
+				if machineType != "" && machineType != worker.Machine.Type {
+					if err := validateMachineType(cloudProfile, shoot.Spec.Region, worker.Zones, machineType); err != nil {
+						return nil, err
+					}
+					changes = append(changes, workerChange{path: "machine/type", old: worker.Machine.Type, new: machineType})
+				}
+				if imageVersion != "" {
+					image := worker.Machine.Image
+					if image == nil {
+						return nil, fmt.Errorf("worker pool %s has no machine image", worker.Name)
+					}
+					if imageVersion != stringValue(image.Version) {
+						if err := validateMachineImageVersion(cloudProfile, image.Name, imageVersion, time.Now()); err != nil {
+							return nil, err
+						}
+						changes = append(changes, workerChange{path: "machine/image/version", old: stringValue(image.Version), new: imageVersion})
+					}
+				}
+				return changes, nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&machineType, "machine-type", "", "machine type of the worker pool")
+	cmd.Flags().StringVar(&imageVersion, "image-version", "", "version of the machine image of the worker pool")
+	flags.addFlags(cmd)
+
+	return cmd
+}
+
+// addFlags adds the flags to the command
+func (f *workerChangeFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "only show the change to the shoot")
+	f.addSingleShootFlags(cmd)
+}
+
+// changeWorkerPool computes the changes of the worker pool of the targeted shoot, shows them and applies them
+func changeWorkerPool(targetReader TargetReader, configReader ConfigReader, pool string, flags workerChangeFlags, ioStreams IOStreams,
+	change func(worker *gardencorev1beta1.Worker, shoot *gardencorev1beta1.Shoot, cloudProfile *gardencorev1beta1.CloudProfile) ([]workerChange, error)) error {
+	target := targetReader.ReadTarget(pathTarget)
+	if !CheckShootIsTargeted(target) {
+		return errors.New("no shoot targeted")
+	}
+	gardenClientset, shoots, err := resolveShoots(target, "", "")
+	if err != nil {
+		return err
+	}
+	shoot := &shoots[0]
+
+	index := -1
+	var names []string
+	for i, worker := range shoot.Spec.Provider.Workers {
+		names = append(names, worker.Name)
+		if worker.Name == pool {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("shoot %s has no worker pool %q, worker pools are: %s", shootKey(shoot), pool, strings.Join(names, ", "))
+	}
+	worker := &shoot.Spec.Provider.Workers[index]
+
+	cloudProfile, err := gardenClientset.CoreV1beta1().CloudProfiles().Get(shoot.Spec.CloudProfileName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	changes, err := change(worker, shoot, cloudProfile)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(ioStreams.Out, "Worker pool %s of shoot %s is unchanged\n", pool, shootKey(shoot))
+		return nil
+	}
+	renderWorkerChanges(shoot, pool, changes, ioStreams.Out)
+	if flags.dryRun {
+		return nil
+	}
+	if err := confirmShootRestrictions(target, configReader, shoots, flags.yes, ioStreams); err != nil {
+		return err
+	}
+
+	patch, err := workerPatch(index, pool, changes)
+	if err != nil {
+		return err
+	}
+	return patchWorkerPool(gardenClientset, shoot, pool, patch, flags.shootChangeFlags, ioStreams.Out)
+}
+
+// workerPatch returns a JSON patch which changes only the given fields of the worker pool, it fails if the
+// worker pools have been reordered in the meantime
+func workerPatch(index int, pool string, changes []workerChange) ([]byte, error) {
+	prefix := fmt.Sprintf("/spec/provider/workers/%d", index)
+	operations := []map[string]interface{}{{"op": "test", "path": prefix + "/name", "value": pool}}
+	for _, change := range changes {
+		operations = append(operations, map[string]interface{}{"op": "add", "path": prefix + "/" + change.path, "value": change.new})
+	}
+	return json.Marshal(operations)
+}
+
+// patchWorkerPool applies the patch to the shoot and optionally waits for the reconciliation
+func patchWorkerPool(gardenClientset gardencoreclientset.Interface, shoot *gardencorev1beta1.Shoot, pool string, patch []byte, flags shootChangeFlags, writer io.Writer) error {
+	patched, err := gardenClientset.CoreV1beta1().Shoots(shoot.Namespace).Patch(shoot.Name, types.JSONPatchType, patch)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Worker pool %s of shoot %s will be changed\n", pool, shootKey(shoot))
+	if !flags.wait {
+		return nil
+	}
+
+	if _, err := waitForShoot(gardenClientset, shoot.Namespace, shoot.Name, lastOperationSucceeded(patched.Generation), flags.timeout, writer); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Worker pool %s of shoot %s has been changed\n", pool, shootKey(shoot))
+
+	return nil
+}
+
+// validateWorkerScaling checks the autoscaler bounds like the gardener apiserver does
+func validateWorkerScaling(minimum, maximum int32, zones int) error {
+	switch {
+	case minimum < 0:
+		return fmt.Errorf("minimum %d must not be negative", minimum)
+	case maximum < minimum:
+		return fmt.Errorf("maximum %d must not be less than minimum %d", maximum, minimum)
+	case maximum != 0 && int(maximum) < zones:
+		return fmt.Errorf("maximum %d must not be less than the number of zones %d", maximum, zones)
+	}
+	return nil
+}
+
+// validateMachineType checks that the cloud profile offers the machine type in all zones of the worker pool
+func validateMachineType(cloudProfile *gardencorev1beta1.CloudProfile, region string, zones []string, machineType string) error {
+	found := false
+	for _, offered := range cloudProfile.Spec.MachineTypes {
+		if offered.Name != machineType {
+			continue
+		}
+		if offered.Usable != nil && !*offered.Usable {
+			return fmt.Errorf("machine type %s of cloud profile %s is not usable", machineType, cloudProfile.Name)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("machine type %s is not offered by cloud profile %s", machineType, cloudProfile.Name)
+	}
+
+	for _, offered := range cloudProfile.Spec.Regions {
+		if offered.Name != region {
+			continue
+		}
+		for _, zone := range offered.Zones {
+			if containsString(zones, zone.Name) && containsString(zone.UnavailableMachineTypes, machineType) {
+				return fmt.Errorf("machine type %s is not available in zone %s", machineType, zone.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateMachineImageVersion checks that the cloud profile offers the version of the machine image and that it has not expired
+func validateMachineImageVersion(cloudProfile *gardencorev1beta1.CloudProfile, image, version string, now time.Time) error {
+	for _, offered := range cloudProfile.Spec.MachineImages {
+		if offered.Name != image {
+			continue
+		}
+		for _, expirable := range offered.Versions {
+			if expirable.Version != version {
+				continue
+			}
+			if expirable.ExpirationDate != nil && expirable.ExpirationDate.Time.Before(now) {
+				return fmt.Errorf("version %s of machine image %s expired on %s", version, image, expirable.ExpirationDate.Format("2006-01-02"))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("version %s of machine image %s is not offered by cloud profile %s", version, image, cloudProfile.Name)
+}
+
+// toWorkerPools returns the worker pools of the shoot
+func toWorkerPools(shoot *gardencorev1beta1.Shoot) *WorkerPools {
+	pools := &WorkerPools{Shoot: shootKey(shoot)}
+	for _, worker := range shoot.Spec.Provider.Workers {
+		meta := WorkerPoolMeta{
+			Name:        worker.Name,
+			Minimum:     worker.Minimum,
+			Maximum:     worker.Maximum,
+			MachineType: worker.Machine.Type,
+			Zones:       worker.Zones,
+		}
+		if worker.MaxSurge != nil {
+			meta.MaxSurge = worker.MaxSurge.String()
+		}
+		if worker.MaxUnavailable != nil {
+			meta.MaxUnavailable = worker.MaxUnavailable.String()
+		}
+		if worker.Machine.Image != nil {
+			meta.Image = worker.Machine.Image.Name
+			meta.ImageVersion = stringValue(worker.Machine.Image.Version)
+		}
+		if worker.Volume != nil {
+			meta.VolumeName = stringValue(worker.Volume.Name)
+			meta.VolumeType = stringValue(worker.Volume.Type)
+			meta.VolumeSize = worker.Volume.VolumeSize
+		}
+		pools.Pools = append(pools.Pools, meta)
+	}
+	return pools
+}
+
+// renderWorkerPools renders a table of the worker pools
+func renderWorkerPools(pools *WorkerPools, writer io.Writer) error {
+	w := tabwriter.NewWriter(writer, 6, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMIN\tMAX\tSURGE\tUNAVAILABLE\tMACHINE TYPE\tIMAGE\tZONES")
+	for _, pool := range pools.Pools {
+		image := strings.TrimSuffix(pool.Image+":"+pool.ImageVersion, ":")
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", pool.Name, pool.Minimum, pool.Maximum, valueOrDash(pool.MaxSurge),
+			valueOrDash(pool.MaxUnavailable), pool.MachineType, valueOrDash(image), valueOrDash(strings.Join(pool.Zones, ",")))
+	}
+	return w.Flush()
+}
+
+// renderWorkerChanges prints the changes of the worker pool as a diff of the shoot
+func renderWorkerChanges(shoot *gardencorev1beta1.Shoot, pool string, changes []workerChange, writer io.Writer) {
+	fmt.Fprintf(writer, "Shoot %s:\n  spec:\n    provider:\n      workers:\n      - name: %s\n", shootKey(shoot), pool)
+	var parents []string
+	for _, change := range changes {
+		keys := strings.Split(change.path, "/")
+		// only print the parent keys which have not been printed for the previous change
+		common := 0
+		for common < len(parents) && common < len(keys)-1 && parents[common] == keys[common] {
+			common++
+		}
+		for depth := common; depth < len(keys)-1; depth++ {
+			fmt.Fprintf(writer, "%s%s:\n", strings.Repeat(" ", 8+2*depth), keys[depth])
+		}
+		parents = keys[:len(keys)-1]
+
+		indent := strings.Repeat(" ", 7+2*(len(keys)-1))
+		key := keys[len(keys)-1]
+		fmt.Fprintf(writer, "-%s%s: %v\n+%s%s: %v\n", indent, key, change.old, indent, key, change.new)
+	}
+}
+
+// valueOrDash returns the value or a dash if it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
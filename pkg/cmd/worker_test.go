@@ -0,0 +1,218 @@
+// Copyright (c) 2020 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"github.com/gardener/gardenctl/pkg/cmd"
+	mockcmd "github.com/gardener/gardenctl/pkg/mock/cmd"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	gardencorefake "github.com/gardener/gardener/pkg/client/core/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Worker command", func() {
+	var (
+		ctrl         *gomock.Controller
+		targetReader *mockcmd.MockTargetReader
+		configReader *mockcmd.MockConfigReader
+		target       *mockcmd.MockTargetInterface
+		clientSet    *gardencorefake.Clientset
+	)
+
+	projectNamespace := "garden-prod"
+	imageVersion := "27.1.0"
+	volumeType := "gp2"
+	surge := intstr.FromInt(1)
+	unavailable := intstr.FromString("10%")
+
+	execute := func(args ...string) (string, error) {
+		ioStreams, _, out, _ := cmd.NewTestIOStreams()
+		command := cmd.NewWorkerCmd(targetReader, configReader, ioStreams)
+		command.SetArgs(args)
+		err := command.Execute()
+		return out.String(), err
+	}
+	getWorkers := func() []gardencorev1beta1.Worker {
+		shoot, err := clientSet.CoreV1beta1().Shoots(projectNamespace).Get("test-shoot", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return shoot.Spec.Provider.Workers
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		targetReader = mockcmd.NewMockTargetReader(ctrl)
+		configReader = mockcmd.NewMockConfigReader(ctrl)
+		target = mockcmd.NewMockTargetInterface(ctrl)
+
+		clientSet = gardencorefake.NewSimpleClientset(
+			&gardencorev1beta1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec:       gardencorev1beta1.ProjectSpec{Namespace: &projectNamespace},
+			},
+			&gardencorev1beta1.Shoot{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: projectNamespace},
+				Spec: gardencorev1beta1.ShootSpec{
+					CloudProfileName: "aws",
+					Region:           "eu-west-1",
+					Provider: gardencorev1beta1.Provider{
+						Workers: []gardencorev1beta1.Worker{
+							{
+								Name:           "cpu",
+								Minimum:        1,
+								Maximum:        3,
+								MaxSurge:       &surge,
+								MaxUnavailable: &unavailable,
+								Machine:        gardencorev1beta1.Machine{Type: "m5.large", Image: &gardencorev1beta1.ShootMachineImage{Name: "gardenlinux", Version: &imageVersion}},
+								Volume:         &gardencorev1beta1.Volume{Type: &volumeType, VolumeSize: "50Gi"},
+								Zones:          []string{"eu-west-1a", "eu-west-1b"},
+							},
+							{
+								Name:    "gpu",
+								Machine: gardencorev1beta1.Machine{Type: "p2.xlarge"},
+								Zones:   []string{"eu-west-1b"},
+							},
+						},
+					},
+				},
+			},
+			&gardencorev1beta1.CloudProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws"},
+				Spec: gardencorev1beta1.CloudProfileSpec{
+					MachineImages: []gardencorev1beta1.MachineImage{
+						{Name: "gardenlinux", Versions: []gardencorev1beta1.ExpirableVersion{{Version: "27.1.0"}, {Version: "184.0.0"}}},
+					},
+					MachineTypes: []gardencorev1beta1.MachineType{
+						{Name: "m5.large", CPU: resource.MustParse("2"), Memory: resource.MustParse("8Gi")},
+						{Name: "m5.xlarge", CPU: resource.MustParse("4"), Memory: resource.MustParse("16Gi")},
+						{Name: "p2.xlarge", CPU: resource.MustParse("4"), Memory: resource.MustParse("61Gi")},
+					},
+					Regions: []gardencorev1beta1.Region{
+						{Name: "eu-west-1", Zones: []gardencorev1beta1.AvailabilityZone{{Name: "eu-west-1a", UnavailableMachineTypes: []string{"p2.xlarge"}}, {Name: "eu-west-1b"}}},
+					},
+				},
+			},
+		)
+
+		targetReader.EXPECT().ReadTarget(gomock.Any()).Return(target).AnyTimes()
+		target.EXPECT().Stack().Return([]cmd.TargetMeta{
+			{Kind: cmd.TargetKindGarden, Name: "test-garden"},
+			{Kind: cmd.TargetKindProject, Name: "prod"},
+			{Kind: cmd.TargetKindShoot, Name: "test-shoot"},
+		}).AnyTimes()
+		target.EXPECT().GardenerClient().Return(clientSet, nil).AnyTimes()
+		configReader.EXPECT().ReadConfig(gomock.Any()).Return(&cmd.GardenConfig{}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should list the worker pools", func() {
+		out, err := execute("ls")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`NAME   MIN   MAX   SURGE   UNAVAILABLE   MACHINE TYPE   IMAGE                ZONES
+cpu    1     3     1       10%           m5.large       gardenlinux:27.1.0   eu-west-1a,eu-west-1b
+gpu    0     0     -       -             p2.xlarge      -                    eu-west-1b
+`))
+	})
+
+	It("should show the scaling diff without applying it", func() {
+		out, err := execute("scale", "cpu", "--min", "2", "--max", "10", "--dry-run")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`Shoot garden-prod/test-shoot:
+  spec:
+    provider:
+      workers:
+      - name: cpu
+-       minimum: 1
++       minimum: 2
+-       maximum: 3
++       maximum: 10
+`))
+		Expect(getWorkers()[0].Maximum).To(Equal(int32(3)))
+	})
+
+	It("should scale the worker pool and preserve the rest of the spec", func() {
+		_, err := execute("scale", "cpu", "--max", "10")
+
+		Expect(err).NotTo(HaveOccurred())
+		workers := getWorkers()
+		Expect(workers).To(HaveLen(2))
+		Expect(workers[0].Minimum).To(Equal(int32(1)))
+		Expect(workers[0].Maximum).To(Equal(int32(10)))
+		Expect(workers[0].Volume.VolumeSize).To(Equal("50Gi"))
+		Expect(*workers[0].MaxUnavailable).To(Equal(unavailable))
+		Expect(workers[1].Name).To(Equal("gpu"))
+	})
+
+	It("should reject a maximum below the number of zones", func() {
+		_, err := execute("scale", "cpu", "--min", "1", "--max", "1")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("cannot scale worker pool cpu: maximum 1 must not be less than the number of zones 2"))
+	})
+
+	It("should set the machine type and image version", func() {
+		out, err := execute("set", "cpu", "--machine-type", "m5.xlarge", "--image-version", "184.0.0")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(`Shoot garden-prod/test-shoot:
+  spec:
+    provider:
+      workers:
+      - name: cpu
+        machine:
+-         type: m5.large
++         type: m5.xlarge
+          image:
+-           version: 27.1.0
++           version: 184.0.0
+Worker pool cpu of shoot garden-prod/test-shoot will be changed
+`))
+		workers := getWorkers()
+		Expect(workers[0].Machine.Type).To(Equal("m5.xlarge"))
+		Expect(workers[0].Machine.Image.Name).To(Equal("gardenlinux"))
+		Expect(*workers[0].Machine.Image.Version).To(Equal("184.0.0"))
+	})
+
+	It("should reject machine types which are not available in the zones of the pool", func() {
+		_, err := execute("set", "cpu", "--machine-type", "p2.xlarge")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("machine type p2.xlarge is not available in zone eu-west-1a"))
+	})
+
+	It("should reject image versions not offered by the cloud profile", func() {
+		_, err := execute("set", "cpu", "--image-version", "1.0.0")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("version 1.0.0 of machine image gardenlinux is not offered by cloud profile aws"))
+	})
+
+	It("should name the existing worker pools for unknown pools", func() {
+		_, err := execute("scale", "mem", "--max", "5")
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(`shoot garden-prod/test-shoot has no worker pool "mem", worker pools are: cpu, gpu`))
+	})
+})
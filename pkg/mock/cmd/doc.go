@@ -19,5 +19,9 @@
 //go:generate mockgen -package cmd -destination=kubeconfig_writer.go github.com/gardener/gardenctl/pkg/cmd KubeconfigWriter
 //go:generate mockgen -package cmd -destination=config_reader.go github.com/gardener/gardenctl/pkg/cmd ConfigReader
 //go:generate mockgen -package cmd -destination=history_writer.go github.com/gardener/gardenctl/pkg/cmd HistoryWriter
+//go:generate mockgen -package cmd -destination=prompter.go github.com/gardener/gardenctl/pkg/cmd Prompter
+//go:generate mockgen -package cmd -destination=editor.go github.com/gardener/gardenctl/pkg/cmd Editor
+//go:generate mockgen -package cmd -destination=port_forwarder.go github.com/gardener/gardenctl/pkg/cmd PortForwarder
+//go:generate mockgen -package cmd -destination=pod_executor.go github.com/gardener/gardenctl/pkg/cmd PodExecutor
 
 package cmd
@@ -7,8 +7,10 @@ package cmd
 import (
 	cmd "github.com/gardener/gardenctl/pkg/cmd"
 	versioned "github.com/gardener/gardener/pkg/client/core/clientset/versioned"
+	versioned0 "github.com/gardener/machine-controller-manager/pkg/client/clientset/versioned"
 	gomock "github.com/golang/mock/gomock"
 	kubernetes "k8s.io/client-go/kubernetes"
+	versioned1 "k8s.io/metrics/pkg/client/clientset/versioned"
 	reflect "reflect"
 )
 
@@ -95,6 +97,36 @@ func (mr *MockTargetInterfaceMockRecorder) Kind() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kind", reflect.TypeOf((*MockTargetInterface)(nil).Kind))
 }
 
+// MachineClient mocks base method
+func (m *MockTargetInterface) MachineClient() (versioned0.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineClient")
+	ret0, _ := ret[0].(versioned0.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MachineClient indicates an expected call of MachineClient
+func (mr *MockTargetInterfaceMockRecorder) MachineClient() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineClient", reflect.TypeOf((*MockTargetInterface)(nil).MachineClient))
+}
+
+// MetricsClient mocks base method
+func (m *MockTargetInterface) MetricsClient() (versioned1.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsClient")
+	ret0, _ := ret[0].(versioned1.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MetricsClient indicates an expected call of MetricsClient
+func (mr *MockTargetInterfaceMockRecorder) MetricsClient() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsClient", reflect.TypeOf((*MockTargetInterface)(nil).MetricsClient))
+}
+
 // SetStack mocks base method
 func (m *MockTargetInterface) SetStack(arg0 []cmd.TargetMeta) {
 	m.ctrl.T.Helper()